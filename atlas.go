@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+// writeAtlas writes every glyph as ASCII art into a text grid of the given
+// number of columns, each cell labelled with its codepoint. All cells share
+// the ink bounds of the font so the glyphs line up on a common baseline;
+// glyphs without a bitmap show as empty cells.
+func writeAtlas(filename string, font *gfx.Font, columns int) {
+	cells, width, height := asciiCells(font)
+	cellWidth := max(width, len("U+0000"))
+
+	var b strings.Builder
+	first, last := font.Range()
+	fmt.Fprintf(&b, "%d glyphs, 0x%04X-0x%04X, cells %dx%d\n", len(font.Glyphs), first, last, width, height)
+	for start := 0; start < len(font.Glyphs); start += columns {
+		end := min(start+columns, len(font.Glyphs))
+		var labels []string
+		for _, g := range font.Glyphs[start:end] {
+			labels = append(labels, fmt.Sprintf("%-*s", cellWidth, fmt.Sprintf("U+%04X", g.Code)))
+		}
+		fmt.Fprintf(&b, "\n%s\n", strings.TrimRight(strings.Join(labels, "  "), " "))
+		for y := 0; y < height; y++ {
+			var row []string
+			for _, cell := range cells[start:end] {
+				row = append(row, fmt.Sprintf("%-*s", cellWidth, cell[y]))
+			}
+			fmt.Fprintf(&b, "%s\n", strings.TrimRight(strings.Join(row, "  "), " "))
+		}
+	}
+	if err := writeOutput(filename, []byte(b.String())); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Wrote an atlas of %d glyphs to %s\n", len(font.Glyphs), filename)
+}
+
+// asciiCells renders every glyph of the font as the rows of an ASCII art
+// cell of the ink bounds of the font, which all cells share.
+func asciiCells(font *gfx.Font) (cells [][]string, width, height int) {
+	minX, maxX, minY, maxY := font.InkBounds()
+	width, height = maxX-minX, maxY-minY
+	cellFont := gfx.Font{Descent: -minY}
+
+	cells = make([][]string, len(font.Glyphs))
+	for i, g := range font.Glyphs {
+		shifted := *g
+		shifted.XOffset -= minX
+		bitmap, _ := cellFont.RenderCell(&shifted, width, height)
+		cell := &gfx.Glyph{Width: width, Height: height, Bitmap: bitmap}
+		cells[i] = strings.Split(strings.TrimSuffix(cell.ASCII(), "\n"), "\n")
+	}
+	return cells, width, height
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// hexCase classifies the letters of a hex string: "upper" or "lower" when
+// all its letters have that case, "mixed" when both occur and "" when it
+// has digits only.
+func hexCase(s string) string {
+	upper := strings.ContainsAny(s, "ABCDEF")
+	lower := strings.ContainsAny(s, "abcdef")
+	switch {
+	case upper && lower:
+		return "mixed"
+	case upper:
+		return "upper"
+	case lower:
+		return "lower"
+	}
+	return ""
+}
+
+// auditHexCase warns about the bitmap lines of a BDF whose hex letters do
+// not have the case most bitmap lines of the file use, which often shows
+// that the file was pieced together from several sources. Lines mixing
+// both cases are always reported. Nothing is rejected.
+func auditHexCase(filename string, data []byte) {
+	var lines []int
+	var cases []string
+	counts := map[string]int{}
+	insideBitmap := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "BITMAP":
+			insideBitmap = true
+		case line == "ENDCHAR" || strings.HasPrefix(line, "STARTCHAR"):
+			insideBitmap = false
+		case insideBitmap:
+			if c := hexCase(line); c != "" {
+				lines = append(lines, lineNo)
+				cases = append(cases, c)
+				counts[c]++
+			}
+		}
+	}
+	dominant := "upper"
+	if counts["lower"] > counts["upper"] {
+		dominant = "lower"
+	}
+	var odd []string
+	for i, c := range cases {
+		if c != dominant {
+			odd = append(odd, fmt.Sprint(lines[i]))
+		}
+	}
+	fmt.Printf("%s: hex casing of the bitmap lines with letters: %d uppercase, %d lowercase, %d mixed\n",
+		filename, counts["upper"], counts["lower"], counts["mixed"])
+	if n := len(odd); n > 0 {
+		if n > 20 {
+			odd = append(odd[:20], "...")
+		}
+		warnf("%s: %d bitmap lines differ from the dominant %scase hex, lines %s", filename, n, dominant, strings.Join(odd, ", "))
+	}
+}
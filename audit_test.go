@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestHexCase(t *testing.T) {
+	for s, want := range map[string]string{"F0": "upper", "f0": "lower", "Fa": "mixed", "00": ""} {
+		if got := hexCase(s); got != want {
+			t.Errorf("hexCase(%q) = %q, want %q", s, got, want)
+		}
+	}
+}
+
+func TestAuditHexCase(t *testing.T) {
+	glyph := func(code int, rows ...string) string {
+		return fmt.Sprintf("STARTCHAR g\nENCODING %d\nBBX 4 2 0 0\nBITMAP\n", code) + strings.Join(rows, "\n") + "\nENDCHAR\n"
+	}
+	// Line 13 is lowercase and line 21 mixed. The digits of line 14 and
+	// the keywords outside the bitmaps do not count.
+	data := "STARTFONT 2.1\n" + glyph(0x41, "F0", "A0") + glyph(0x42, "c0", "90") + glyph(0x43, "B0", "Ae") + "ENDFONT\n"
+
+	warnings := captureWarnings(t)
+	auditHexCase("font.bdf", []byte(data))
+	if want := "warning: font.bdf: 2 bitmap lines differ from the dominant uppercase hex, lines 13, 21\n"; warnings.String() != want {
+		t.Errorf("warnings %q, want %q", warnings, want)
+	}
+
+	warnings.Reset()
+	auditHexCase("font.bdf", []byte(strings.ReplaceAll(data, "c0", "C0")))
+	if strings.Contains(warnings.String(), "lines 13") {
+		t.Errorf("warnings %q, want only the mixed line", warnings)
+	}
+}
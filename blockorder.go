@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+// blockBitmapOrder returns the codes of the glyphs of font grouped by the
+// Unicode block of their codepoint, for -bitmap-order=block. Blocks with a
+// code in hot, frequency file codes most used first, come first in the
+// order of their most used code; the other blocks follow in code order.
+// Within a block the glyphs stay in code order.
+func blockBitmapOrder(font *gfx.Font, hot []int) []int {
+	rank := map[int]int{}
+	for _, code := range hot {
+		b, _ := gfx.BlockOf(code + font.CodeBase)
+		if _, ok := rank[b.First]; !ok {
+			rank[b.First] = len(rank)
+		}
+	}
+	rankOf := func(g *gfx.Glyph) int {
+		b, _ := gfx.BlockOf(g.Code + font.CodeBase)
+		if r, ok := rank[b.First]; ok {
+			return r
+		}
+		return len(rank)
+	}
+	glyphs := append([]*gfx.Glyph{}, font.Glyphs...)
+	sort.SliceStable(glyphs, func(i, j int) bool { return rankOf(glyphs[i]) < rankOf(glyphs[j]) })
+	codes := make([]int, len(glyphs))
+	for i, g := range glyphs {
+		codes[i] = g.Code
+	}
+	return codes
+}
+
+// printBlockLayout prints where the bitmaps of every Unicode block end up
+// when the glyphs of font are packed in the order of codes.
+func printBlockLayout(font *gfx.Font, codes []int, align int) {
+	var ordered []*gfx.Glyph
+	for _, code := range codes {
+		g, _ := font.Glyph(code)
+		ordered = append(ordered, g)
+	}
+	blob, offsets := gfx.PackGlyphs(ordered, align)
+	fmt.Println("Bitmap layout by Unicode block:")
+	for start := 0; start < len(ordered); {
+		block, _ := gfx.BlockOf(ordered[start].Code + font.CodeBase)
+		end := start + 1
+		for end < len(ordered) {
+			if b, _ := gfx.BlockOf(ordered[end].Code + font.CodeBase); b.First != block.First {
+				break
+			}
+			end++
+		}
+		stop := len(blob)
+		if end < len(ordered) {
+			stop = offsets[end]
+		}
+		fmt.Printf("  U+%04X-U+%04X %s: %d glyphs, bytes %d-%d\n", block.First, block.Last, block.Name, end-start, offsets[start], stop)
+		start = end
+	}
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestBlockBitmapOrder(t *testing.T) {
+	font := parseBDF(writeTestBDF(t, nil, 0x41, 0x42, 0xE9, 0x2190, 0xE000))
+	// On their own, blocks keep code order.
+	if got, want := blockBitmapOrder(font, nil), []int{0x41, 0x42, 0xE9, 0x2190, 0xE000}; !slices.Equal(got, want) {
+		t.Errorf("order %#x, want %#x", got, want)
+	}
+	// Used blocks come first by their most used code, each in code order.
+	if got, want := blockBitmapOrder(font, []int{0xE9, 0x2190, 0x42}), []int{0xE9, 0x2190, 0x41, 0x42, 0xE000}; !slices.Equal(got, want) {
+		t.Errorf("order %#x with frequencies, want %#x", got, want)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+// readPriorityFile reads a file of codepoints, most important first, in
+// the notation of parseCodepoint, separated by blanks or newlines, with
+// '#' starting a comment. Repeated codes keep their first place.
+func readPriorityFile(filename string) []int {
+	file, err := os.Open(filename)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	seen := map[int]bool{}
+	var codes []int
+	scanner := bufio.NewScanner(file)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		for _, field := range strings.Fields(line) {
+			code, err := parseCodepoint(field)
+			if err != nil {
+				log.Fatalf("%s:%d: %v", filename, lineNo, err)
+			}
+			if !seen[code] {
+				seen[code] = true
+				codes = append(codes, code)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatal(err)
+	}
+	return codes
+}
+
+// headerBytes is the flash taken on AVR by a standard GFX header of
+// glyphs: the packed bitmaps, a 7-byte GFXglyph for every code from the
+// first to the last glyph, and the GFXfont struct.
+func headerBytes(bitmaps, first, last int) int {
+	return bitmaps + 7*(last-first+1) + 9
+}
+
+// fitBudget keeps the glyphs of font in priority order, followed by the
+// glyphs missing from priority in code order, for as long as the header
+// fits maxBytes, and drops the glyph that would exceed it and all after
+// it. The gaps the kept glyphs leave in the glyph table are counted.
+func fitBudget(font *gfx.Font, maxBytes int, priority []int) {
+	var order []*gfx.Glyph
+	listed := map[*gfx.Glyph]bool{}
+	for _, code := range priority {
+		if g, ok := font.Glyph(code); ok && !listed[g] {
+			order = append(order, g)
+			listed[g] = true
+		}
+	}
+	for _, g := range font.Glyphs {
+		if !listed[g] {
+			order = append(order, g)
+		}
+	}
+
+	keep := map[*gfx.Glyph]bool{}
+	bitmaps, first, last, size := 0, 0, 0, 0
+	for i, g := range order {
+		b := bitmaps + len(g.PackedBitmap())
+		f, l := g.Code, g.Code
+		if i > 0 {
+			f, l = min(first, g.Code), max(last, g.Code)
+		}
+		s := headerBytes(b, f, l)
+		if s > maxBytes {
+			break
+		}
+		keep[g] = true
+		bitmaps, first, last, size = b, f, l, s
+	}
+	if len(keep) == 0 {
+		log.Fatalf("-max-bytes=%d does not fit a single glyph", maxBytes)
+	}
+	dropped := font.Drop(func(g *gfx.Glyph) bool { return !keep[g] })
+	if len(dropped) > 0 {
+		var list []string
+		for _, g := range dropped {
+			list = append(list, fmt.Sprintf("0x%04X", g.Code))
+		}
+		warnf("dropped %d glyphs over the -max-bytes budget: %s", len(dropped), strings.Join(list, ", "))
+	}
+	fmt.Printf("Kept %d glyphs in %d of %d bytes\n", len(font.Glyphs), size, maxBytes)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadPriorityFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "priority.txt")
+	// A repeated code keeps its first place.
+	data := "# most important first\nU+0050 0x41\n\n66 0x50 # P again\n"
+	if err := os.WriteFile(filename, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readPriorityFile(filename), []int{0x50, 0x41, 0x42}; !slices.Equal(got, want) {
+		t.Errorf("readPriorityFile() = %#x, want %#x", got, want)
+	}
+}
+
+func TestFitBudget(t *testing.T) {
+	// Every box packs into 2 bytes. 0x41-0x50 with three glyphs takes
+	// 6 bytes of bitmaps, 16 glyph table entries and the struct.
+	budget := headerBytes(6, 0x41, 0x50)
+	if budget != 127 {
+		t.Fatalf("headerBytes() = %d, want 127", budget)
+	}
+	warnings := captureWarnings(t)
+	font := parseBDF(writeTestBDF(t, nil, 0x41, 0x42, 0x43, 0x50))
+	fitBudget(font, budget, []int{0x50, 0x41})
+	if got, want := glyphCodes(font), []int{0x41, 0x42, 0x50}; !slices.Equal(got, want) {
+		t.Errorf("codes %#x, want %#x", got, want)
+	}
+	if !strings.Contains(warnings.String(), "dropped 1 glyphs over the -max-bytes budget: 0x0043") {
+		t.Errorf("warnings %q, want 0x0043 dropped", warnings)
+	}
+
+	// Without priorities the glyphs go in code order, and the gap up to
+	// 0x50 does not fit.
+	font = parseBDF(writeTestBDF(t, nil, 0x41, 0x42, 0x43, 0x50))
+	fitBudget(font, budget, nil)
+	if got, want := glyphCodes(font), []int{0x41, 0x42, 0x43}; !slices.Equal(got, want) {
+		t.Errorf("codes %#x without priorities, want %#x", got, want)
+	}
+}
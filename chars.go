@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// readCharsFile returns the text of a -chars-file decoded from the given
+// charset.
+func readCharsFile(filename, charset string) (string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	return decodeText(data, charset)
+}
+
+// decodeText converts data in one of the supported charsets to a string.
+func decodeText(data []byte, charset string) (string, error) {
+	switch strings.ToLower(strings.ReplaceAll(charset, "_", "-")) {
+	case "utf-8", "utf8":
+		if !utf8.Valid(data) {
+			return "", fmt.Errorf("input is not valid UTF-8")
+		}
+		return strings.TrimPrefix(string(data), "\uFEFF"), nil
+	case "latin1", "latin-1", "iso-8859-1":
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return string(runes), nil
+	case "utf-16le", "utf-16be":
+		if len(data)%2 != 0 {
+			return "", fmt.Errorf("odd number of bytes in %s input", charset)
+		}
+		big := strings.HasSuffix(strings.ToLower(charset), "be")
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			lo, hi := data[2*i], data[2*i+1]
+			if big {
+				lo, hi = hi, lo
+			}
+			units[i] = uint16(lo) | uint16(hi)<<8
+		}
+		return strings.TrimPrefix(string(utf16.Decode(units)), "\uFEFF"), nil
+	}
+	return "", fmt.Errorf("unsupported charset %q (use utf-8, latin1, utf-16le or utf-16be)", charset)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+// diffMain implements `bdf2gfx diff a b`. Inputs are BDF files or JSON
+// dumps written by -format=json.
+func diffMain(args []string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	asJSON := fs.Bool("diff-json", false, "print the differences as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: bdf2tft diff [-diff-json] <a.bdf|a.json> <b.bdf|b.json>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	d := gfx.Diff(loadFont(fs.Arg(0)), loadFont(fs.Arg(1)))
+	if *asJSON {
+		data, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	fmt.Printf("Added %d, removed %d, changed %d glyphs\n", len(d.Added), len(d.Removed), len(d.Changed))
+	for _, code := range d.Added {
+		fmt.Printf("  + 0x%04X\n", code)
+	}
+	for _, code := range d.Removed {
+		fmt.Printf("  - 0x%04X\n", code)
+	}
+	for _, c := range d.Changed {
+		var what []string
+		if c.Metrics {
+			what = append(what, "metrics")
+		}
+		if c.Bitmap {
+			what = append(what, "bitmap")
+		}
+		fmt.Printf("  ~ 0x%04X %s\n", c.Code, strings.Join(what, ", "))
+	}
+}
+
+// loadFont reads a BDF file or a JSON dump, depending on the extension.
+func loadFont(filename string) *gfx.Font {
+	if !strings.HasSuffix(filename, ".json") {
+		return parseBDF(filename)
+	}
+	file, err := openInput(filename)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		log.Fatalf("%s: %v", filename, err)
+	}
+	var font gfx.Font
+	if err := json.Unmarshal(data, &font); err != nil {
+		log.Fatalf("%s: %v", filename, err)
+	}
+	return &font
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+// dumpGlyph prints everything known about one glyph of the font: the BDF
+// metrics, the GFX fields derived from them, the bitmap rows next to their
+// rendering and the bytes that end up in the GFX bitmap blob.
+func dumpGlyph(font *gfx.Font, spec string) {
+	code, err := parseCodepoint(spec)
+	if err != nil {
+		log.Fatal(err)
+	}
+	g, ok := font.Glyph(code)
+	if !ok {
+		log.Fatalf("No glyph 0x%04X in the font", code)
+	}
+
+	fmt.Printf("Glyph 0x%04X %s\n", g.Code, g.Name)
+	fmt.Printf("  BBX %d %d %d %d\n", g.Width, g.Height, g.XOffset, g.YOffset)
+	fmt.Printf("  advance %d\n", g.XAdvance)
+	fmt.Printf("  GFX: width %d, height %d, xAdvance %d, xOffset %d, yOffset %d\n",
+		g.Width, g.Height, g.XAdvance, g.XOffset, g.YOffsetTFT())
+
+	bpr := g.BytesPerRow()
+	art := strings.Split(g.ASCII(), "\n")
+	fmt.Println("  BDF rows:")
+	for y := 0; y < g.Height; y++ {
+		fmt.Printf("    %-*X  %s\n", 2*bpr, g.Row(y), art[y])
+	}
+
+	blob, _ := gfx.PackGlyphs([]*gfx.Glyph{g}, 1)
+	var hex []string
+	for _, b := range blob {
+		hex = append(hex, fmt.Sprintf("0x%02X", b))
+	}
+	fmt.Printf("  GFX bytes (%d): %s\n", len(blob), strings.Join(hex, ", "))
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+// fallbackChain writes a primary and a fallback font into one header,
+// followed by a <name>GlyphFor helper that looks a codepoint up in the
+// primary font first and in the fallback font after that.
+type fallbackChain struct {
+	primary, fallback *gfx.Header
+}
+
+func (c *fallbackChain) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	if _, err := c.primary.WriteTo(&buf); err != nil {
+		return 0, err
+	}
+	buf.WriteString("\n")
+	if _, err := c.fallback.WriteTo(&buf); err != nil {
+		return 0, fmt.Errorf("fallback font: %w", err)
+	}
+
+	name, fallback := c.primary.Name, c.fallback.Name
+	readDim, readAdvance := "pgm_read_byte", "pgm_read_byte"
+	if c.primary.Wide {
+		readDim = "pgm_read_word"
+	}
+	if c.primary.WideAdvance {
+		readAdvance = "pgm_read_word"
+	}
+	fmt.Fprint(&buf, "\n// Returns the glyph for code from the first font of the chain that has\n")
+	fmt.Fprint(&buf, "// one, and sets *font to that font; returns NULL if neither has one.\n")
+	fmt.Fprint(&buf, "// Codes inside a font's range without a glyph are empty entries, which\n")
+	fmt.Fprint(&buf, "// are skipped.\n")
+	fmt.Fprintf(&buf, "static inline const GFXglyph *%sGlyphFor(uint16_t code, const GFXfont **font) {\n", name)
+	fmt.Fprintf(&buf, "  const GFXfont *chain[] = { &%s, &%s };\n", name, fallback)
+	fmt.Fprint(&buf, "  for (int i = 0; i < 2; i++) {\n")
+	fmt.Fprint(&buf, "    const GFXfont *f = chain[i];\n")
+	fmt.Fprint(&buf, "    uint16_t first = pgm_read_word(&f->first);\n")
+	fmt.Fprint(&buf, "    if (code < first || code > pgm_read_word(&f->last)) {\n")
+	fmt.Fprint(&buf, "      continue;\n")
+	fmt.Fprint(&buf, "    }\n")
+	fmt.Fprint(&buf, "    const GFXglyph *glyph = ((const GFXglyph *)pgm_read_ptr(&f->glyph)) + (code - first);\n")
+	fmt.Fprintf(&buf, "    if (%s(&glyph->xAdvance) == 0 && %s(&glyph->width) == 0) {\n", readAdvance, readDim)
+	fmt.Fprint(&buf, "      continue;\n")
+	fmt.Fprint(&buf, "    }\n")
+	fmt.Fprint(&buf, "    *font = f;\n")
+	fmt.Fprint(&buf, "    return glyph;\n")
+	fmt.Fprint(&buf, "  }\n")
+	fmt.Fprint(&buf, "  return NULL;\n")
+	fmt.Fprint(&buf, "}\n")
+
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
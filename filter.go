@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+// presets are named -range values for common subsets.
+var presets = map[string]string{
+	"ascii": "0x20-0x7E",
+	// Copyright, registered, degree, plus-minus, micro, middle dot,
+	// multiplication and division signs, bullet, ellipsis, euro, the
+	// arrows left/up/right/down/left-right/up-down, return arrow, check
+	// and ballot marks and the triangles up/right/down/left.
+	"ascii+symbols": "0x20-0x7E,0xA9,0xAE,0xB0,0xB1,0xB5,0xB7,0xD7,0xF7,0x2022,0x2026,0x20AC," +
+		"0x2190-0x2195,0x21B5,0x2713,0x2717,0x25B2,0x25B6,0x25BC,0x25C0",
+}
+
+// filterGlyphs applies the codepoint filter flags to the font. The glyph
+// that notdefSpec selects, if any, is kept from the control code drop so
+// that -notdef can still use it.
+func filterGlyphs(font *gfx.Font, notdefSpec string) {
+	// Many BDF fonts carry glyphs for the control codes, which a sketch
+	// never prints; leaving them in would only move first down to 0x00.
+	if !*keepControlChars {
+		var notdef *gfx.Glyph
+		if notdefSpec != "" {
+			// An error shows up again when the glyph is moved.
+			notdef, _ = font.NotdefGlyph(notdefSpec)
+		}
+		dropped := font.Drop(func(g *gfx.Glyph) bool { return g.Code < 0x20 && g != notdef })
+		if len(dropped) > 0 {
+			if len(font.Glyphs) == 0 {
+				log.Fatal("No glyphs left after dropping control characters, use -keep-control-chars to keep them")
+			}
+			fmt.Printf("Dropped %d control glyphs below 0x20\n", len(dropped))
+		}
+	}
+
+	var keep []func(code int) bool
+
+	if *codeRange != "" {
+		ranges, err := gfx.ParseRanges(*codeRange)
+		if err != nil {
+			log.Fatal(err)
+		}
+		keep = append(keep, ranges.Contains)
+	}
+
+	if *preset != "" {
+		spec, ok := presets[*preset]
+		if !ok {
+			log.Fatalf("Unknown preset %q", *preset)
+		}
+		ranges, err := gfx.ParseRanges(spec)
+		if err != nil {
+			log.Fatal(err)
+		}
+		keep = append(keep, ranges.Contains)
+	}
+
+	if *chars != "" || *charsFile != "" {
+		text := *chars
+		if *charsFile != "" {
+			fileText, err := readCharsFile(*charsFile, *charsEncoding)
+			if err != nil {
+				log.Fatalf("%s: %v", *charsFile, err)
+			}
+			text += fileText
+		}
+		set := map[int]bool{}
+		for _, r := range text {
+			if r != '\n' && r != '\r' {
+				set[int(r)] = true
+			}
+		}
+		keep = append(keep, func(code int) bool { return set[code] })
+	}
+
+	if *templateFile != "" {
+		text, err := templateChars(*templateFile)
+		if err != nil {
+			log.Fatalf("%s: %v", *templateFile, err)
+		}
+		set := map[int]bool{}
+		var missing []string
+		for _, r := range text {
+			if r == '\n' || r == '\r' || set[int(r)] {
+				continue
+			}
+			set[int(r)] = true
+			if _, ok := font.Glyph(int(r)); !ok {
+				missing = append(missing, fmt.Sprintf("U+%04X %q", r, r))
+			}
+		}
+		fmt.Printf("%s uses %d characters\n", *templateFile, len(set))
+		if len(missing) > 0 {
+			warnf("%s: %d characters are not in the font: %s", *templateFile, len(missing), strings.Join(missing, ", "))
+		}
+		keep = append(keep, func(code int) bool { return set[code] })
+	}
+
+	if *uppercaseOnly || *lowercaseOnly {
+		keep = append(keep, func(code int) bool {
+			switch {
+			case *uppercaseOnly && code >= 'A' && code <= 'Z':
+				return true
+			case *lowercaseOnly && code >= 'a' && code <= 'z':
+				return true
+			}
+			return strings.ContainsRune(*subsetExtra, rune(code))
+		})
+	}
+
+	if len(keep) > 0 {
+		font.Filter(func(code int) bool {
+			for _, k := range keep {
+				if !k(code) {
+					return false
+				}
+			}
+			return true
+		})
+		if len(font.Glyphs) == 0 {
+			log.Fatal("No glyphs left after filtering")
+		}
+		fmt.Printf("Kept %d glyphs\n", len(font.Glyphs))
+	}
+
+	if *maxWidth > 0 || *maxHeight > 0 {
+		dropped := font.Drop(func(g *gfx.Glyph) bool {
+			return (*maxWidth > 0 && g.Width > *maxWidth) || (*maxHeight > 0 && g.Height > *maxHeight)
+		})
+		if len(dropped) > 0 {
+			var list []string
+			for _, g := range dropped {
+				list = append(list, fmt.Sprintf("0x%04X (%dx%d)", g.Code, g.Width, g.Height))
+			}
+			warnf("dropped oversized glyphs: %s", strings.Join(list, ", "))
+		}
+		if len(font.Glyphs) == 0 {
+			log.Fatal("No glyphs left after dropping oversized glyphs")
+		}
+		fmt.Printf("Dropped %d oversized glyphs\n", len(dropped))
+	}
+}
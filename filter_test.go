@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestFilterControlChars(t *testing.T) {
+	filename := writeTestBDF(t, nil, 0x01, 0x1F, 0x20, 0x41)
+
+	font := parseBDF(filename)
+	filterGlyphs(font, "")
+	if got, want := glyphCodes(font), []int{0x20, 0x41}; !slices.Equal(got, want) {
+		t.Errorf("codes %#x, want %#x", got, want)
+	}
+
+	setFlag(t, keepControlChars, true)
+	font = parseBDF(filename)
+	filterGlyphs(font, "")
+	if got, want := glyphCodes(font), []int{0x01, 0x1F, 0x20, 0x41}; !slices.Equal(got, want) {
+		t.Errorf("codes %#x with -keep-control-chars, want %#x", got, want)
+	}
+}
+
+func TestFilterControlCharsKeepsNotdef(t *testing.T) {
+	font := parseBDF(writeTestBDF(t, nil, 0x00, 0x01, 0x41))
+	filterGlyphs(font, "0x00")
+	if got, want := glyphCodes(font), []int{0x00, 0x41}; !slices.Equal(got, want) {
+		t.Fatalf("codes %#x with -notdef=0x00, want %#x", got, want)
+	}
+	synthesized, err := font.MoveNotdefFirst("0x00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g := font.Glyphs[0]; synthesized || g.Code != 0 || !bytes.Equal(g.Bitmap, []byte{0xF0, 0xF0, 0xF0, 0xF0}) {
+		t.Errorf("notdef 0x%04X %X, synthesized %v; want the font's own box at 0x0000", g.Code, g.Bitmap, synthesized)
+	}
+}
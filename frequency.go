@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bufio"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// readFrequencyFile reads a usage frequency file and returns its codes,
+// most used first. Every non-empty line holds a codepoint and how often it
+// is used, "code count", with '#' starting a comment; codes with equal
+// counts keep their file order, and a repeated code adds up its counts.
+func readFrequencyFile(filename string) []int {
+	file, err := os.Open(filename)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	counts := map[int]int{}
+	var codes []int
+	scanner := bufio.NewScanner(file)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			log.Fatalf("%s:%d: expected \"code count\"", filename, lineNo)
+		}
+		code, err := parseCodepoint(fields[0])
+		if err != nil {
+			log.Fatalf("%s:%d: %v", filename, lineNo, err)
+		}
+		count, err := strconv.Atoi(fields[1])
+		if err != nil || count < 0 {
+			log.Fatalf("%s:%d: bad count %q", filename, lineNo, fields[1])
+		}
+		if _, ok := counts[code]; !ok {
+			codes = append(codes, code)
+		}
+		counts[code] += count
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatal(err)
+	}
+	sort.SliceStable(codes, func(i, j int) bool { return counts[codes[i]] > counts[codes[j]] })
+	return codes
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestReadFrequencyFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "freq.txt")
+	// A repeated code adds up, and equal counts keep their file order.
+	data := "# code count\n0x41 3\n\nU+0043 10 # C\n0x42 4\n65 1\n"
+	if err := os.WriteFile(filename, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readFrequencyFile(filename), []int{0x43, 0x41, 0x42}; !slices.Equal(got, want) {
+		t.Errorf("readFrequencyFile() = %#x, want %#x", got, want)
+	}
+}
@@ -0,0 +1,598 @@
+package gfx
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Parser holds options for reading BDF files that deviate from the spec.
+// The zero value parses spec-conforming files.
+type Parser struct {
+	// LSBFirst decodes bitmap rows whose leftmost pixel is stored in the
+	// least significant bit of each byte instead of the most significant.
+	LSBFirst bool
+
+	// ReverseBytes decodes bitmap rows whose bytes are stored right to
+	// left, as some exporters write rows of more than 8 pixels. It is
+	// applied before LSBFirst.
+	ReverseBytes bool
+
+	// SkipBadGlyphs drops glyphs that fail to parse instead of failing the
+	// whole font. Every dropped glyph is reported through Warn.
+	SkipBadGlyphs bool
+
+	// CheckEncoding reports glyphs whose ENCODING is lower than that of the
+	// preceding glyph in the file (ErrOutOfOrder) or repeats an earlier one
+	// (ErrDuplicateCode) through Warn.
+	CheckEncoding bool
+
+	// DropDuplicates keeps only the first glyph in file order for every
+	// repeated ENCODING. Dropped glyphs are reported through Warn.
+	DropDuplicates bool
+
+	// MetricsOnly ignores the BITMAP data, leaving every Glyph.Bitmap
+	// empty. It is much faster for inspecting large fonts.
+	MetricsOnly bool
+
+	// DiscardBitmaps decodes and checks the BITMAP rows like a full parse,
+	// and fails a glyph whose row count differs from its BBX height with
+	// ErrBitmapSize, but does not keep them: every Glyph.Bitmap is nil, as
+	// with MetricsOnly. It is meant for checking files quickly.
+	DiscardBitmaps bool
+
+	// AdobeNames accepts an Adobe glyph name, such as "space", as the
+	// ENCODING value, as some exporters write, and maps it to its
+	// codepoint with AdobeCode. Without it such values are an error.
+	AdobeNames bool
+
+	// Warn receives recoverable problems found while parsing. It may be nil.
+	Warn func(error)
+}
+
+var (
+	ErrOutOfOrder    = errors.New("encoding out of order")
+	ErrDuplicateCode = errors.New("duplicate encoding")
+	ErrBadBitmapRow  = errors.New("bad bitmap row")
+	ErrSecondBitmap  = errors.New("second BITMAP in one glyph")
+	ErrNoStartFont   = errors.New("no STARTFONT line")
+)
+
+// GlyphError describes a problem with a single glyph of a BDF file.
+// Skipped is set when the glyph was left out of the font because of it.
+// Line is 0 for the glyphs of PCF files, which have no lines.
+type GlyphError struct {
+	Line    int
+	Code    int
+	Name    string
+	Err     error
+	Skipped bool
+}
+
+func (e *GlyphError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("glyph 0x%04X (%s): %v", e.Code, e.Name, e.Err)
+	}
+	if e.Code < 0 {
+		return fmt.Sprintf("line %d: glyph %d (%s): %v", e.Line, e.Code, e.Name, e.Err)
+	}
+	return fmt.Sprintf("line %d: glyph 0x%04X (%s): %v", e.Line, e.Code, e.Name, e.Err)
+}
+
+func (e *GlyphError) Unwrap() error { return e.Err }
+
+func (p *Parser) warn(err error) {
+	if p.Warn != nil {
+		p.Warn(err)
+	}
+}
+
+// ParseBDF reads a BDF font with the default Parser.
+func ParseBDF(r io.Reader) (*Font, error) {
+	return (&Parser{}).Parse(r)
+}
+
+// Parse reads a BDF font. Glyphs are returned sorted by codepoint.
+func (p *Parser) Parse(r io.Reader) (*Font, error) {
+	font := &Font{DefaultChar: -1}
+	var glyphs, unencoded []*Glyph
+	var currentGlyph *Glyph
+	insideGlyph := false
+	insideBitmap := false
+	insideKerning := false
+	insideProperties := false
+	skipGlyph := false
+	startFont := false
+	hasAscent, hasDescent := false, false
+	// Font-wide DWIDTH and DWIDTH1 of BDF 2.2, for glyphs without their own.
+	var dwidth, dwidth1 int
+	hasDWidth, hasDWidth1 := false, false
+	var sizePixels float64
+	var bytesPerRow int
+	rows := 0 // bitmap rows of the current glyph, with DiscardBitmaps
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	dosEOF := false
+	for !dosEOF && scanner.Scan() {
+		// Files from DOS tools may end with a Ctrl-Z; it and anything
+		// after it are not part of the font.
+		line, _, cut := strings.Cut(scanner.Text(), "\x1a")
+		dosEOF = cut
+		lineNo++
+
+		// Some distributions put a license or other text before
+		// STARTFONT; it is skipped with a warning.
+		if !startFont {
+			if fields := strings.Fields(line); len(fields) == 0 || fields[0] != "STARTFONT" {
+				continue
+			}
+			startFont = true
+			if lineNo > 1 {
+				p.warn(fmt.Errorf("line %d: skipped %d lines before STARTFONT", lineNo, lineNo-1))
+			}
+			continue
+		}
+
+		endGlyph := func() {
+			switch {
+			case skipGlyph:
+			case currentGlyph.Code == -1:
+				unencoded = append(unencoded, currentGlyph)
+			default:
+				glyphs = append(glyphs, currentGlyph)
+			}
+			insideGlyph = false
+			insideBitmap = false
+			skipGlyph = false
+		}
+		// glyphError fails the parse, or with SkipBadGlyphs drops the
+		// current glyph and returns nil.
+		glyphError := func(format string, args ...any) error {
+			err := &GlyphError{Line: lineNo, Code: currentGlyph.Code, Name: currentGlyph.Name, Err: fmt.Errorf(format, args...)}
+			if !p.SkipBadGlyphs {
+				return err
+			}
+			err.Skipped = true
+			p.warn(err)
+			insideBitmap = false
+			skipGlyph = true
+			return nil
+		}
+
+		if skipGlyph {
+			fields := strings.Fields(line)
+			if len(fields) == 0 || (fields[0] != "ENDCHAR" && fields[0] != "STARTCHAR") {
+				continue
+			}
+			if fields[0] == "ENDCHAR" {
+				endGlyph()
+				continue
+			}
+			endGlyph()
+		}
+
+		if insideGlyph && insideBitmap {
+			line = strings.TrimSpace(line)
+			// Some files put a comment after ENDCHAR.
+			fields := strings.Fields(line)
+			if len(fields) > 0 && fields[0] == "ENDCHAR" {
+				if p.DiscardBitmaps && rows != currentGlyph.Height {
+					if err := glyphError("%w: %d rows for a BBX height of %d", ErrBitmapSize, rows, currentGlyph.Height); err != nil {
+						return nil, err
+					}
+				}
+				endGlyph()
+				continue
+			}
+			// A second BITMAP means the file is corrupt, and taking either
+			// section would silently lose the rows of the other.
+			if len(fields) > 0 && fields[0] == "BITMAP" {
+				if err := glyphError("%w, the file is corrupt", ErrSecondBitmap); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			// Hand-edited files sometimes have blank lines in the bitmap.
+			if line == "" || p.MetricsOnly {
+				continue
+			}
+
+			// Some exporters put several rows, or the whole bitmap, on one
+			// line, with or without spaces between the rows.
+			data, err := hex.DecodeString(strings.Join(strings.Fields(line), ""))
+			if err != nil {
+				if err := glyphError("%w %q: %s", ErrBadBitmapRow, line, hexProblem(err)); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if bytesPerRow == 0 || len(data) == 0 || len(data)%bytesPerRow != 0 {
+				if err := glyphError("expected %d bytes, got %d", bytesPerRow, len(data)); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if p.DiscardBitmaps {
+				rows += len(data) / bytesPerRow
+				continue
+			}
+			for len(data) > 0 {
+				rowBytes := data[:bytesPerRow]
+				data = data[bytesPerRow:]
+				if p.ReverseBytes {
+					slices.Reverse(rowBytes)
+				}
+				if p.LSBFirst {
+					for i, b := range rowBytes {
+						rowBytes[i] = bits.Reverse8(b)
+					}
+				}
+				if bpp := font.BitsPerPixel; bpp > 1 {
+					currentGlyph.Gray, rowBytes = appendGrayRow(currentGlyph.Gray, rowBytes, currentGlyph.Width, bpp)
+				}
+				currentGlyph.Bitmap = append(currentGlyph.Bitmap, rowBytes...)
+			}
+			continue
+		}
+
+		fields := strings.Fields(line)
+		// COMMENT lines may contain anything, including keywords.
+		if len(fields) == 0 || fields[0] == "COMMENT" {
+			continue
+		}
+
+		if insideKerning {
+			switch fields[0] {
+			case "ENDKERNING":
+				insideKerning = false
+			case "KERNPAIR":
+				var pair KernPair
+				if len(fields) < 4 {
+					return nil, fmt.Errorf("line %d: KERNPAIR needs 3 values", lineNo)
+				}
+				values := []*int{&pair.Left, &pair.Right, &pair.Adjust}
+				for i, v := range values {
+					n, err := strconv.Atoi(fields[i+1])
+					if err != nil {
+						return nil, fmt.Errorf("line %d: bad KERNPAIR: %v", lineNo, err)
+					}
+					*v = n
+				}
+				font.Kerning = append(font.Kerning, pair)
+			}
+			continue
+		}
+
+		if insideProperties {
+			if fields[0] == "ENDPROPERTIES" {
+				insideProperties = false
+				continue
+			}
+			font.Properties[fields[0]] = keywordValue(line)
+		}
+
+		// Keywords whose value is read below need one.
+		if len(fields) < 2 {
+			switch fields[0] {
+			case "FONT_ASCENT", "FONT_DESCENT", "RAW_ASCENT", "RAW_DESCENT", "METRICSSET", "DEFAULT_CHAR", "CAP_HEIGHT", "X_HEIGHT":
+				if !insideGlyph {
+					return nil, fmt.Errorf("line %d: %s needs a value", lineNo, fields[0])
+				}
+			case "ENCODING", "DWIDTH":
+				if insideGlyph {
+					if err := glyphError("%s needs a value", fields[0]); err != nil {
+						return nil, err
+					}
+					continue
+				}
+			}
+		}
+
+		// Font-wide keywords are only honored outside of glyphs, and
+		// glyph keywords only inside one.
+		if !insideGlyph {
+			switch fields[0] {
+			case "FONTBOUNDINGBOX":
+				if len(fields) >= 5 {
+					var bb BBX
+					for i, v := range []*int{&bb.Width, &bb.Height, &bb.XOffset, &bb.YOffset} {
+						*v, _ = strconv.Atoi(fields[i+1])
+					}
+					font.BoundingBox = &bb
+				}
+			case "FONT_ASCENT":
+				font.Ascent, _ = strconv.Atoi(fields[1])
+				hasAscent = true
+			case "FONT_DESCENT":
+				font.Descent, _ = strconv.Atoi(fields[1])
+				hasDescent = true
+			case "RAW_ASCENT":
+				font.RawAscent, _ = strconv.Atoi(fields[1])
+			case "RAW_DESCENT":
+				font.RawDescent, _ = strconv.Atoi(fields[1])
+			case "METRICSSET":
+				font.MetricsSet, _ = strconv.Atoi(fields[1])
+			case "DEFAULT_CHAR":
+				font.DefaultChar, _ = strconv.Atoi(fields[1])
+			case "CAP_HEIGHT":
+				font.CapHeight, _ = strconv.Atoi(fields[1])
+			case "X_HEIGHT":
+				font.XHeight, _ = strconv.Atoi(fields[1])
+			case "SIZE":
+				if len(fields) > 3 {
+					points, _ := strconv.ParseFloat(fields[1], 64)
+					yRes, _ := strconv.ParseFloat(fields[3], 64)
+					sizePixels = points * yRes / 72
+				}
+				if len(fields) > 4 {
+					bpp, _ := strconv.Atoi(fields[4])
+					switch bpp {
+					case 1, 2, 4, 8:
+						font.BitsPerPixel = bpp
+					default:
+						return nil, fmt.Errorf("line %d: unsupported bits per pixel %s", lineNo, fields[4])
+					}
+				}
+			case "DWIDTH":
+				if len(fields) > 1 {
+					dwidth, _ = strconv.Atoi(fields[1])
+					hasDWidth = true
+				}
+			case "DWIDTH1":
+				if len(fields) > 2 {
+					dwidth1, _ = strconv.Atoi(fields[2])
+					hasDWidth1 = true
+				}
+			case "VVECTOR":
+				if len(fields) > 2 {
+					x, _ := strconv.Atoi(fields[1])
+					y, _ := strconv.Atoi(fields[2])
+					font.VVector = &[2]int{x, y}
+				}
+			case "FONT":
+				font.XLFD = keywordValue(line)
+			case "CHARSET_REGISTRY":
+				font.CharsetRegistry = keywordValue(line)
+			case "CHARSET_ENCODING":
+				font.CharsetEncoding = keywordValue(line)
+			case "STARTKERNING":
+				insideKerning = true
+			case "STARTPROPERTIES":
+				insideProperties = true
+				if font.Properties == nil {
+					font.Properties = map[string]string{}
+				}
+			}
+		}
+
+		switch fields[0] {
+		case "STARTCHAR":
+			if insideGlyph {
+				if err := glyphError("STARTCHAR %s before ENDCHAR", keywordValue(line)); err != nil {
+					return nil, err
+				}
+				endGlyph()
+			}
+			currentGlyph = &Glyph{Name: keywordValue(line), line: lineNo,
+				dwidth: dwidth, hasDWidth: hasDWidth, dwidth1: dwidth1, hasDWidth1: hasDWidth1}
+			insideGlyph = true
+		case "ENDCHAR":
+			if insideGlyph {
+				endGlyph()
+			}
+		case "ENCODING":
+			if insideGlyph {
+				code, err := strconv.Atoi(fields[1])
+				if err != nil {
+					var ok bool
+					if code, ok = AdobeCode(fields[1]); !p.AdobeNames || !ok {
+						currentGlyph.Code = -1
+						what := "not a number"
+						if p.AdobeNames {
+							what = "not a number or known Adobe glyph name"
+						}
+						if err := glyphError("ENCODING %q is %s", fields[1], what); err != nil {
+							return nil, err
+						}
+						continue
+					}
+				}
+				currentGlyph.Code = code
+				// -1 marks an unencoded glyph, possibly with a
+				// non-standard code that GFX fonts have no use for.
+				if code < -1 {
+					if err := glyphError("negative ENCODING %d", code); err != nil {
+						return nil, err
+					}
+				}
+			}
+		case "DWIDTH":
+			if insideGlyph {
+				currentGlyph.dwidth, _ = strconv.Atoi(fields[1])
+				currentGlyph.hasDWidth = true
+			}
+		case "DWIDTH1":
+			if insideGlyph && len(fields) > 2 {
+				currentGlyph.dwidth1, _ = strconv.Atoi(fields[2])
+				currentGlyph.hasDWidth1 = true
+			}
+		case "VVECTOR":
+			if insideGlyph && len(fields) > 2 {
+				currentGlyph.vvector[0], _ = strconv.Atoi(fields[1])
+				currentGlyph.vvector[1], _ = strconv.Atoi(fields[2])
+				currentGlyph.hasVVector = true
+			}
+		case "BBX":
+			if insideGlyph {
+				if len(fields) < 5 {
+					if err := glyphError("BBX needs 4 values"); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				width, _ := strconv.Atoi(fields[1])
+				height, _ := strconv.Atoi(fields[2])
+				xOffset, _ := strconv.Atoi(fields[3])
+				yOffset, _ := strconv.Atoi(fields[4])
+				currentGlyph.Width = width
+				currentGlyph.Height = height
+				currentGlyph.XOffset = xOffset
+				currentGlyph.YOffset = yOffset
+				bytesPerRow = (width*max(font.BitsPerPixel, 1) + 7) / 8
+			}
+		case "ATTRIBUTES":
+			if insideGlyph && len(fields) > 1 {
+				currentGlyph.Attributes = fields[1]
+			}
+		case "BITMAP":
+			if insideGlyph {
+				if !p.DiscardBitmaps {
+					currentGlyph.Bitmap = []byte{}
+				}
+				insideBitmap = true
+				rows = 0
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !startFont {
+		return nil, ErrNoStartFont
+	}
+
+	if p.CheckEncoding || p.DropDuplicates {
+		glyphs = p.checkEncoding(glyphs)
+	}
+
+	// A stable sort keeps duplicated codes in file order.
+	sort.SliceStable(glyphs, func(i, j int) bool {
+		return glyphs[i].Code < glyphs[j].Code
+	})
+
+	for _, g := range glyphs {
+		g.XAdvance = font.advance(g)
+	}
+	for _, g := range unencoded {
+		g.XAdvance = font.advance(g)
+	}
+	font.unencoded = unencoded
+
+	// Some fonts only have the scalable RAW_ASCENT and RAW_DESCENT, in
+	// thousandths of the pixel size.
+	if (!hasAscent && font.RawAscent != 0) || (!hasDescent && font.RawDescent != 0) {
+		pixels := sizePixels
+		if px, err := strconv.Atoi(font.Properties["PIXEL_SIZE"]); err == nil && px > 0 {
+			pixels = float64(px)
+		}
+		if pixels <= 0 {
+			p.warn(errors.New("RAW_ASCENT and RAW_DESCENT ignored, there is no pixel size to scale them by"))
+		} else {
+			if !hasAscent {
+				font.Ascent = int(math.Round(float64(font.RawAscent) * pixels / 1000))
+			}
+			if !hasDescent {
+				font.Descent = int(math.Round(float64(font.RawDescent) * pixels / 1000))
+			}
+			if font.Ascent+font.Descent <= 0 {
+				p.warn(fmt.Errorf("RAW_ASCENT %d and RAW_DESCENT %d scale to a line height of %d at %g pixels",
+					font.RawAscent, font.RawDescent, font.Ascent+font.Descent, pixels))
+			}
+		}
+	}
+
+	font.Glyphs = glyphs
+	return font, nil
+}
+
+// appendGrayRow decodes a row of bpp-bit gray levels, appends them to gray
+// and returns the row as a 1-bit bitmap row that sets the pixels of at
+// least half intensity.
+func appendGrayRow(gray, row []byte, width, bpp int) ([]byte, []byte) {
+	mono := make([]byte, (width+7)/8)
+	mask := byte(1<<bpp - 1)
+	for x := 0; x < width; x++ {
+		bit := x * bpp
+		level := row[bit/8] >> (8 - bpp - bit%8) & mask
+		gray = append(gray, level)
+		if level > mask/2 {
+			mono[x/8] |= 0x80 >> (x % 8)
+		}
+	}
+	return gray, mono
+}
+
+// hexProblem explains a hex.DecodeString error in terms of a BDF file.
+func hexProblem(err error) string {
+	var invalid hex.InvalidByteError
+	switch {
+	case errors.Is(err, hex.ErrLength):
+		return "odd number of hex digits, every byte needs two (is the file truncated or corrupt?)"
+	case errors.As(err, &invalid):
+		return fmt.Sprintf("%q is not a hex digit", rune(invalid))
+	}
+	return err.Error()
+}
+
+// keywordValue returns everything after the keyword of a line, with BDF
+// string quoting removed.
+func keywordValue(line string) string {
+	line = strings.TrimSpace(line)
+	var value string
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		value = strings.TrimSpace(line[i+1:])
+	}
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		value = strings.ReplaceAll(value[1:len(value)-1], `""`, `"`)
+	}
+	return value
+}
+
+// checkEncoding looks for out of order and repeated encodings in file
+// order, dropping the repeats if requested.
+func (p *Parser) checkEncoding(glyphs []*Glyph) []*Glyph {
+	seen := map[int]*Glyph{}
+	var kept []*Glyph
+	for i, g := range glyphs {
+		if prev := seen[g.Code]; prev != nil {
+			err := &GlyphError{Line: g.line, Code: g.Code, Name: g.Name,
+				Err: fmt.Errorf("%w, first defined on line %d", ErrDuplicateCode, prev.line), Skipped: p.DropDuplicates}
+			p.warn(err)
+			if p.DropDuplicates {
+				continue
+			}
+		} else {
+			seen[g.Code] = g
+		}
+		if p.CheckEncoding && i > 0 && g.Code < glyphs[i-1].Code {
+			p.warn(&GlyphError{Line: g.line, Code: g.Code, Name: g.Name,
+				Err: fmt.Errorf("%w after 0x%04X", ErrOutOfOrder, glyphs[i-1].Code)})
+		}
+		kept = append(kept, g)
+	}
+	return kept
+}
+
+// advance picks the horizontal advance of a glyph. DWIDTH is authoritative
+// for METRICSSET 0 and 2; vertical-only fonts fall back to the magnitude of
+// the DWIDTH1 displacement when a glyph has no DWIDTH. Glyphs start out
+// with the font-wide DWIDTH and DWIDTH1, which their own replace.
+func (f *Font) advance(g *Glyph) int {
+	if f.MetricsSet == MetricsVertical && !g.hasDWidth && g.hasDWidth1 {
+		if g.dwidth1 < 0 {
+			return -g.dwidth1
+		}
+		return g.dwidth1
+	}
+	return g.dwidth
+}
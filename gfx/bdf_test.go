@@ -0,0 +1,420 @@
+package gfx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseCodeAboveUint16(t *testing.T) {
+	font := parseTest(t, testBDF(testBox(0x41), testBox(0x1F600)))
+	if got := codes(font); len(got) != 2 || got[1] != 0x1F600 {
+		t.Fatalf("codes %#x, want 0x41 and 0x1f600", got)
+	}
+	err := font.CheckCodes16()
+	if err == nil || !strings.Contains(err.Error(), "0x1F600") {
+		t.Errorf("CheckCodes16() = %v, want an error for 0x1F600", err)
+	}
+
+	// No GFX layout holds the code; the header fails without output.
+	var buf bytes.Buffer
+	if _, err := (&Header{Font: font}).WriteTo(&buf); err == nil || !strings.Contains(err.Error(), "uint16_t") {
+		t.Errorf("WriteTo error %v, want one about uint16_t", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("WriteTo wrote %d bytes", buf.Len())
+	}
+
+	if font.Filter(func(code int) bool { return code <= 0xFFFF }); font.CheckCodes16() != nil {
+		t.Error("CheckCodes16 fails after filtering out 0x1F600")
+	}
+}
+
+func TestParseNegativeEncoding(t *testing.T) {
+	_, err := ParseBDF(strings.NewReader(testBDF(testBox(0x41), testBox(-5))))
+	if err == nil || !strings.Contains(err.Error(), "negative ENCODING -5") {
+		t.Errorf("ParseBDF error %v, want negative ENCODING -5", err)
+	}
+}
+
+func TestParseUnencoded(t *testing.T) {
+	notdef := testGlyph(".notdef", -1, 3, 3, "E0", "A0", "E0")
+	font := parseTest(t, testBDF(notdef, testBox(0x41)))
+	if got := codes(font); len(got) != 1 || got[0] != 0x41 {
+		t.Errorf("codes %#x, want only 0x41", got)
+	}
+	if len(font.unencoded) != 1 || font.unencoded[0].Name != ".notdef" || font.unencoded[0].XAdvance != 4 {
+		t.Errorf("unencoded glyphs %+v, want .notdef with an advance of 4", font.unencoded)
+	}
+}
+
+func TestParseOddHexRow(t *testing.T) {
+	// The second bitmap row of exclam, line 26 of the file, has three hex
+	// digits.
+	bdf := testBDF(testBox(0x41), testGlyph("exclam", 0x21, 4, 3, "F0", "F00", "F0"))
+	_, err := ParseBDF(strings.NewReader(bdf))
+	var glyphErr *GlyphError
+	if !errors.As(err, &glyphErr) || !errors.Is(err, ErrBadBitmapRow) {
+		t.Fatalf("ParseBDF error %v, want a GlyphError for a bad bitmap row", err)
+	}
+	want := `line 26: glyph 0x0021 (exclam): bad bitmap row "F00": odd number of hex digits, every byte needs two (is the file truncated or corrupt?)`
+	if err.Error() != want {
+		t.Errorf("error\n  %v\nwant\n  %s", err, want)
+	}
+}
+
+func TestParseBadHexDigit(t *testing.T) {
+	_, err := ParseBDF(strings.NewReader(testBDF(testGlyph("A", 0x41, 4, 2, "F0", "G0"))))
+	if err == nil || !strings.Contains(err.Error(), `"G0": 'G' is not a hex digit`) {
+		t.Errorf("ParseBDF error %v, want one naming the digit G", err)
+	}
+}
+
+func TestParseCommentKeywords(t *testing.T) {
+	keywords := []string{
+		"FONT_ASCENT 9", "FONT_DESCENT 9", "METRICSSET 1", "DEFAULT_CHAR 9",
+		"STARTCHAR x", "ENCODING 9", "DWIDTH 9 0", "DWIDTH1 0 9", "BBX 9 9 9 9",
+		"BITMAP", "ENDCHAR",
+	}
+	var comments strings.Builder
+	for _, k := range keywords {
+		comments.WriteString("COMMENT " + k + "\n")
+	}
+	plain := testBDF(testBox(0x41), testBox(0x42))
+	// In the header, among the properties and inside a glyph.
+	commented := strings.Replace(plain, "SIZE 8 75 75\n", "SIZE 8 75 75\n"+comments.String(), 1)
+	commented = strings.Replace(commented, "FONT_ASCENT 6\n", "FONT_ASCENT 6\n"+comments.String(), 1)
+	commented = strings.Replace(commented, "ENCODING 65\n", "ENCODING 65\n"+comments.String(), 1)
+
+	want, got := parseTest(t, plain), parseTest(t, commented)
+	// The comments move the glyphs further down the file.
+	for _, f := range []*Font{want, got} {
+		for _, g := range f.Glyphs {
+			g.line = 0
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("font with comments %+v, want %+v", got, want)
+	}
+}
+
+func TestParseKeywordState(t *testing.T) {
+	// Glyph keywords in the header and font keywords in a glyph are
+	// ignored.
+	bdf := strings.Replace(testBDF(testBox(0x41)), "CHARS 1\n", "CHARS 1\nENCODING 66\nBBX 9 9 0 0\nDWIDTH 9 0\n", 1)
+	bdf = strings.Replace(bdf, "ENCODING 65\n", "ENCODING 65\nFONT_ASCENT 9\nMETRICSSET 1\n", 1)
+	font := parseTest(t, bdf)
+	if font.Ascent != 6 || font.MetricsSet != 0 {
+		t.Errorf("ascent %d and METRICSSET %d, want 6 and 0", font.Ascent, font.MetricsSet)
+	}
+	if g := font.Glyphs[0]; len(font.Glyphs) != 1 || g.Code != 0x41 || g.Width != 4 || g.XAdvance != 5 {
+		t.Errorf("glyphs %+v, want one 4x4 glyph 0x41 with an advance of 5", font.Glyphs)
+	}
+}
+
+func TestParseLSBFirst(t *testing.T) {
+	// A 10 pixel wide row whose leftmost pixel is stored in the lowest bit:
+	// pixels 0, 1 and 9 are set.
+	bdf := testBDF(testGlyph("A", 0x41, 10, 1, "0302"))
+	msb := parseTest(t, bdf)
+	lsb, err := (&Parser{LSBFirst: true}).Parse(strings.NewReader(bdf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := msb.Glyphs[0].Bitmap; !bytes.Equal(got, []byte{0x03, 0x02}) {
+		t.Errorf("MSB-first row % X, want 03 02", got)
+	}
+	if got := lsb.Glyphs[0].Bitmap; !bytes.Equal(got, []byte{0xC0, 0x40}) {
+		t.Errorf("LSB-first row % X, want C0 40", got)
+	}
+}
+
+func TestParseAttributes(t *testing.T) {
+	withAttributes := strings.Replace(testBox(0x41), "BITMAP\n", "ATTRIBUTES 00A0\nBITMAP\n", 1)
+	font := parseTest(t, testBDF(withAttributes, testBox(0x42)))
+	plain := parseTest(t, testBDF(testBox(0x41), testBox(0x42)))
+	if got := font.Glyphs[0].Attributes; got != "00A0" {
+		t.Errorf("attributes %q, want 00A0", got)
+	}
+	if got := font.Glyphs[1].Attributes; got != "" {
+		t.Errorf("attributes of 0x42 %q, want none", got)
+	}
+	for i, g := range font.Glyphs {
+		if want := plain.Glyphs[i]; !bytes.Equal(g.Bitmap, want.Bitmap) || g.Height != want.Height {
+			t.Errorf("glyph 0x%04X bitmap % X, want % X", g.Code, g.Bitmap, want.Bitmap)
+		}
+	}
+}
+
+func TestParseBlankBitmapLines(t *testing.T) {
+	blank := testGlyph("A", 0x41, 4, 3, "F0", "", "90", "  ", "F0")
+	font := parseTest(t, testBDF(blank))
+	if got := font.Glyphs[0].Bitmap; !bytes.Equal(got, []byte{0xF0, 0x90, 0xF0}) {
+		t.Errorf("bitmap % X, want F0 90 F0", got)
+	}
+}
+
+func TestParseSingleLineBitmap(t *testing.T) {
+	rows := testGlyph("A", 0x41, 12, 3, "F0F0", "9090", "F0F0")
+	want := parseTest(t, testBDF(rows)).Glyphs[0].Bitmap
+	for _, lines := range [][]string{{"F0F0 9090 F0F0"}, {"F0F09090F0F0"}, {"F0F0 9090", "F0F0"}} {
+		font := parseTest(t, testBDF(testGlyph("A", 0x41, 12, 3, lines...)))
+		if got := font.Glyphs[0].Bitmap; !bytes.Equal(got, want) {
+			t.Errorf("BITMAP lines %q: % X, want % X", lines, got, want)
+		}
+	}
+}
+
+func TestParseEndcharComment(t *testing.T) {
+	box := strings.Replace(testBox(0x41), "ENDCHAR\n", "ENDCHAR # note\n", 1)
+	font := parseTest(t, testBDF(box, testBox(0x42)))
+	if got := codes(font); !slices.Equal(got, []int{0x41, 0x42}) {
+		t.Errorf("codes %#x, want 0x41 and 0x42", got)
+	}
+	if got := font.Glyphs[0].Bitmap; !bytes.Equal(got, []byte{0xF0, 0xF0, 0xF0, 0xF0}) {
+		t.Errorf("bitmap % X, want four F0 rows", got)
+	}
+}
+
+func TestParseTextBeforeStartfont(t *testing.T) {
+	license := "Copyright (c) 2024 Someone\nPermission is hereby granted, free of charge...\n\n"
+	var warnings []error
+	p := &Parser{Warn: func(err error) { warnings = append(warnings, err) }}
+	font, err := p.Parse(strings.NewReader(license + testBDF(testBox(0x41))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := codes(font); len(got) != 1 || got[0] != 0x41 || font.Ascent != 6 {
+		t.Errorf("codes %#x and ascent %d, want 0x41 and 6", got, font.Ascent)
+	}
+	if len(warnings) != 1 || warnings[0].Error() != "line 4: skipped 3 lines before STARTFONT" {
+		t.Errorf("warnings %v, want one about 3 skipped lines", warnings)
+	}
+
+	if _, err := ParseBDF(strings.NewReader(license)); !errors.Is(err, ErrNoStartFont) {
+		t.Errorf("ParseBDF error %v without STARTFONT, want ErrNoStartFont", err)
+	}
+}
+
+func TestParseAdobeNameEncoding(t *testing.T) {
+	bdf := testBDF(strings.Replace(testBox(0x20), "ENCODING 32", "ENCODING space", 1), testBox(0x41))
+	want := `line 11: glyph -1 (u0020): ENCODING "space" is not a number`
+	if _, err := ParseBDF(strings.NewReader(bdf)); err == nil || err.Error() != want {
+		t.Errorf("ParseBDF error %v, want %s", err, want)
+	}
+
+	font, err := (&Parser{AdobeNames: true}).Parse(strings.NewReader(bdf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := codes(font); !slices.Equal(got, []int{0x20, 0x41}) {
+		t.Errorf("codes %#x, want 0x20 and 0x41", got)
+	}
+
+	for name, want := range map[string]int{"space": 0x20, "A": 0x41, "uni00E9": 0xE9, "u1F600": 0x1F600} {
+		if code, ok := AdobeCode(name); !ok || code != want {
+			t.Errorf("AdobeCode(%q) = %#x, %v, want %#x", name, code, ok, want)
+		}
+	}
+	if _, ok := AdobeCode("notaglyphname"); ok {
+		t.Error("AdobeCode accepts an unknown name")
+	}
+}
+
+// rawBDF returns testBDF with FONT_ASCENT and FONT_DESCENT replaced by the
+// given RAW_ASCENT and RAW_DESCENT at a PIXEL_SIZE of 10.
+func rawBDF(ascent, descent int, glyphs ...string) string {
+	raw := fmt.Sprintf("PIXEL_SIZE 10\nRAW_ASCENT %d\nRAW_DESCENT %d\n", ascent, descent)
+	return strings.Replace(testBDF(glyphs...), "FONT_ASCENT 6\nFONT_DESCENT 2\n", raw, 1)
+}
+
+func TestParseRawMetrics(t *testing.T) {
+	font := parseTest(t, rawBDF(800, 200, testBox(0x41)))
+	if font.Ascent != 8 || font.Descent != 2 || font.YAdvance() != 10 {
+		t.Errorf("ascent %d, descent %d, yAdvance %d, want 8, 2 and 10", font.Ascent, font.Descent, font.YAdvance())
+	}
+	if font.RawAscent != 800 || font.RawDescent != 200 {
+		t.Errorf("raw ascent %d and descent %d, want 800 and 200", font.RawAscent, font.RawDescent)
+	}
+
+	// FONT_ASCENT and FONT_DESCENT win over the raw values.
+	bdf := strings.Replace(testBDF(testBox(0x41)), "FONT_ASCENT 6\n", "FONT_ASCENT 6\nPIXEL_SIZE 10\nRAW_ASCENT 900\nRAW_DESCENT 100\n", 1)
+	if font := parseTest(t, bdf); font.Ascent != 6 || font.Descent != 2 {
+		t.Errorf("ascent %d and descent %d with FONT_ASCENT, want 6 and 2", font.Ascent, font.Descent)
+	}
+
+	// Without PIXEL_SIZE the pixel size comes from the SIZE line, 8.
+	bdf = strings.Replace(rawBDF(875, 125, testBox(0x41)), "PIXEL_SIZE 10\n", "", 1)
+	if font := parseTest(t, bdf); font.Ascent != 7 || font.Descent != 1 {
+		t.Errorf("ascent %d and descent %d at SIZE 8, want 7 and 1", font.Ascent, font.Descent)
+	}
+
+	// Without any pixel size the raw values are ignored with a warning.
+	var warnings []error
+	p := &Parser{Warn: func(err error) { warnings = append(warnings, err) }}
+	font, err := p.Parse(strings.NewReader(strings.Replace(bdf, "SIZE 8 75 75\n", "", 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if font.Ascent != 0 || font.Descent != 0 || len(warnings) != 1 {
+		t.Errorf("ascent %d and descent %d with warnings %v, want 0, 0 and one warning", font.Ascent, font.Descent, warnings)
+	}
+}
+
+func TestParseSecondBitmap(t *testing.T) {
+	double := strings.Replace(testBox(0x42), "F0\nENDCHAR", "F0\nBITMAP\n90\nENDCHAR", 1)
+	bdf := testBDF(testBox(0x41), double, testBox(0x43))
+	_, err := ParseBDF(strings.NewReader(bdf))
+	var glyphErr *GlyphError
+	if !errors.As(err, &glyphErr) || !errors.Is(err, ErrSecondBitmap) || glyphErr.Code != 0x42 || glyphErr.Line != 29 {
+		t.Fatalf("ParseBDF error %v, want ErrSecondBitmap for 0x42 on line 29", err)
+	}
+
+	font, err := (&Parser{SkipBadGlyphs: true}).Parse(strings.NewReader(bdf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := codes(font); !slices.Equal(got, []int{0x41, 0x43}) {
+		t.Errorf("codes %#x after skipping, want 0x41 and 0x43", got)
+	}
+}
+
+func TestParseReverseBytes(t *testing.T) {
+	// A 12 pixel row, F0F0 left to right, written with its bytes swapped.
+	bdf := testBDF(testGlyph("A", 0x41, 12, 2, "F0F0", "30C0"))
+	font, err := (&Parser{ReverseBytes: true}).Parse(strings.NewReader(bdf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := font.Glyphs[0].Bitmap, []byte{0xF0, 0xF0, 0xC0, 0x30}; !bytes.Equal(got, want) {
+		t.Errorf("bitmap % X, want % X", got, want)
+	}
+
+	// Bytes are swapped before the bits are reversed.
+	font, err = (&Parser{ReverseBytes: true, LSBFirst: true}).Parse(strings.NewReader(bdf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := font.Glyphs[0].Bitmap, []byte{0x0F, 0x0F, 0x03, 0x0C}; !bytes.Equal(got, want) {
+		t.Errorf("bitmap with LSBFirst % X, want % X", got, want)
+	}
+}
+
+func TestParseDOSEOF(t *testing.T) {
+	bdf := testBDF(testBox(0x41))
+	for _, tail := range []string{"\x1a", "\x1a\x1a\x1a", "\x1agarbage after the marker\nSTARTCHAR x\n"} {
+		font := parseTest(t, bdf+tail)
+		if got := codes(font); len(got) != 1 || got[0] != 0x41 {
+			t.Errorf("tail %q: codes %#x, want 0x41", tail, got)
+		}
+	}
+
+	// A marker on the last line of a glyph ends it there.
+	cut := strings.TrimSuffix(bdf, "ENDCHAR\nENDFONT\n") + "ENDCHAR\x1a"
+	if font := parseTest(t, cut); len(font.Glyphs) != 1 || !bytes.Equal(font.Glyphs[0].Bitmap, []byte{0xF0, 0xF0, 0xF0, 0xF0}) {
+		t.Errorf("glyphs %+v, want the 4x4 box", font.Glyphs)
+	}
+}
+
+func TestParseDiscardBitmaps(t *testing.T) {
+	p := &Parser{DiscardBitmaps: true}
+	font, err := p.Parse(strings.NewReader(testBDF(testBox(0x41), testGlyph("space", 0x20, 0, 0))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := codes(font); !slices.Equal(got, []int{0x20, 0x41}) || font.Glyphs[1].Bitmap != nil {
+		t.Errorf("codes %#x and bitmap %v, want 0x20 and 0x41 without bitmaps", got, font.Glyphs[1].Bitmap)
+	}
+
+	// The rows are still checked.
+	short := testGlyph("short", 0x42, 4, 4, "F0", "F0", "F0")
+	_, err = p.Parse(strings.NewReader(testBDF(testBox(0x41), short)))
+	var glyphErr *GlyphError
+	if !errors.As(err, &glyphErr) || !errors.Is(err, ErrBitmapSize) || glyphErr.Code != 0x42 {
+		t.Errorf("err = %v, want ErrBitmapSize for glyph 0x42", err)
+	}
+	bad := testGlyph("bad", 0x42, 4, 4, "F0", "F0", "FX", "F0")
+	if _, err := p.Parse(strings.NewReader(testBDF(bad))); !errors.Is(err, ErrBadBitmapRow) {
+		t.Errorf("err = %v, want ErrBadBitmapRow", err)
+	}
+}
+
+func TestParseKeywordWithoutValue(t *testing.T) {
+	box := testBox(0x41)
+	tests := []struct {
+		name, bdf, want string
+	}{
+		{"FONT_ASCENT", strings.Replace(testBDF(box), "FONT_ASCENT 6", "FONT_ASCENT", 1), "line 6: FONT_ASCENT needs a value"},
+		{"DEFAULT_CHAR", strings.Replace(testBDF(box), "CHARS 1", "DEFAULT_CHAR", 1), "line 9: DEFAULT_CHAR needs a value"},
+		{"ENCODING", strings.Replace(testBDF(box), "ENCODING 65", "ENCODING", 1), "line 11: glyph 0x0000 (u0041): ENCODING needs a value"},
+		{"DWIDTH", strings.Replace(testBDF(box), "DWIDTH 5 0", "DWIDTH", 1), "line 12: glyph 0x0041 (u0041): DWIDTH needs a value"},
+		{"STARTCHAR", strings.Replace(testBDF(box, box), "BITMAP\nF0\nF0\nF0\nF0\nENDCHAR\nSTARTCHAR u0041", "STARTCHAR", 1), "line 14: glyph 0x0041 (u0041): STARTCHAR  before ENDCHAR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseBDF(strings.NewReader(tt.bdf))
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("ParseBDF error %v, want %s", err, tt.want)
+			}
+		})
+	}
+
+	// Bad glyphs can be skipped instead.
+	bdf := strings.Replace(testBDF(testBox(0x41), testBox(0x42)), "ENCODING 65", "ENCODING", 1)
+	font, err := (&Parser{SkipBadGlyphs: true}).Parse(strings.NewReader(bdf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := codes(font); len(got) != 1 || got[0] != 0x42 {
+		t.Errorf("codes %#x, want only 0x42", got)
+	}
+}
+
+func TestParseFontWideDWidth(t *testing.T) {
+	// BDF 2.2 allows DWIDTH and DWIDTH1 before the glyphs, for all glyphs
+	// without their own.
+	bare := "STARTCHAR\tbare\nENCODING 66\nBBX 4 1 0 0\nBITMAP\nF0\nENDCHAR\n"
+	bdf := strings.Replace(testBDF(testBox(0x41), bare), "FONTBOUNDINGBOX 6 8 0 -2\n", "FONTBOUNDINGBOX 6 8 0 -2\nDWIDTH 7 0\n", 1)
+	font := parseTest(t, bdf)
+	if a, b := font.Glyphs[0].XAdvance, font.Glyphs[1].XAdvance; a != 5 || b != 7 {
+		t.Errorf("advances %d and %d, want 5 from the glyph and 7 from the font", a, b)
+	}
+	if name := font.Glyphs[1].Name; name != "bare" {
+		t.Errorf("STARTCHAR name %q after a tab, want bare", name)
+	}
+
+	// A vertical font with only a font-wide DWIDTH1.
+	bdf = strings.Replace(testBDF(bare), "FONTBOUNDINGBOX 6 8 0 -2\n", "FONTBOUNDINGBOX 6 8 0 -2\nMETRICSSET 1\nDWIDTH1 0 -9\n", 1)
+	if font := parseTest(t, bdf); font.Glyphs[0].XAdvance != 9 {
+		t.Errorf("advance %d with a font-wide DWIDTH1 of -9, want 9", font.Glyphs[0].XAdvance)
+	}
+}
+
+func TestParseRawMetricsZero(t *testing.T) {
+	// 40 thousandths of 10 pixels round to no line height at all.
+	var warnings []error
+	p := &Parser{Warn: func(err error) { warnings = append(warnings, err) }}
+	font, err := p.Parse(strings.NewReader(rawBDF(40, 0, testBox(0x41), testBox(0x42))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0].Error(), "scale to a line height of 0") {
+		t.Errorf("warnings %v, want one about a line height of 0", warnings)
+	}
+	if diags := font.Validate(); len(diags) != 1 || !errors.Is(diags[0].Err, ErrYAdvance) {
+		t.Errorf("Validate() = %v, want an ErrYAdvance warning", diags)
+	}
+
+	// The bitmap lines are then not wrapped by glyph height.
+	var buf bytes.Buffer
+	if _, err := (&Header{Font: font, Name: "Test"}).WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "0xFF, 0xFF, 0xFF, 0xFF, \n") {
+		t.Errorf("bitmaps not on one line:\n%s", &buf)
+	}
+}
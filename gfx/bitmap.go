@@ -0,0 +1,93 @@
+package gfx
+
+// BytesPerRow is the size of one bitmap row of a BDF glyph.
+func (g *Glyph) BytesPerRow() int {
+	return (g.Width + 7) / 8
+}
+
+// Row returns bitmap row y, counted from the top, as BytesPerRow bytes.
+// Bytes a bitmap with fewer rows than its BBX height lacks are zero.
+func (g *Glyph) Row(y int) []byte {
+	row := make([]byte, g.BytesPerRow())
+	if start := y * len(row); y >= 0 && start < len(g.Bitmap) {
+		copy(row, g.Bitmap[start:])
+	}
+	return row
+}
+
+// Pixel reports whether the pixel at column x and row y (counted from the
+// top of the bounding box) is set. Coordinates outside the box, and rows
+// missing from the bitmap, are unset.
+func (g *Glyph) Pixel(x, y int) bool {
+	if x < 0 || y < 0 || x >= g.Width || y >= g.Height {
+		return false
+	}
+	i := y*g.BytesPerRow() + x/8
+	return i < len(g.Bitmap) && g.Bitmap[i]&(0x80>>(x%8)) != 0
+}
+
+// SetPixel sets the pixel at column x and row y. Coordinates outside the
+// box, or rows missing from the bitmap, are ignored.
+func (g *Glyph) SetPixel(x, y int) {
+	if x < 0 || y < 0 || x >= g.Width || y >= g.Height {
+		return
+	}
+	if i := y*g.BytesPerRow() + x/8; i < len(g.Bitmap) {
+		g.Bitmap[i] |= 0x80 >> (x % 8)
+	}
+}
+
+// Level returns the gray level, from 0 to 2^bpp-1, of the pixel at column
+// x and row y. Glyphs without gray levels have only 0 and the maximum.
+func (g *Glyph) Level(x, y, bpp int) int {
+	if x < 0 || y < 0 || x >= g.Width || y >= g.Height {
+		return 0
+	}
+	if len(g.Gray) == g.Width*g.Height {
+		return int(g.Gray[y*g.Width+x])
+	}
+	if g.Pixel(x, y) {
+		return 1<<bpp - 1
+	}
+	return 0
+}
+
+// reframe changes the bounding box to width x height and moves every set
+// pixel by (dx, dy). Pixels that end up outside the new box are dropped.
+// Offsets and advance are left to the caller.
+func (g *Glyph) reframe(width, height, dx, dy int) {
+	n := &Glyph{Width: width, Height: height}
+	n.Bitmap = make([]byte, height*n.BytesPerRow())
+	if g.Gray != nil {
+		n.Gray = make([]byte, width*height)
+	}
+	for y := 0; y < g.Height; y++ {
+		for x := 0; x < g.Width; x++ {
+			if g.Pixel(x, y) {
+				n.SetPixel(x+dx, y+dy)
+			}
+			nx, ny := x+dx, y+dy
+			if n.Gray != nil && nx >= 0 && ny >= 0 && nx < width && ny < height {
+				n.Gray[ny*width+nx] = g.Gray[y*g.Width+x]
+			}
+		}
+	}
+	g.Width, g.Height, g.Bitmap, g.Gray = width, height, n.Bitmap, n.Gray
+}
+
+// ASCII renders the glyph bitmap as text, one line per row, using '#' for
+// set pixels and '.' for clear ones.
+func (g *Glyph) ASCII() string {
+	var b []byte
+	for y := 0; y < g.Height; y++ {
+		for x := 0; x < g.Width; x++ {
+			if g.Pixel(x, y) {
+				b = append(b, '#')
+			} else {
+				b = append(b, '.')
+			}
+		}
+		b = append(b, '\n')
+	}
+	return string(b)
+}
@@ -0,0 +1,46 @@
+package gfx
+
+// Blocks splits the codes of the font into ranges, starting a new range
+// wherever more than maxGap consecutive codes have no glyph.
+func (f *Font) Blocks(maxGap int) []CodeRange {
+	var blocks []CodeRange
+	for _, g := range f.Glyphs {
+		if n := len(blocks); n > 0 && g.Code-blocks[n-1].Last-1 <= maxGap {
+			blocks[n-1].Last = g.Code
+			continue
+		}
+		blocks = append(blocks, CodeRange{g.Code, g.Code})
+	}
+	return blocks
+}
+
+// Sub returns a copy of the font that holds the glyphs of r, with an empty
+// zero-advance glyph for every code in r the font does not define, so that
+// the glyph of a code is found at index code - First.
+func (f *Font) Sub(r CodeRange) *Font {
+	return f.fill(r, nil)
+}
+
+// fill is Sub with the glyphs of the missing codes made by missing, or
+// empty zero-advance glyphs when missing is nil.
+func (f *Font) fill(r CodeRange, missing func(code int) *Glyph) *Font {
+	sub := *f
+	sub.Glyphs = nil
+	i := 0
+	for i < len(f.Glyphs) && f.Glyphs[i].Code < r.First {
+		i++
+	}
+	for code := r.First; code <= r.Last; code++ {
+		if i < len(f.Glyphs) && f.Glyphs[i].Code == code {
+			sub.Glyphs = append(sub.Glyphs, f.Glyphs[i])
+			i++
+			continue
+		}
+		if missing != nil {
+			sub.Glyphs = append(sub.Glyphs, missing(code))
+			continue
+		}
+		sub.Glyphs = append(sub.Glyphs, &Glyph{Code: code, Bitmap: []byte{}})
+	}
+	return &sub
+}
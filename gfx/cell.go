@@ -0,0 +1,39 @@
+package gfx
+
+// RenderCell draws g into a fixed width x height cell and returns the cell
+// as MSB-first rows of (width+7)/8 bytes. The glyph is positioned by its
+// xOffset and by the font baseline, which sits Descent rows above the bottom
+// of the cell. clipped reports whether any ink fell outside the cell.
+func (f *Font) RenderCell(g *Glyph, width, height int) (cell []byte, clipped bool) {
+	return renderCell(g, width, height, 0, height-f.Descent)
+}
+
+// RenderCentered is like RenderCell but centers the glyph in the cell: its
+// advance horizontally, and the font's ascent plus descent vertically.
+func (f *Font) RenderCentered(g *Glyph, width, height int) (cell []byte, clipped bool) {
+	dx := floorDiv(width-g.XAdvance, 2)
+	baseline := floorDiv(height-(f.Ascent+f.Descent), 2) + f.Ascent
+	return renderCell(g, width, height, dx, baseline)
+}
+
+// renderCell draws g with its origin at column dx of the baseline row.
+func renderCell(g *Glyph, width, height, dx, baseline int) (cell []byte, clipped bool) {
+	c := &Glyph{Width: width, Height: height}
+	c.Bitmap = make([]byte, height*c.BytesPerRow())
+
+	top := baseline - (g.YOffset + g.Height)
+	for y := 0; y < g.Height; y++ {
+		for x := 0; x < g.Width; x++ {
+			if !g.Pixel(x, y) {
+				continue
+			}
+			cx, cy := dx+g.XOffset+x, top+y
+			if cx < 0 || cy < 0 || cx >= width || cy >= height {
+				clipped = true
+				continue
+			}
+			c.SetPixel(cx, cy)
+		}
+	}
+	return c.Bitmap, clipped
+}
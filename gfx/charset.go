@@ -0,0 +1,78 @@
+package gfx
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Charset returns the XLFD charset of the font, such as "ISO10646-1" or
+// "ISO8859-1", from the CHARSET_REGISTRY and CHARSET_ENCODING properties.
+// When they are missing the last two fields of the XLFD name are used.
+func (f *Font) Charset() string {
+	if f.CharsetRegistry != "" {
+		return f.CharsetRegistry + "-" + f.CharsetEncoding
+	}
+	if fields := xlfdFields(f.XLFD); fields != nil {
+		return fields[12] + "-" + fields[13]
+	}
+	return ""
+}
+
+// IsUnicode reports whether glyph codes are Unicode codepoints. Fonts
+// without any charset information are assumed to be Unicode.
+func (f *Font) IsUnicode() bool {
+	cs := strings.ToUpper(f.Charset())
+	return cs == "" || strings.HasPrefix(cs, "ISO10646-")
+}
+
+// Remap records a glyph whose code was changed.
+type Remap struct {
+	From, To int
+}
+
+// ToUnicode converts glyph codes from the font's legacy 8-bit charset to
+// Unicode using the built-in tables. Glyphs with codes the charset does not
+// define are dropped and returned separately. Unicode fonts are left as is.
+func (f *Font) ToUnicode() (remapped []Remap, dropped []*Glyph, err error) {
+	if f.IsUnicode() {
+		return nil, nil, nil
+	}
+	table, ok := legacyCharsets[strings.ToUpper(f.Charset())]
+	if !ok {
+		return nil, nil, fmt.Errorf("no built-in table for charset %s", f.Charset())
+	}
+
+	var kept []*Glyph
+	for _, g := range f.Glyphs {
+		switch {
+		case g.Code < 0x80:
+			kept = append(kept, g)
+		case g.Code > 0xFF || table[g.Code-0x80] == 0:
+			dropped = append(dropped, g)
+		default:
+			to := int(table[g.Code-0x80])
+			if to != g.Code {
+				remapped = append(remapped, Remap{g.Code, to})
+				g.Code = to
+			}
+			kept = append(kept, g)
+		}
+	}
+	slices.SortStableFunc(kept, func(a, b *Glyph) int { return a.Code - b.Code })
+	f.Glyphs = kept
+	to := map[int]int{}
+	for _, r := range remapped {
+		to[r.From] = r.To
+	}
+	for i, p := range f.Kerning {
+		if code, ok := to[p.Left]; ok {
+			f.Kerning[i].Left = code
+		}
+		if code, ok := to[p.Right]; ok {
+			f.Kerning[i].Right = code
+		}
+	}
+	f.CharsetRegistry, f.CharsetEncoding = "ISO10646", "1"
+	return remapped, dropped, nil
+}
@@ -0,0 +1,87 @@
+package gfx
+
+import (
+	"fmt"
+	"io"
+)
+
+// CodeSegment is a run of consecutive codes that all have a glyph, mapped
+// to consecutive glyph indexes like a segment of a TrueType format 4 cmap:
+// the glyph of code is at index code + Delta.
+type CodeSegment struct {
+	First, Last int
+	Delta       int
+}
+
+// Segments returns the runs of consecutive codes of glyphs, which must be
+// sorted by code and free of duplicates, with the glyph table index of
+// every code.
+func Segments(glyphs []*Glyph) []CodeSegment {
+	var segments []CodeSegment
+	for i, g := range glyphs {
+		if n := len(segments); n > 0 && segments[n-1].Last == g.Code-1 {
+			segments[n-1].Last = g.Code
+			continue
+		}
+		segments = append(segments, CodeSegment{g.Code, g.Code, i - g.Code})
+	}
+	return segments
+}
+
+// unique returns a copy of the font without the later glyphs of repeated
+// codes, which a contiguous table would not hold either.
+func (f *Font) unique() *Font {
+	u := *f
+	u.Glyphs = nil
+	for _, g := range f.Glyphs {
+		if n := len(u.Glyphs); n == 0 || u.Glyphs[n-1].Code != g.Code {
+			u.Glyphs = append(u.Glyphs, g)
+		}
+	}
+	return &u
+}
+
+// writeCodeMap emits the segments of a table without padding entries and
+// the lookup helpers that take the place of the GFXfont struct.
+func (h *Header) writeCodeMap(w io.Writer, t *table) {
+	segments := Segments(t.font.Glyphs)
+	fmt.Fprint(w, "// Runs of consecutive codes as { first, last, delta }: the glyph of a code\n")
+	fmt.Fprintf(w, "// in a run is %sGlyphs[(uint16_t)(code + delta)], like a TrueType format 4\n", t.name)
+	fmt.Fprint(w, "// cmap segment. The runs are sorted by code.\n")
+	fmt.Fprintf(w, "#define %s_SEGMENT_COUNT %d\n", t.name, len(segments))
+	fmt.Fprintf(w, "const uint16_t %sSegments[][3] PROGMEM = {\n", t.name)
+	for _, s := range segments {
+		fmt.Fprintf(w, "  { 0x%04X, 0x%04X, 0x%04X },\n", s.First, s.Last, uint16(s.Delta))
+	}
+	fmt.Fprint(w, "};\n\n")
+
+	if !h.MetricDefines {
+		first, last := t.font.Range()
+		fmt.Fprintf(w, "#define %s_FIRST 0x%x\n", t.name, first)
+		fmt.Fprintf(w, "#define %s_LAST 0x%x\n", t.name, last)
+		fmt.Fprintf(w, "#define %s_Y_ADVANCE %d\n\n", t.name, t.font.YAdvance())
+	}
+
+	fmt.Fprintf(w, "// %sGlyphIndex returns the index of the glyph for code in %sGlyphs,\n", t.name, t.name)
+	fmt.Fprint(w, "// or -1 if the font has none, with a binary search of the segments.\n")
+	fmt.Fprintf(w, "static inline int32_t %sGlyphIndex(uint16_t code) {\n", t.name)
+	fmt.Fprintf(w, "  int lo = 0, hi = %s_SEGMENT_COUNT - 1;\n", t.name)
+	fmt.Fprint(w, "  while (lo <= hi) {\n")
+	fmt.Fprint(w, "    int mid = (lo + hi) / 2;\n")
+	fmt.Fprintf(w, "    if (code < pgm_read_word(&%sSegments[mid][0])) {\n", t.name)
+	fmt.Fprint(w, "      hi = mid - 1;\n")
+	fmt.Fprintf(w, "    } else if (code > pgm_read_word(&%sSegments[mid][1])) {\n", t.name)
+	fmt.Fprint(w, "      lo = mid + 1;\n")
+	fmt.Fprint(w, "    } else {\n")
+	fmt.Fprintf(w, "      return (uint16_t)(code + pgm_read_word(&%sSegments[mid][2]));\n", t.name)
+	fmt.Fprint(w, "    }\n")
+	fmt.Fprint(w, "  }\n")
+	fmt.Fprint(w, "  return -1;\n")
+	fmt.Fprint(w, "}\n\n")
+
+	fmt.Fprintf(w, "// %sGetGlyph returns the glyph for code, or NULL if the font has none.\n", t.name)
+	fmt.Fprintf(w, "static inline const GFXglyph *%sGetGlyph(uint16_t code) {\n", t.name)
+	fmt.Fprintf(w, "  int32_t index = %sGlyphIndex(code);\n", t.name)
+	fmt.Fprintf(w, "  return index < 0 ? NULL : &%sGlyphs[index];\n", t.name)
+	fmt.Fprint(w, "}\n\n")
+}
@@ -0,0 +1,80 @@
+package gfx
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// segmentRow matches a row of the <name>Segments array.
+var segmentRow = regexp.MustCompile(`(?m)^  \{ 0x([0-9A-F]{4}), 0x([0-9A-F]{4}), 0x([0-9A-F]{4}) \},$`)
+
+// segmentLookup does what the emitted <name>GlyphIndex does with the rows of
+// <name>Segments.
+func segmentLookup(segments [][3]uint16, code uint16) int {
+	lo, hi := 0, len(segments)-1
+	for lo <= hi {
+		mid := (lo + hi) / 2
+		switch {
+		case code < segments[mid][0]:
+			hi = mid - 1
+		case code > segments[mid][1]:
+			lo = mid + 1
+		default:
+			return int(code + segments[mid][2])
+		}
+	}
+	return -1
+}
+
+func TestCodeMapLookup(t *testing.T) {
+	present := []int{0x20, 0x21, 0x22, 0x41, 0x42, 0x3B1, 0x2190, 0x2191, 0x2192, 0xFFFD}
+	var glyphs []string
+	for _, c := range present {
+		glyphs = append(glyphs, testBox(c))
+	}
+	font := parseTest(t, testBDF(glyphs...))
+
+	want := []CodeSegment{{0x20, 0x22, -0x20}, {0x41, 0x42, 3 - 0x41}, {0x3B1, 0x3B1, 5 - 0x3B1}, {0x2190, 0x2192, 6 - 0x2190}, {0xFFFD, 0xFFFD, 9 - 0xFFFD}}
+	if got := Segments(font.Glyphs); !slices.Equal(got, want) {
+		t.Errorf("Segments() = %v, want %v", got, want)
+	}
+
+	var buf bytes.Buffer
+	if _, err := (&Header{Font: font, Name: "Test", CodeMap: true}).WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	header := buf.String()
+	if !strings.Contains(header, fmt.Sprintf("#define Test_SEGMENT_COUNT %d\n", len(want))) {
+		t.Errorf("header without the segment count:\n%s", header)
+	}
+	if strings.Contains(header, "const GFXfont") {
+		t.Errorf("header has a GFXfont struct:\n%s", header)
+	}
+	if rows := strings.Count(header, "}, // 0x"); rows != len(present) {
+		t.Errorf("glyph table has %d rows, want %d without padding", rows, len(present))
+	}
+
+	var segments [][3]uint16
+	for _, m := range segmentRow.FindAllStringSubmatch(header, -1) {
+		var s [3]uint16
+		for i := range s {
+			v, _ := strconv.ParseUint(m[i+1], 16, 16)
+			s[i] = uint16(v)
+		}
+		segments = append(segments, s)
+	}
+	if len(segments) != len(want) {
+		t.Fatalf("%d segment rows in the header, want %d:\n%s", len(segments), len(want), header)
+	}
+	for code := 0; code <= 0xFFFF; code++ {
+		index := slices.Index(present, code)
+		if got := segmentLookup(segments, uint16(code)); got != index {
+			t.Errorf("lookup of 0x%04X = %d, want %d", code, got, index)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package gfx
+
+import "strings"
+
+// SanitizeComment makes s safe to embed in a C comment. It removes "*/",
+// which would end a block comment, control and non-ASCII characters, and
+// trailing backslashes, which would continue a line comment onto the next
+// line. ok reports whether s was already safe.
+func SanitizeComment(s string) (safe string, ok bool) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x20 || c >= 0x7F {
+			continue
+		}
+		b.WriteByte(c)
+	}
+	safe = b.String()
+	for strings.Contains(safe, "*/") {
+		safe = strings.ReplaceAll(safe, "*/", "")
+	}
+	safe = strings.TrimRight(safe, "\\")
+	return safe, safe == s
+}
+
+// commentSafe is SanitizeComment without the report.
+func commentSafe(s string) string {
+	safe, _ := SanitizeComment(s)
+	return safe
+}
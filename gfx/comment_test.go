@@ -0,0 +1,43 @@
+package gfx
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeComment(t *testing.T) {
+	tests := []struct {
+		in, want string
+		ok       bool
+	}{
+		{"uni0041", "uni0041", true},
+		{"A */ int x; /*", "A  int x; /*", false},
+		// Removing one "*/" must not leave another.
+		{"**//", "", false},
+		{"tab\there\x7f", "tabhere", false},
+		{"café", "caf", false},
+		{`line\`, "line", false},
+	}
+	for _, tt := range tests {
+		got, ok := SanitizeComment(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("SanitizeComment(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestHeaderHostileName(t *testing.T) {
+	hostile := testGlyph("A */ #error broken /*", 0x41, 4, 1, "F0")
+	font := parseTest(t, testBDF(hostile))
+	var buf bytes.Buffer
+	if _, err := (&Header{Font: font, Name: "Test", DebugArrays: true}).WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "// 0x0041 A  #error broken /*: 4x1") {
+		t.Errorf("header without the sanitized name:\n%s", &buf)
+	}
+	if strings.Contains(buf.String(), "*/ #error") {
+		t.Errorf("header has the raw name:\n%s", &buf)
+	}
+}
@@ -0,0 +1,152 @@
+package gfx
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// CompactIndexStep is the number of glyphs between two entries of the
+// index of a compact glyph table.
+const CompactIndexStep = 16
+
+// EncodeCompact encodes the metrics of the glyphs as variable-length
+// records. Each record holds, in this order:
+//
+//   - a byte with the width in the high and the height in the low nibble;
+//     a high nibble of 0xF instead means that width and height follow as
+//     unsigned LEB128 varints,
+//   - xAdvance as an unsigned varint,
+//   - a byte with xOffset in the high and the GFX yOffset in the low
+//     nibble, both two's complement; the byte 0x80 instead means that both
+//     follow as zigzag varints.
+//
+// The bitmap offset is not stored: the bitmaps are packed continuously, so
+// it is the sum of (width * height + 7) / 8 over the preceding glyphs. For
+// every CompactIndexStep-th glyph the index holds the offset of its record
+// and of its bitmap, so a decoder walks at most CompactIndexStep records.
+func EncodeCompact(glyphs []*Glyph) (records []byte, index [][2]int) {
+	bitmap := 0
+	for i, g := range glyphs {
+		if i%CompactIndexStep == 0 {
+			index = append(index, [2]int{len(records), bitmap})
+		}
+		bitmap += (g.Width*g.Height + 7) / 8
+		records = appendCompactRecord(records, g)
+	}
+	return records, index
+}
+
+func appendCompactRecord(rec []byte, g *Glyph) []byte {
+	if g.Width < 0xF && g.Height <= 0xF {
+		rec = append(rec, byte(g.Width<<4|g.Height))
+	} else {
+		rec = append(rec, 0xF0)
+		rec = binary.AppendUvarint(rec, uint64(g.Width))
+		rec = binary.AppendUvarint(rec, uint64(g.Height))
+	}
+	rec = binary.AppendUvarint(rec, uint64(g.XAdvance))
+	x, y := g.XOffset, g.YOffsetTFT()
+	if x >= -7 && x <= 7 && y >= -8 && y <= 7 {
+		return append(rec, byte(x&0xF)<<4|byte(y&0xF))
+	}
+	rec = append(rec, 0x80)
+	rec = binary.AppendVarint(rec, int64(x))
+	return binary.AppendVarint(rec, int64(y))
+}
+
+// writeCompact writes a table with compact glyph records instead of a
+// GFXglyph array, together with a decoder that fills a GFXglyph.
+func (h *Header) writeCompact(w io.Writer, t *table) {
+	_, index := EncodeCompact(t.font.Glyphs)
+
+	writeBitmaps(w, t, t.font.Ascent+t.font.Descent)
+
+	fmt.Fprintf(w, "// Glyph records, see %sGetGlyph for the layout.\n", t.name)
+	fmt.Fprintf(w, "const uint8_t %sRecords[] PROGMEM = {\n", t.name)
+	for _, g := range t.font.Glyphs {
+		row := "  "
+		for _, b := range appendCompactRecord(nil, g) {
+			row += fmt.Sprintf("0x%02X, ", b)
+		}
+		fmt.Fprintf(w, "%-24s%s\n", row, h.comment(max(len(row), 24), fmt.Sprintf("0x%04X", g.Code)))
+	}
+	fmt.Fprint(w, "};\n\n")
+
+	fmt.Fprintf(w, "// Record and bitmap offsets of every %s_INDEX_STEP-th glyph.\n", t.name)
+	fmt.Fprintf(w, "#define %s_INDEX_STEP %d\n", t.name, CompactIndexStep)
+	fmt.Fprintf(w, "const uint16_t %sIndex[][2] PROGMEM = {\n", t.name)
+	for _, e := range index {
+		fmt.Fprintf(w, "  { %d, %d },\n", e[0], e[1])
+	}
+	fmt.Fprint(w, "};\n\n")
+
+	first, last := t.font.Range()
+	fmt.Fprintf(w, "#define %s_FIRST 0x%x\n", t.name, first)
+	fmt.Fprintf(w, "#define %s_LAST 0x%x\n", t.name, last)
+	fmt.Fprintf(w, "#define %s_Y_ADVANCE %d\n\n", t.name, t.font.YAdvance())
+
+	fmt.Fprintf(w, "static inline uint16_t %sReadUvarint(const uint8_t **p) {\n", t.name)
+	fmt.Fprint(w, "  uint16_t v = 0;\n")
+	fmt.Fprint(w, "  uint8_t shift = 0, b;\n")
+	fmt.Fprint(w, "  do {\n")
+	fmt.Fprint(w, "    b = pgm_read_byte((*p)++);\n")
+	fmt.Fprint(w, "    v |= (uint16_t)(b & 0x7F) << shift;\n")
+	fmt.Fprint(w, "    shift += 7;\n")
+	fmt.Fprint(w, "  } while (b & 0x80);\n")
+	fmt.Fprint(w, "  return v;\n")
+	fmt.Fprint(w, "}\n\n")
+	fmt.Fprintf(w, "static inline int16_t %sReadVarint(const uint8_t **p) {\n", t.name)
+	fmt.Fprintf(w, "  uint16_t u = %sReadUvarint(p);\n", t.name)
+	fmt.Fprint(w, "  return (int16_t)(u >> 1) ^ -(int16_t)(u & 1);\n")
+	fmt.Fprint(w, "}\n\n")
+
+	fmt.Fprint(w, "// Fills *glyph with the metrics of the glyph at index, which is code minus\n")
+	fmt.Fprintf(w, "// %s_FIRST. A record is a byte with width and height nibbles, or 0xF_\n", t.name)
+	fmt.Fprint(w, "// followed by both as LEB128 varints; xAdvance as a varint; and a byte with\n")
+	fmt.Fprint(w, "// signed xOffset and yOffset nibbles, or 0x80 followed by both as zigzag\n")
+	fmt.Fprint(w, "// varints. Bitmaps are packed continuously, so bitmapOffset is the sum of\n")
+	fmt.Fprint(w, "// (width * height + 7) / 8 over the preceding glyphs.\n")
+	fmt.Fprintf(w, "static inline void %sGetGlyph(uint16_t index, GFXglyph *glyph) {\n", t.name)
+	fmt.Fprintf(w, "  uint16_t i = index / %s_INDEX_STEP;\n", t.name)
+	fmt.Fprintf(w, "  const uint8_t *p = &%sRecords[pgm_read_word(&%sIndex[i][0])];\n", t.name, t.name)
+	fmt.Fprintf(w, "  uint16_t offset = pgm_read_word(&%sIndex[i][1]);\n", t.name)
+	fmt.Fprintf(w, "  for (i *= %s_INDEX_STEP;; i++) {\n", t.name)
+	fmt.Fprint(w, "    uint8_t b = pgm_read_byte(p++);\n")
+	fmt.Fprint(w, "    uint16_t width = b >> 4, height = b & 0x0F;\n")
+	fmt.Fprint(w, "    if (width == 0x0F) {\n")
+	fmt.Fprintf(w, "      width = %sReadUvarint(&p);\n", t.name)
+	fmt.Fprintf(w, "      height = %sReadUvarint(&p);\n", t.name)
+	fmt.Fprint(w, "    }\n")
+	fmt.Fprintf(w, "    uint16_t xAdvance = %sReadUvarint(&p);\n", t.name)
+	fmt.Fprint(w, "    b = pgm_read_byte(p++);\n")
+	fmt.Fprint(w, "    int16_t xOffset = ((b >> 4) ^ 8) - 8, yOffset = ((b & 0x0F) ^ 8) - 8;\n")
+	fmt.Fprint(w, "    if (b == 0x80) {\n")
+	fmt.Fprintf(w, "      xOffset = %sReadVarint(&p);\n", t.name)
+	fmt.Fprintf(w, "      yOffset = %sReadVarint(&p);\n", t.name)
+	fmt.Fprint(w, "    }\n")
+	fmt.Fprint(w, "    if (i == index) {\n")
+	fmt.Fprint(w, "      // Empty glyphs at the end would point past the bitmaps.\n")
+	fmt.Fprint(w, "      glyph->bitmapOffset = width && height ? offset : 0;\n")
+	fmt.Fprint(w, "      glyph->width = width;\n")
+	fmt.Fprint(w, "      glyph->height = height;\n")
+	fmt.Fprint(w, "      glyph->xAdvance = xAdvance;\n")
+	fmt.Fprint(w, "      glyph->xOffset = xOffset;\n")
+	fmt.Fprint(w, "      glyph->yOffset = yOffset;\n")
+	fmt.Fprint(w, "      return;\n")
+	fmt.Fprint(w, "    }\n")
+	fmt.Fprint(w, "    offset += ((uint32_t)width * height + 7) / 8;\n")
+	fmt.Fprint(w, "  }\n")
+	fmt.Fprint(w, "}\n\n")
+}
+
+// checkCompact reports whether the compact layout can hold the table.
+func (h *Header) checkCompact(t *table) error {
+	if h.Blocks || h.CodeMap || h.Planes || h.Delta || h.Huffman || h.Columns || h.GlyphCRC || h.Wide || h.WideAdvance || h.AdvanceIndex || h.Vertical || h.MissingBox || h.Align > 1 || len(h.BitmapOrder) > 0 || h.Part != AllParts {
+		return fmt.Errorf("compact glyph records cannot be combined with other bitmap or table layouts")
+	}
+	if records, _ := EncodeCompact(t.font.Glyphs); len(records) > 0xFFFF {
+		return fmt.Errorf("compact glyph records of %d bytes do not fit uint16_t offsets", len(records))
+	}
+	return nil
+}
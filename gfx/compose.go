@@ -0,0 +1,124 @@
+package gfx
+
+import "sort"
+
+// Compose overlays the combining glyph mark onto base and returns the
+// result as a new glyph with the given code and the advance of base. A
+// zero-advance mark is placed where a renderer would draw it, at the pen
+// position after base; a spacing mark is centred over the ink of base.
+// Both glyphs keep their vertical position relative to the baseline.
+func Compose(base, mark *Glyph, code int) *Glyph {
+	dx := base.XAdvance
+	if mark.XAdvance != 0 {
+		dx = base.XOffset + (base.Width-mark.Width)/2 - mark.XOffset
+	}
+
+	type box struct{ x0, y0, x1, y1 int } // pen coordinates, y up
+	boxOf := func(g *Glyph, dx int) box {
+		return box{g.XOffset + dx, g.YOffset, g.XOffset + dx + g.Width, g.YOffset + g.Height}
+	}
+	b, m := boxOf(base, 0), boxOf(mark, dx)
+	switch {
+	case base.Width == 0 || base.Height == 0:
+		b = m
+	case mark.Width == 0 || mark.Height == 0:
+		m = b
+	}
+	u := box{min(b.x0, m.x0), min(b.y0, m.y0), max(b.x1, m.x1), max(b.y1, m.y1)}
+
+	g := &Glyph{
+		Code:     code,
+		Name:     base.Name + "+" + mark.Name,
+		Width:    u.x1 - u.x0,
+		Height:   u.y1 - u.y0,
+		XOffset:  u.x0,
+		YOffset:  u.y0,
+		XAdvance: base.XAdvance,
+	}
+	g.Bitmap = make([]byte, g.Height*g.BytesPerRow())
+	draw := func(src *Glyph, dx int) {
+		for y := 0; y < src.Height; y++ {
+			for x := 0; x < src.Width; x++ {
+				if src.Pixel(x, y) {
+					// Row y of src is (top of src - y) in pen coordinates.
+					g.SetPixel(src.XOffset+dx+x-u.x0, u.y1-(src.YOffset+src.Height)+y)
+				}
+			}
+		}
+	}
+	draw(base, 0)
+	draw(mark, dx)
+	return g
+}
+
+// Glyph returns the glyph with the given codepoint; of repeated codes the
+// first in Glyphs is found. The first call builds an index of the codes,
+// which later calls reuse until Glyphs is replaced or changes length, or a
+// glyph found through it has another code. After changing codes in place
+// without touching the slice, as Rebase does, call Reindex. Building the
+// index makes concurrent calls unsafe.
+func (f *Font) Glyph(code int) (*Glyph, bool) {
+	if i := f.glyphIndex(code); i >= 0 {
+		return f.Glyphs[i], true
+	}
+	return nil, false
+}
+
+// codeIndex maps the codes of the glyphs of a font to their index, for the
+// glyph slice it was built from.
+type codeIndex struct {
+	glyphs []*Glyph
+	index  map[int]int
+}
+
+// current reports whether the index was built from glyphs.
+func (ix *codeIndex) current(glyphs []*Glyph) bool {
+	return ix != nil && len(ix.glyphs) == len(glyphs) && (len(glyphs) == 0 || &ix.glyphs[0] == &glyphs[0])
+}
+
+// Reindex drops the code index of Glyph, so the next lookup builds it
+// anew.
+func (f *Font) Reindex() {
+	f.index = nil
+}
+
+// glyphIndex returns the index of the glyph with the given codepoint, or -1,
+// through the code index, see Glyph.
+func (f *Font) glyphIndex(code int) int {
+	for rebuilt := false; ; rebuilt = true {
+		if !f.index.current(f.Glyphs) {
+			ix := &codeIndex{f.Glyphs, make(map[int]int, len(f.Glyphs))}
+			for i, g := range f.Glyphs {
+				if _, ok := ix.index[g.Code]; !ok {
+					ix.index[g.Code] = i
+				}
+			}
+			f.index = ix
+		}
+		i, ok := f.index.index[code]
+		switch {
+		case !ok:
+			return -1
+		case f.Glyphs[i].Code == code:
+			return i
+		case rebuilt:
+			return -1
+		}
+		// A code was changed in place.
+		f.index = nil
+	}
+}
+
+// AddGlyph inserts g keeping the glyphs sorted, replacing any glyph with
+// the same code. It reports whether a glyph was replaced.
+func (f *Font) AddGlyph(g *Glyph) bool {
+	i := sort.Search(len(f.Glyphs), func(i int) bool { return f.Glyphs[i].Code >= g.Code })
+	if i < len(f.Glyphs) && f.Glyphs[i].Code == g.Code {
+		f.Glyphs[i] = g
+		return true
+	}
+	f.Glyphs = append(f.Glyphs, nil)
+	copy(f.Glyphs[i+1:], f.Glyphs[i:])
+	f.Glyphs[i] = g
+	return false
+}
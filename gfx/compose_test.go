@@ -0,0 +1,96 @@
+package gfx
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestGlyphLookup(t *testing.T) {
+	font := parseTest(t, testBDF(testBox(0x41), testBox(0x20), testBox(0x7E)))
+	for _, code := range []int{0x20, 0x41, 0x7E} {
+		g, ok := font.Glyph(code)
+		if !ok || g.Code != code {
+			t.Errorf("Glyph(0x%04X) = %v, %v, want the glyph", code, g, ok)
+		}
+	}
+	for _, code := range []int{-1, 0, 0x21, 0x7F, 0x10000} {
+		if g, ok := font.Glyph(code); ok || g != nil {
+			t.Errorf("Glyph(0x%04X) = %v, %v, want nothing", code, g, ok)
+		}
+	}
+}
+
+func TestGlyphLookupUnsorted(t *testing.T) {
+	// The index does not rely on the glyphs being sorted.
+	a, b, c := &Glyph{Code: 0x43}, &Glyph{Code: 0x41}, &Glyph{Code: 0x42}
+	font := &Font{Glyphs: []*Glyph{a, b, c}}
+	for _, g := range []*Glyph{a, b, c} {
+		if got, ok := font.Glyph(g.Code); !ok || got != g {
+			t.Errorf("Glyph(0x%04X) = %v, %v, want %v", g.Code, got, ok, g)
+		}
+	}
+}
+
+func TestGlyphLookupFollowsChanges(t *testing.T) {
+	font := parseTest(t, testBDF(testBox(0x41), testBox(0x42)))
+	if _, ok := font.Glyph(0x41); !ok {
+		t.Fatal("no glyph 0x41")
+	}
+
+	// A new slice.
+	font.Filter(func(code int) bool { return code == 0x42 })
+	if _, ok := font.Glyph(0x41); ok {
+		t.Error("Glyph(0x41) found a filtered glyph")
+	}
+
+	// Codes changed in place.
+	font.Rebase(0x30)
+	if g, ok := font.Glyph(0x30); !ok || g.Name != "u0042" {
+		t.Errorf("Glyph(0x30) after Rebase = %v, %v, want u0042", g, ok)
+	}
+	if _, ok := font.Glyph(0x42); ok {
+		t.Error("Glyph(0x42) found the old code after Rebase")
+	}
+
+	// A glyph replaced in place.
+	replacement := &Glyph{Code: 0x30, Name: "new"}
+	font.AddGlyph(replacement)
+	if g, _ := font.Glyph(0x30); g != replacement {
+		t.Errorf("Glyph(0x30) = %v, want the replacement", g)
+	}
+
+	// Inserted glyphs.
+	font.AddGlyph(&Glyph{Code: 0x20})
+	if got, want := codes(font), []int{0x20, 0x30}; !slices.Equal(got, want) {
+		t.Fatalf("codes %#x, want %#x", got, want)
+	}
+	if g, ok := font.Glyph(0x20); !ok || g.Code != 0x20 {
+		t.Errorf("Glyph(0x20) = %v, %v after AddGlyph", g, ok)
+	}
+}
+
+func TestGlyphLookupRepeatedCode(t *testing.T) {
+	first, second := &Glyph{Code: 0x41, Name: "first"}, &Glyph{Code: 0x41, Name: "second"}
+	font := &Font{Glyphs: []*Glyph{first, second}}
+	if g, _ := font.Glyph(0x41); g != first {
+		t.Errorf("Glyph(0x41) = %v, want the first of the repeated code", g)
+	}
+}
+
+func TestMergeConflicts(t *testing.T) {
+	font := parseTest(t, testBDF(testBox(0x41), testBox(0x43)))
+	other := parseTest(t, testBDF(testBox(0x42), testBox(0x43), testBox(0x44)))
+	conflicts, err := font.Merge(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(conflicts, []int{0x43}) {
+		t.Errorf("conflicts %#x, want 0x43", conflicts)
+	}
+	if got, want := codes(font), []int{0x41, 0x42, 0x43, 0x44}; !slices.Equal(got, want) {
+		t.Errorf("codes %#x, want %#x", got, want)
+	}
+	if _, ok := font.Glyph(0x44); !ok {
+		t.Error("Glyph(0x44) misses a merged glyph")
+	}
+}
@@ -0,0 +1,82 @@
+package gfx
+
+// Delta marks a glyph whose bitmap is stored as a delta record against the
+// bitmap of an earlier glyph of the same size.
+type Delta struct {
+	Glyph, Base int // indexes into the glyph slice
+}
+
+// deltaEntrySize is the size of a Delta in the C header, two uint16_t.
+const deltaEntrySize = 4
+
+// PackGlyphsDelta is like PackGlyphs with an align of one, but stores a
+// glyph as a delta record against an earlier glyph of the same width and
+// height when the record and its Delta entry take fewer bytes than the
+// bitmap itself. The bases are always stored in full, so decoding never
+// chains. The deltas are returned sorted by glyph index.
+//
+// A delta record holds runs of three parts: a byte with the number of
+// bytes that equal the base, a byte with the number n of bytes that
+// differ, and those n bytes XORed with the base. The runs repeat until the
+// whole bitmap is covered.
+func PackGlyphsDelta(glyphs []*Glyph) ([]byte, []int, []Delta) {
+	bitmaps := make([][]byte, len(glyphs))
+	for i, g := range glyphs {
+		bitmaps[i] = g.PackedBitmap()
+	}
+
+	type size struct{ w, h int }
+	bases := map[size][]int{}
+	stored := make([][]byte, len(glyphs))
+	var deltas []Delta
+	for i, g := range glyphs {
+		stored[i] = bitmaps[i]
+		if len(bitmaps[i]) == 0 {
+			continue
+		}
+		key := size{g.Width, g.Height}
+		best, bestBase := []byte(nil), -1
+		for _, b := range bases[key] {
+			if rec := deltaRecord(bitmaps[b], bitmaps[i]); best == nil || len(rec) < len(best) {
+				best, bestBase = rec, b
+			}
+		}
+		if best != nil && len(best)+deltaEntrySize < len(bitmaps[i]) {
+			stored[i] = best
+			deltas = append(deltas, Delta{i, bestBase})
+			continue
+		}
+		bases[key] = append(bases[key], i)
+	}
+
+	storedOf := map[*Glyph][]byte{}
+	for i, g := range glyphs {
+		storedOf[g] = stored[i]
+	}
+	blob, offsets := packWith(glyphs, 1, func(g *Glyph) []byte { return storedOf[g] })
+	return blob, offsets, deltas
+}
+
+// deltaRecord encodes the difference of b to base, which have the same
+// length, as described at PackGlyphsDelta.
+func deltaRecord(base, b []byte) []byte {
+	var rec []byte
+	pos := 0
+	for pos < len(b) {
+		skip := 0
+		for pos+skip < len(b) && skip < 0xFF && base[pos+skip] == b[pos+skip] {
+			skip++
+		}
+		pos += skip
+		n := 0
+		for pos+n < len(b) && n < 0xFF && base[pos+n] != b[pos+n] {
+			n++
+		}
+		rec = append(rec, byte(skip), byte(n))
+		for j := 0; j < n; j++ {
+			rec = append(rec, base[pos+j]^b[pos+j])
+		}
+		pos += n
+	}
+	return rec
+}
@@ -0,0 +1,93 @@
+package gfx
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// decodeDelta applies a delta record to the bitmap of its base.
+func decodeDelta(base, rec []byte) []byte {
+	b := bytes.Clone(base)
+	pos := 0
+	for len(rec) >= 2 {
+		skip, n := int(rec[0]), int(rec[1])
+		pos += skip
+		for j := 0; j < n; j++ {
+			b[pos+j] ^= rec[2+j]
+		}
+		pos += n
+		rec = rec[2+n:]
+	}
+	return b
+}
+
+func TestPackGlyphsDelta(t *testing.T) {
+	// 16x16 glyphs that differ from the first in one row, and one that
+	// differs everywhere.
+	rows := func(row int, value string) []string {
+		r := make([]string, 16)
+		for y := range r {
+			r[y] = "8001"
+		}
+		if row >= 0 {
+			r[row] = value
+		}
+		return r
+	}
+	var glyphs []string
+	glyphs = append(glyphs, testGlyph("base", 0x41, 16, 16, rows(-1, "")...))
+	glyphs = append(glyphs, testGlyph("near", 0x42, 16, 16, rows(3, "FFFF")...))
+	glyphs = append(glyphs, testGlyph("nearer", 0x43, 16, 16, rows(15, "8003")...))
+	inverse := make([]string, 16)
+	for y := range inverse {
+		inverse[y] = "7FFE"
+	}
+	glyphs = append(glyphs, testGlyph("inverse", 0x44, 16, 16, inverse...))
+	glyphs = append(glyphs, testBox(0x45))
+	font := parseTest(t, testBDF(glyphs...))
+
+	blob, offsets, deltas := PackGlyphsDelta(font.Glyphs)
+	if want := []Delta{{1, 0}, {2, 0}}; fmt.Sprint(deltas) != fmt.Sprint(want) {
+		t.Fatalf("deltas %v, want %v", deltas, want)
+	}
+	// near: 6 equal bytes, 2 different ones, then 24 equal ones. nearer:
+	// 31 equal bytes and 1 different one.
+	if want := 32 + 6 + 3 + 32 + 2; len(blob) != want {
+		t.Errorf("blob of %d bytes, want %d", len(blob), want)
+	}
+
+	// Every glyph decodes to its plain bitmap.
+	base := map[int]int{}
+	for _, d := range deltas {
+		base[d.Glyph] = d.Base
+	}
+	for i, g := range font.Glyphs {
+		want := g.PackedBitmap()
+		end := len(blob)
+		if i+1 < len(offsets) {
+			end = offsets[i+1]
+		}
+		got := blob[offsets[i]:end]
+		if b, ok := base[i]; ok {
+			got = decodeDelta(blob[offsets[b]:offsets[b]+len(want)], got)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("glyph %s decodes to % X, want % X", g.Name, got, want)
+		}
+	}
+
+	var buf bytes.Buffer
+	if _, err := (&Header{Font: font, Name: "Test", Delta: true}).WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"TestDeltas[] PROGMEM", "TestDecodeBitmap("} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("header without %s:\n%s", want, &buf)
+		}
+	}
+	if _, err := (&Header{Font: font, Delta: true, Align: 4}).WriteTo(&buf); err == nil {
+		t.Error("Delta with Align did not fail")
+	}
+}
@@ -0,0 +1,47 @@
+package gfx
+
+import "bytes"
+
+// GlyphChange describes how a glyph present in both fonts differs.
+type GlyphChange struct {
+	Code    int  `json:"code"`
+	Metrics bool `json:"metrics"`
+	Bitmap  bool `json:"bitmap"`
+}
+
+// FontDiff lists the differences between two fonts.
+type FontDiff struct {
+	Added   []int         `json:"added"`
+	Removed []int         `json:"removed"`
+	Changed []GlyphChange `json:"changed"`
+}
+
+// Diff compares the glyphs of a and b by codepoint.
+func Diff(a, b *Font) *FontDiff {
+	d := &FontDiff{}
+	i, j := 0, 0
+	for i < len(a.Glyphs) || j < len(b.Glyphs) {
+		switch {
+		case j == len(b.Glyphs) || (i < len(a.Glyphs) && a.Glyphs[i].Code < b.Glyphs[j].Code):
+			d.Removed = append(d.Removed, a.Glyphs[i].Code)
+			i++
+		case i == len(a.Glyphs) || b.Glyphs[j].Code < a.Glyphs[i].Code:
+			d.Added = append(d.Added, b.Glyphs[j].Code)
+			j++
+		default:
+			ga, gb := a.Glyphs[i], b.Glyphs[j]
+			c := GlyphChange{
+				Code: ga.Code,
+				Metrics: ga.Width != gb.Width || ga.Height != gb.Height || ga.XOffset != gb.XOffset ||
+					ga.YOffset != gb.YOffset || ga.XAdvance != gb.XAdvance,
+				Bitmap: !bytes.Equal(ga.Bitmap, gb.Bitmap),
+			}
+			if c.Metrics || c.Bitmap {
+				d.Changed = append(d.Changed, c)
+			}
+			i++
+			j++
+		}
+	}
+	return d
+}
@@ -0,0 +1,110 @@
+package gfx
+
+import "fmt"
+
+// Dither selects how the gray levels of a grayscale font are reduced to
+// the 1-bit bitmap every glyph carries.
+type Dither int
+
+const (
+	// DitherThreshold sets the pixels of at least half intensity, as the
+	// parser does.
+	DitherThreshold Dither = iota
+	// DitherFloydSteinberg diffuses the error of every pixel to its
+	// neighbours to the right and below, within the glyph.
+	DitherFloydSteinberg
+	// DitherOrdered compares the levels with a 4x4 Bayer matrix.
+	DitherOrdered
+)
+
+// ParseDither returns the Dither named threshold, floyd-steinberg or
+// ordered.
+func ParseDither(name string) (Dither, error) {
+	switch name {
+	case "threshold":
+		return DitherThreshold, nil
+	case "floyd-steinberg":
+		return DitherFloydSteinberg, nil
+	case "ordered":
+		return DitherOrdered, nil
+	}
+	return 0, fmt.Errorf("unknown dither method %q", name)
+}
+
+// bayer4 is the 4x4 Bayer threshold matrix, with values 0 to 15.
+var bayer4 = [4][4]int{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+// Dither recomputes the bitmap of g from its gray levels, of bpp bits
+// each. The levels are kept, so it can be called again with another
+// method. Glyphs without gray levels are left alone.
+func (g *Glyph) Dither(d Dither, bpp int) {
+	if len(g.Gray) != g.Width*g.Height || len(g.Gray) == 0 {
+		return
+	}
+	maxLevel := 1<<bpp - 1
+	g.Bitmap = make([]byte, g.Height*g.BytesPerRow())
+	switch d {
+	case DitherThreshold:
+		for y := 0; y < g.Height; y++ {
+			for x := 0; x < g.Width; x++ {
+				if int(g.Gray[y*g.Width+x]) > maxLevel/2 {
+					g.SetPixel(x, y)
+				}
+			}
+		}
+	case DitherFloydSteinberg:
+		// Levels are kept in sixteenths to limit the rounding of the error.
+		value := make([]int, len(g.Gray))
+		for i, l := range g.Gray {
+			value[i] = int(l) * 16
+		}
+		spread := func(x, y, e int) {
+			if x >= 0 && x < g.Width && y < g.Height {
+				value[y*g.Width+x] += e
+			}
+		}
+		for y := 0; y < g.Height; y++ {
+			for x := 0; x < g.Width; x++ {
+				v, out := value[y*g.Width+x], 0
+				if 2*v > maxLevel*16 {
+					g.SetPixel(x, y)
+					out = maxLevel * 16
+				}
+				e := v - out
+				spread(x+1, y, e*7/16)
+				spread(x-1, y+1, e*3/16)
+				spread(x, y+1, e*5/16)
+				spread(x+1, y+1, e/16)
+			}
+		}
+	case DitherOrdered:
+		for y := 0; y < g.Height; y++ {
+			for x := 0; x < g.Width; x++ {
+				// Set when level/max is above (b+0.5)/16.
+				if 32*int(g.Gray[y*g.Width+x]) > (2*bayer4[y%4][x%4]+1)*maxLevel {
+					g.SetPixel(x, y)
+				}
+			}
+		}
+	}
+}
+
+// Dither recomputes the bitmaps of a grayscale font, see Glyph.Dither. It
+// returns the number of glyphs whose bitmap changed.
+func (f *Font) Dither(d Dither) int {
+	bpp := max(f.BitsPerPixel, 1)
+	changed := 0
+	for _, g := range f.Glyphs {
+		before := string(g.Bitmap)
+		g.Dither(d, bpp)
+		if string(g.Bitmap) != before {
+			changed++
+		}
+	}
+	return changed
+}
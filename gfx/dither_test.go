@@ -0,0 +1,63 @@
+package gfx
+
+import (
+	"strings"
+	"testing"
+)
+
+// setPixels counts the pixels set in the bitmap of g.
+func setPixels(g *Glyph) int {
+	n := 0
+	for y := 0; y < g.Height; y++ {
+		for x := 0; x < g.Width; x++ {
+			if g.Pixel(x, y) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func TestDither(t *testing.T) {
+	// A 4 bpp 4x4 glyph of level 7 of 15, just below half intensity.
+	gray := testGlyph("gray", 0x41, 4, 4, "7777", "7777", "7777", "7777")
+	bdf := strings.Replace(testBDF(gray), "SIZE 8 75 75\n", "SIZE 8 75 75 4\n", 1)
+
+	font := parseTest(t, bdf)
+	if font.BitsPerPixel != 4 || setPixels(font.Glyphs[0]) != 0 {
+		t.Fatalf("parsed %d bpp with %d pixels set, want 4 bpp and none", font.BitsPerPixel, setPixels(font.Glyphs[0]))
+	}
+	if n := font.Dither(DitherThreshold); n != 0 {
+		t.Errorf("threshold changed %d glyphs, want none", n)
+	}
+
+	// Dithering keeps the perceived weight: close to 7 of 16 pixels.
+	for _, d := range []Dither{DitherFloydSteinberg, DitherOrdered} {
+		font := parseTest(t, bdf)
+		if n := font.Dither(d); n != 1 {
+			t.Errorf("dither %d changed %d glyphs, want 1", d, n)
+		}
+		if n := setPixels(font.Glyphs[0]); n < 6 || n > 8 {
+			t.Errorf("dither %d set %d of 16 pixels, want about 7", d, n)
+		}
+		if n := len(font.Glyphs[0].Gray); n != 16 {
+			t.Errorf("dither %d kept %d gray levels, want 16", d, n)
+		}
+	}
+
+	// The ordered pattern is fixed by the Bayer matrix.
+	font = parseTest(t, bdf)
+	font.Dither(DitherOrdered)
+	want := []string{"#.#.", ".#.#", "#.#.", "...#"}
+	for y, row := range want {
+		for x, c := range row {
+			if got := font.Glyphs[0].Pixel(x, y); got != (c == '#') {
+				t.Errorf("ordered pixel %d,%d is %v, want %c", x, y, got, c)
+			}
+		}
+	}
+
+	if _, err := ParseDither("random"); err == nil {
+		t.Error("ParseDither accepts an unknown method")
+	}
+}
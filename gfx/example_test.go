@@ -0,0 +1,54 @@
+package gfx_test
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+const checkerBDF = `STARTFONT 2.1
+FONTBOUNDINGBOX 4 2 0 0
+STARTPROPERTIES 2
+FONT_ASCENT 2
+FONT_DESCENT 0
+ENDPROPERTIES
+CHARS 1
+STARTCHAR A
+ENCODING 65
+DWIDTH 5 0
+BBX 4 2 0 0
+BITMAP
+90
+60
+ENDCHAR
+ENDFONT
+`
+
+// This inverts every glyph while the header is written. The pixels of the
+// 4x2 glyph, 1001 0110, pack into 0x96 and come out as 0x69.
+func ExampleHeader_glyphTransform() {
+	font, err := gfx.ParseBDF(strings.NewReader(checkerBDF))
+	if err != nil {
+		log.Fatal(err)
+	}
+	h := &gfx.Header{Font: font, Name: "MyFont", GlyphTransform: func(g *gfx.Glyph) {
+		for i := range g.Bitmap {
+			g.Bitmap[i] = ^g.Bitmap[i]
+		}
+	}}
+	var buf bytes.Buffer
+	if _, err := h.WriteTo(&buf); err != nil {
+		log.Fatal(err)
+	}
+	_, bitmaps, _ := strings.Cut(buf.String(), "MyFontBitmaps[] PROGMEM = {\n")
+	bitmaps, _, _ = strings.Cut(bitmaps, "\n")
+	fmt.Println(strings.TrimSpace(bitmaps))
+	// The font itself is left alone.
+	fmt.Printf("% X\n", font.Glyphs[0].Bitmap)
+	// Output:
+	// 0x69,
+	// 90 60
+}
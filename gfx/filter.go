@@ -0,0 +1,101 @@
+package gfx
+
+import "unicode"
+
+// IsBlank reports whether a glyph draws no pixels at all.
+func (g *Glyph) IsBlank() bool {
+	for _, b := range g.Bitmap {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// blankCode reports whether a codepoint is expected to draw nothing: the
+// spaces, the control and format characters such as the zero-width
+// joiners, and the blank braille pattern.
+func blankCode(code int) bool {
+	r := rune(code)
+	return unicode.IsSpace(r) || unicode.In(r, unicode.Zs, unicode.Cc, unicode.Cf) || r == 0x2800
+}
+
+// InklessGlyphs returns the glyphs that have a BBX of at least one pixel
+// but an all-zero bitmap, which usually means the bitmap was lost in a
+// conversion. Codepoints that are expected to be blank, such as the
+// spaces, are left out.
+func (f *Font) InklessGlyphs() []*Glyph {
+	var inkless []*Glyph
+	for _, g := range f.Glyphs {
+		if g.Width > 0 && g.Height > 0 && g.IsBlank() && !blankCode(g.Code+f.CodeBase) {
+			inkless = append(inkless, g)
+		}
+	}
+	return inkless
+}
+
+// TrimBlank drops blank glyphs from the end (and optionally the start) of
+// the glyph list so that first/last cover only glyphs with ink. It returns
+// the number of glyphs removed.
+func (f *Font) TrimBlank(leading, trailing bool) int {
+	glyphs := f.Glyphs
+	if trailing {
+		for len(glyphs) > 0 && glyphs[len(glyphs)-1].IsBlank() {
+			glyphs = glyphs[:len(glyphs)-1]
+		}
+	}
+	if leading {
+		for len(glyphs) > 0 && glyphs[0].IsBlank() {
+			glyphs = glyphs[1:]
+		}
+	}
+	n := len(f.Glyphs) - len(glyphs)
+	f.Glyphs = glyphs
+	return n
+}
+
+// Rebase renumbers the glyphs so that the first one gets code base. The
+// shift is recorded in CodeBase so real codepoints can be recovered.
+func (f *Font) Rebase(base int) {
+	if len(f.Glyphs) == 0 {
+		return
+	}
+	shift := f.Glyphs[0].Code - base
+	for _, g := range f.Glyphs {
+		g.Code -= shift
+	}
+	f.Reindex()
+	for i := range f.Kerning {
+		f.Kerning[i].Left -= shift
+		f.Kerning[i].Right -= shift
+	}
+	f.CodeBase += shift
+}
+
+// Filter keeps only the glyphs whose codepoint satisfies keep, preserving
+// their order, and returns the number of glyphs removed.
+func (f *Font) Filter(keep func(code int) bool) int {
+	var kept []*Glyph
+	for _, g := range f.Glyphs {
+		if keep(g.Code) {
+			kept = append(kept, g)
+		}
+	}
+	n := len(f.Glyphs) - len(kept)
+	f.Glyphs = kept
+	return n
+}
+
+// Drop removes the glyphs for which drop returns true and returns them.
+func (f *Font) Drop(drop func(g *Glyph) bool) []*Glyph {
+	var kept, dropped []*Glyph
+	for _, g := range f.Glyphs {
+		if drop(g) {
+			dropped = append(dropped, g)
+		} else {
+			kept = append(kept, g)
+		}
+	}
+	f.Glyphs = kept
+	return dropped
+}
@@ -0,0 +1,130 @@
+package gfx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFilterKeepsOrderAndRange(t *testing.T) {
+	// Out of file order, so the parser has to sort.
+	font := parseTest(t, testBDF(testBox(0x30), testBox(0x21), testBox(0x7A), testBox(0x41), testBox(0x22)))
+	if got, want := codes(font), []int{0x21, 0x22, 0x30, 0x41, 0x7A}; !slices.Equal(got, want) {
+		t.Fatalf("parsed codes %#x, want %#x", got, want)
+	}
+
+	ranges, err := ParseRanges("0x22-0x40,0x7A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := font.Filter(ranges.Contains); n != 2 {
+		t.Errorf("Filter removed %d glyphs, want 2", n)
+	}
+	if got, want := codes(font), []int{0x22, 0x30, 0x7A}; !slices.Equal(got, want) {
+		t.Errorf("filtered codes %#x, want %#x", got, want)
+	}
+	if first, last := font.Range(); first != 0x22 || last != 0x7A {
+		t.Errorf("Range() = %#x, %#x, want 0x22, 0x7a", first, last)
+	}
+
+	var buf bytes.Buffer
+	if _, err := (&Header{Font: font, Name: "Test"}).WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "  0x22, 0x7a, 8\n") {
+		t.Errorf("header does not have first 0x22, last 0x7A and yAdvance 8:\n%s", buf.String())
+	}
+}
+
+func TestFilterChainKeepsOrder(t *testing.T) {
+	font := parseTest(t, testBDF(testBox(0x41), testBox(0x42), testBox(0x43), testBox(0x61), testBox(0x62)))
+	font.Filter(func(code int) bool { return code != 0x42 })
+	font.Filter(func(code int) bool { return code != 0x62 })
+	if got, want := codes(font), []int{0x41, 0x43, 0x61}; !slices.Equal(got, want) {
+		t.Errorf("codes %#x, want %#x", got, want)
+	}
+	if first, last := font.Range(); first != 0x41 || last != 0x61 {
+		t.Errorf("Range() = %#x, %#x, want 0x41, 0x61", first, last)
+	}
+}
+
+func TestFilterDuplicatesKeepFileOrder(t *testing.T) {
+	// Glyphs on 0x41, told apart by their names, between others in
+	// reverse order, so the sort has to move them all.
+	var glyphs, want []string
+	for i := range 16 {
+		name := fmt.Sprintf("dup%d", i)
+		glyphs = append(glyphs, testBox(0x60-i), testGlyph(name, 0x41, 4, 1, "F0"))
+		want = append(want, name)
+	}
+	font := parseTest(t, testBDF(glyphs...))
+	duplicates := func() []string {
+		var names []string
+		for _, g := range font.Glyphs {
+			if g.Code == 0x41 {
+				names = append(names, g.Name)
+			}
+		}
+		return names
+	}
+	if got := duplicates(); !slices.Equal(got, want) {
+		t.Errorf("parsed glyphs of 0x41 %q, want %q", got, want)
+	}
+	if font.Glyphs[0].Code != 0x41 || font.Glyphs[16].Code != 0x51 {
+		t.Errorf("codes %#x, want 0x41 first and 0x51 after it", codes(font))
+	}
+
+	font.Filter(func(code int) bool { return code < 0x58 })
+	if got := duplicates(); !slices.Equal(got, want) {
+		t.Errorf("filtered glyphs of 0x41 %q, want %q", got, want)
+	}
+	if first, last := font.Range(); first != 0x41 || last != 0x57 {
+		t.Errorf("Range() = %#x, %#x, want 0x41, 0x57", first, last)
+	}
+}
+
+func TestFilterEmptySelection(t *testing.T) {
+	font := parseTest(t, testBDF(testBox(0x41), testBox(0x42)))
+	if n := font.Filter(func(code int) bool { return code > 0x100 }); n != 2 {
+		t.Errorf("Filter removed %d glyphs, want 2", n)
+	}
+	if len(font.Glyphs) != 0 {
+		t.Fatalf("%d glyphs left, want none", len(font.Glyphs))
+	}
+
+	var buf bytes.Buffer
+	_, err := (&Header{Font: font}).WriteTo(&buf)
+	if !errors.Is(err, ErrNoGlyphs) {
+		t.Errorf("WriteTo error %v, want ErrNoGlyphs", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("WriteTo wrote %d bytes for an empty font", buf.Len())
+	}
+}
+
+func TestInklessGlyphs(t *testing.T) {
+	blank := func(name string, code int) string { return testGlyph(name, code, 4, 4, "00", "00", "00", "00") }
+	font := parseTest(t, testBDF(
+		blank("space", 0x20), testBox(0x41), blank("B", 0x42),
+		testGlyph("empty", 0x43, 0, 0), blank("zwj", 0x200D), blank("braille", 0x2800)))
+	var codes []int
+	for _, g := range font.InklessGlyphs() {
+		codes = append(codes, g.Code)
+	}
+	if want := []int{0x42}; !slices.Equal(codes, want) {
+		t.Errorf("InklessGlyphs() codes %#x, want %#x", codes, want)
+	}
+
+	// Rebased codes are checked as the codepoints they stand for.
+	font.Rebase(0)
+	codes = nil
+	for _, g := range font.InklessGlyphs() {
+		codes = append(codes, g.Code)
+	}
+	if want := []int{0x22}; !slices.Equal(codes, want) {
+		t.Errorf("InklessGlyphs() codes %#x after rebasing, want %#x", codes, want)
+	}
+}
@@ -0,0 +1,109 @@
+// Package gfx converts BDF bitmap fonts into the GFX font structures used
+// by the Adafruit GFX and TFT_eSPI libraries.
+package gfx
+
+import "fmt"
+
+type Glyph struct {
+	Code       int    `json:"code"`
+	Name       string `json:"name"`
+	Width      int    `json:"width"`
+	Height     int    `json:"height"`
+	XOffset    int    `json:"xOffset"`
+	YOffset    int    `json:"yOffset"` // BBX y offset: bottom of the bitmap relative to the baseline
+	XAdvance   int    `json:"xAdvance"`
+	Attributes string `json:"attributes,omitempty"` // ATTRIBUTES value, kept verbatim
+	Bitmap     []byte `json:"bitmap"`               // BDF rows, (Width+7)/8 bytes each
+	Gray       []byte `json:"gray,omitempty"`       // gray level of every pixel, row by row, for fonts with more than 1 bit per pixel
+
+	// Raw DWIDTH and DWIDTH1 values; which one becomes XAdvance depends
+	// on the font's METRICSSET.
+	dwidth     int
+	dwidth1    int
+	hasDWidth  bool
+	hasDWidth1 bool
+
+	// VVECTOR of the glyph, overriding the one of the font.
+	vvector    [2]int
+	hasVVector bool
+
+	line int // line of the STARTCHAR keyword
+
+	// placeholder marks the box glyphs that stand in for missing codes,
+	// which share their bitmap.
+	placeholder bool
+}
+
+// YOffsetTFT is the GFX yOffset: the top of the bitmap relative to the
+// baseline, with y growing downwards.
+func (g *Glyph) YOffsetTFT() int {
+	return -(g.YOffset + g.Height)
+}
+
+// Metrics sets as defined by the BDF METRICSSET keyword.
+const (
+	MetricsHorizontal = 0
+	MetricsVertical   = 1
+	MetricsBoth       = 2
+)
+
+// BBX is a bounding box as given by the BDF BBX and FONTBOUNDINGBOX
+// keywords: its size and the offset of its lower left corner from the
+// origin, with y growing upwards.
+type BBX struct {
+	Width   int `json:"width"`
+	Height  int `json:"height"`
+	XOffset int `json:"xOffset"`
+	YOffset int `json:"yOffset"`
+}
+
+type Font struct {
+	XLFD            string            `json:"xlfd,omitempty"` // FONT keyword value
+	CharsetRegistry string            `json:"charsetRegistry,omitempty"`
+	CharsetEncoding string            `json:"charsetEncoding,omitempty"`
+	BoundingBox     *BBX              `json:"boundingBox,omitempty"` // FONTBOUNDINGBOX, nil when absent
+	VVector         *[2]int           `json:"vvector,omitempty"`     // VVECTOR, the vertical origin relative to the horizontal one; nil when absent
+	Ascent          int               `json:"ascent"`
+	Descent         int               `json:"descent"`
+	RawAscent       int               `json:"rawAscent,omitempty"`  // RAW_ASCENT, in thousandths of the pixel size
+	RawDescent      int               `json:"rawDescent,omitempty"` // RAW_DESCENT, likewise
+	MetricsSet      int               `json:"metricsSet"`
+	BitsPerPixel    int               `json:"bitsPerPixel,omitempty"` // from the SIZE line of BDF 2.3 grayscale fonts; 0 means 1
+	DefaultChar     int               `json:"defaultChar"`            // DEFAULT_CHAR property, -1 when absent
+	CapHeight       int               `json:"capHeight,omitempty"`
+	XHeight         int               `json:"xHeight,omitempty"`
+	HasNotdef       bool              `json:"hasNotdef,omitempty"`
+	LineGap         int               `json:"lineGap,omitempty"`    // extra line spacing added to yAdvance
+	CodeBase        int               `json:"codeBase,omitempty"`   // real codepoint = Glyph.Code + CodeBase
+	Properties      map[string]string `json:"properties,omitempty"` // STARTPROPERTIES block, values unquoted
+	Glyphs          []*Glyph          `json:"glyphs"`               // sorted by Code
+	Kerning         []KernPair        `json:"kerning,omitempty"`    // from a STARTKERNING block, in file order
+
+	// unencoded holds the glyphs with ENCODING -1, such as .notdef, which
+	// are not emitted but can be picked by name with MoveNotdefFirst.
+	unencoded []*Glyph
+
+	index *codeIndex // used by Glyph, built on the first lookup
+}
+
+// CheckCodes16 reports an error if the font has glyph codes that do not
+// fit the uint16_t first and last fields of GFXfont.
+func (f *Font) CheckCodes16() error {
+	for _, g := range f.Glyphs {
+		if g.Code < 0 || g.Code > 0xFFFF {
+			return fmt.Errorf("glyph 0x%04X: code does not fit the uint16_t first/last of GFXfont", g.Code)
+		}
+	}
+	return nil
+}
+
+// YAdvance is the line height: ascent plus descent plus the line gap.
+func (f *Font) YAdvance() int {
+	return f.Ascent + f.Descent + f.LineGap
+}
+
+// Range returns the codepoints of the first and last glyph. The font must
+// not be empty.
+func (f *Font) Range() (first, last int) {
+	return f.Glyphs[0].Code, f.Glyphs[len(f.Glyphs)-1].Code
+}
@@ -0,0 +1,991 @@
+package gfx
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Header writes a font as a C header with the GFXglyph/GFXfont structures
+// used by Adafruit GFX and TFT_eSPI. Codes between the first and the last
+// glyph that the font does not define get empty zero-advance glyphs, since
+// renderers index the glyph table with code - first.
+type Header struct {
+	Font *Font
+	Name string // symbol name, "Font" when empty
+
+	// GlyphTransform, when set, is called on a copy of every glyph of Font
+	// before anything is written, so callers can change bitmaps and
+	// metrics programmatically without touching Font. It runs after the
+	// transforms applied to Font beforehand, such as Rotate, BakeXOffset or
+	// NormalizeAdvances, and before the glyphs are split into blocks, gaps
+	// are filled and the table is packed and checked against the C types.
+	// The Bitmap must keep (Width+7)/8 bytes per row. A glyph may be given
+	// another Code; the glyphs are sorted again afterwards.
+	GlyphTransform func(*Glyph)
+
+	// Wide declares width and height as uint16_t. Standard GFX renderers
+	// cannot read this layout.
+	Wide bool
+
+	// WideAdvance declares xAdvance as uint16_t, the layout of GFX forks
+	// for fonts with advances above 255. Standard GFX renderers cannot read
+	// it either.
+	WideAdvance bool
+
+	// AdvanceIndex replaces the xAdvance of every glyph by a uint8_t
+	// advanceIndex into a <name>Advances array of the distinct advances,
+	// typed like xAdvance, with a <name>GlyphAdvance helper to look it up.
+	// This saves flash when most glyphs share a few wide advances; it
+	// changes the GFXglyph layout, so standard GFX renderers cannot read it.
+	AdvanceIndex bool
+
+	// DebugArrays also emits every glyph as a 2D array of its bitmap rows,
+	// with the rows drawn as ASCII art, for checking glyphs by hand.
+	DebugArrays bool
+
+	// Align pads the bitmap blob with zeros so that every bitmapOffset is
+	// a multiple of it. Zero and one mean no padding.
+	Align int
+
+	// CommentWidth truncates the comment after each glyph table row so the
+	// line ends at this column. Zero leaves comments alone.
+	CommentWidth int
+
+	// Blocks splits the font into several GFXfonts, one per run of codes
+	// without gaps wider than BlockGap, and adds an array of them with a
+	// lookup helper. This keeps sparse Unicode fonts small.
+	Blocks   bool
+	BlockGap int
+
+	// GlyphNames adds a <name>GlyphNames array of the STARTCHAR names,
+	// parallel to the glyph table. Defining <name>_NO_GLYPH_NAMES leaves
+	// it out of the build.
+	GlyphNames bool
+
+	// Pretty sizes the columns of the glyph table to the widest value, so
+	// the rows line up whatever the values.
+	Pretty bool
+
+	// Designated writes the glyph table rows with C99 designated
+	// initializers, { .bitmapOffset = 0, .width = 2, ... }, so they stay
+	// correct on GFX forks that order the GFXglyph members differently.
+	// C++ compilers accept them from C++20, and g++ earlier as an
+	// extension as long as the members are named in declaration order.
+	Designated bool
+
+	// Planes stores each glyph of a grayscale font as one 1-bit plane per
+	// bit of the gray level, most significant bit first, one after the
+	// other at the bitmapOffset of the glyph.
+	Planes bool
+
+	// Offsets adds a <name>Offsets array with the bitmapOffset of every
+	// glyph, for renderers that seek in the bitmaps without reading the
+	// glyph table.
+	Offsets bool
+
+	// Delta stores bitmaps that are close to an earlier glyph of the same
+	// size as delta records, see PackGlyphsDelta, and adds a <name>Deltas
+	// array and a <name>DecodeBitmap helper. Standard GFX renderers cannot
+	// draw such glyphs; it cannot be combined with Planes or Align.
+	Delta bool
+
+	// Huffman stores every bitmap Huffman coded with one code per table,
+	// see PackGlyphsHuffman, and adds the <name>HuffmanCounts and
+	// <name>HuffmanSymbols arrays and a <name>DecodeBitmap helper. Standard
+	// GFX renderers cannot draw such glyphs; it cannot be combined with
+	// Planes, Delta, Columns, Align or BitmapOrder.
+	Huffman bool
+
+	// BitmapOrder lists codes whose bitmaps are placed first in the bitmap
+	// blob, in this order, followed by the other glyphs in code order, so
+	// the glyphs used most can share flash pages or cache lines. The glyph
+	// table stays in code order and its offsets follow the bitmaps. It
+	// cannot be combined with Delta or Compact.
+	BitmapOrder []int
+
+	// Columns stores the bitmaps column-major for page-addressed displays,
+	// see ColumnBitmap. Standard GFX renderers cannot draw them; it cannot
+	// be combined with Planes or Delta.
+	Columns bool
+
+	// Coverage adds a <name>Coverage bitset with a bit for every code from
+	// the first to the last glyph, set when the font has a glyph for it,
+	// and a <name>HasGlyph helper that tests a code.
+	Coverage bool
+
+	// GlyphCRC adds a <name>GlyphCRC array with the CRC-8 of the bitmap
+	// bytes of every glyph, parallel to the glyph table, and a
+	// <name>GlyphCRCOk helper that checks a glyph in flash. It cannot be
+	// combined with Planes, Delta or Columns.
+	GlyphCRC bool
+
+	// MetricDefines adds <name>_FIRST, <name>_LAST and <name>_GLYPH_COUNT,
+	// the number of GFXglyph entries, after each GFXfont, and
+	// <name>_Y_ADVANCE next to the ascent and descent.
+	MetricDefines bool
+
+	// SizeDefine adds a <name>_FLASH_BYTES define with the flash taken by
+	// the bitmaps, the glyph table and the GFXfont struct on AVR, and a
+	// comment with the parts.
+	SizeDefine bool
+
+	// Compact replaces the GFXglyph array with variable-length records, see
+	// EncodeCompact, and a <name>GetGlyph decoder. There is no GFXfont
+	// struct, so standard GFX renderers cannot use the font.
+	Compact bool
+
+	// CodeMap leaves the codes without a glyph out of the glyph table and
+	// adds a <name>Segments array of the runs of consecutive codes, see
+	// Segments, with <name>GlyphIndex and <name>GetGlyph helpers. This keeps
+	// sparse fonts small without blocks. There is no GFXfont struct, so
+	// standard GFX renderers cannot use the font; it cannot be combined
+	// with Blocks.
+	CodeMap bool
+
+	// Vertical adds a GFXvglyph array of the vertical metrics of every
+	// glyph, see Font.VerticalMetrics, parallel to the glyph table, and a
+	// <name>DrawVertical helper that draws top-to-bottom text with them. It
+	// cannot be combined with Planes, Delta, Huffman or Columns, whose
+	// bitmaps the helper cannot read.
+	Vertical bool
+
+	// MissingBox fills the codes without a glyph between the first and the
+	// last glyph of a table with rectangle outlines, see BoxGlyph, sized to
+	// the most common advance and the ascent of the font, instead of empty
+	// zero-advance glyphs, so missing characters show on screen. The boxes
+	// share one bitmap, except with Delta, which packs every glyph itself.
+	// It cannot be combined with Compact.
+	MissingBox bool
+
+	// Part selects whether to write the whole header or only the metrics
+	// or the bitmaps, for fonts whose bitmaps live in another memory.
+	Part HeaderPart
+}
+
+// HeaderPart selects the parts of the font a Header writes.
+type HeaderPart int
+
+const (
+	// AllParts writes a self-contained header.
+	AllParts HeaderPart = iota
+	// MetricsPart writes everything but the bitmaps, which are declared
+	// extern.
+	MetricsPart
+	// BitmapsPart writes only the bitmap arrays.
+	BitmapsPart
+)
+
+// glyphField is one member of the GFXglyph struct.
+type glyphField struct {
+	ctype string
+	name  string
+}
+
+// ctypeRange holds the value range of the C types used in the glyph table.
+var ctypeRange = map[string][2]int{
+	"uint8_t":  {0, 0xFF},
+	"int8_t":   {-0x80, 0x7F},
+	"uint16_t": {0, 0xFFFF},
+	"int16_t":  {-0x8000, 0x7FFF},
+}
+
+var ctypeSize = map[string]int{"uint8_t": 1, "int8_t": 1, "uint16_t": 2, "int16_t": 2}
+
+// glyphFields returns the GFXglyph layout of the header.
+func (h *Header) glyphFields() []glyphField {
+	dim := "uint8_t"
+	if h.Wide {
+		dim = "uint16_t"
+	}
+	advance := "uint8_t"
+	if h.WideAdvance {
+		advance = "uint16_t"
+	}
+	advanceField := glyphField{advance, "xAdvance"}
+	if h.AdvanceIndex {
+		advanceField = glyphField{"uint8_t", "advanceIndex"}
+	}
+	return []glyphField{
+		{"uint16_t", "bitmapOffset"},
+		{dim, "width"},
+		{dim, "height"},
+		advanceField,
+		{"int8_t", "xOffset"},
+		{"int8_t", "yOffset"},
+	}
+}
+
+// errWriter remembers the first write error and counts the bytes written,
+// so the emitters can print freely and check once at the end.
+type errWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (ew *errWriter) Write(p []byte) (int, error) {
+	if ew.err != nil {
+		return 0, ew.err
+	}
+	n, err := ew.w.Write(p)
+	ew.n += int64(n)
+	ew.err = err
+	return n, err
+}
+
+// table is the packed bitmap blob and glyph table rows of one GFXfont.
+type table struct {
+	name    string
+	font    *Font
+	bitmaps []byte
+	rows    [][]int
+	deltas  []Delta
+	huffman *HuffmanTable
+
+	advances []int    // the <name>Advances array with AdvanceIndex
+	vertical [][3]int // the <name>VGlyphs rows with Vertical
+}
+
+// pack builds the table of font and checks every value against its field.
+func (h *Header) pack(font *Font, name string) (*table, error) {
+	if err := font.CheckCodes16(); err != nil {
+		return nil, err
+	}
+	bitmapOf := (*Glyph).PackedBitmap
+	if h.Planes {
+		bpp := max(font.BitsPerPixel, 1)
+		bitmapOf = func(g *Glyph) []byte { return g.packedPlanes(bpp) }
+	}
+	if h.Vertical && (h.Planes || h.Delta || h.Huffman || h.Columns) {
+		return nil, fmt.Errorf("vertical metrics cannot be combined with planes, delta, Huffman or column-major bitmaps")
+	}
+	if h.GlyphCRC && (h.Planes || h.Delta || h.Huffman || h.Columns) {
+		return nil, fmt.Errorf("glyph CRCs cannot be combined with planes, delta, Huffman or column-major bitmaps")
+	}
+	if h.Columns {
+		if h.Planes || h.Delta {
+			return nil, fmt.Errorf("column-major bitmaps cannot be combined with planes or delta bitmaps")
+		}
+		bitmapOf = (*Glyph).ColumnBitmap
+	}
+	order := bitmapOrder(font.Glyphs, h.BitmapOrder)
+	ordered := make([]*Glyph, len(order))
+	for i, j := range order {
+		ordered[i] = font.Glyphs[j]
+	}
+	bitmapData, orderedOffsets := packWith(ordered, h.Align, bitmapOf)
+	offsets := make([]int, len(order))
+	for i, j := range order {
+		offsets[j] = orderedOffsets[i]
+	}
+	var deltas []Delta
+	if h.Delta {
+		if h.Planes || h.Align > 1 || len(h.BitmapOrder) > 0 {
+			return nil, fmt.Errorf("delta bitmaps cannot be combined with planes, aligned offsets or a bitmap order")
+		}
+		bitmapData, offsets, deltas = PackGlyphsDelta(font.Glyphs)
+	}
+	var huffman *HuffmanTable
+	if h.Huffman {
+		if h.Planes || h.Delta || h.Columns || h.Align > 1 || len(h.BitmapOrder) > 0 {
+			return nil, fmt.Errorf("Huffman bitmaps cannot be combined with planes, delta or column-major bitmaps, aligned offsets or a bitmap order")
+		}
+		bitmapData, offsets, huffman = PackGlyphsHuffman(font.Glyphs)
+	}
+	fields := h.glyphFields()
+	t := &table{name: name, font: font, bitmaps: bitmapData, rows: make([][]int, len(font.Glyphs)), deltas: deltas, huffman: huffman}
+	index := map[int]int{}
+	if h.AdvanceIndex {
+		t.advances = font.Advances()
+		if len(t.advances) > 0x100 {
+			return nil, fmt.Errorf("%d distinct advances do not fit a uint8_t advance index", len(t.advances))
+		}
+		limit := 0xFF
+		if h.WideAdvance {
+			limit = 0xFFFF
+		}
+		for i, a := range t.advances {
+			if a < 0 || a > limit {
+				return nil, fmt.Errorf("advance %d does not fit the advance table", a)
+			}
+			index[a] = i
+		}
+	}
+	for i, g := range font.Glyphs {
+		advance := g.XAdvance
+		if h.AdvanceIndex {
+			advance = index[g.XAdvance]
+		}
+		t.rows[i] = []int{offsets[i], g.Width, g.Height, advance, g.XOffset, g.YOffsetTFT()}
+		for j, v := range t.rows[i] {
+			r := ctypeRange[fields[j].ctype]
+			if v < r[0] || v > r[1] {
+				hint := ""
+				if !h.Wide && (fields[j].name == "width" || fields[j].name == "height") {
+					hint = " (use the wide layout)"
+				}
+				if !h.WideAdvance && fields[j].name == "xAdvance" {
+					hint = " (use the wide advance layout)"
+				}
+				return nil, fmt.Errorf("glyph 0x%04X: %s %d does not fit %s%s", g.Code, fields[j].name, v, fields[j].ctype, hint)
+			}
+		}
+	}
+	if h.Vertical {
+		var err error
+		if t.vertical, err = verticalRows(t); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// tables applies GlyphTransform and packs the font, or each of its blocks,
+// into the tables WriteTo writes.
+func (h *Header) tables() (*Font, string, []*table, error) {
+	font := h.Font
+	if h.GlyphTransform != nil {
+		font = font.transformed(h.GlyphTransform)
+	}
+	name := h.Name
+	if name == "" {
+		name = "Font"
+	}
+	if len(font.Glyphs) == 0 {
+		return nil, "", nil, ErrNoGlyphs
+	}
+
+	var missing func(code int) *Glyph
+	if h.MissingBox {
+		missing = font.missingBoxes()
+	}
+	var tables []*table
+	if h.CodeMap {
+		if h.Blocks {
+			return nil, "", nil, fmt.Errorf("a code map cannot be combined with blocks")
+		}
+		t, err := h.pack(font.unique(), name)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		tables = append(tables, t)
+	} else if h.Blocks {
+		for i, r := range font.Blocks(h.BlockGap) {
+			t, err := h.pack(font.fill(r, missing), fmt.Sprintf("%s_%d", name, i))
+			if err != nil {
+				return nil, "", nil, err
+			}
+			tables = append(tables, t)
+		}
+	} else {
+		first, last := font.Range()
+		t, err := h.pack(font.fill(CodeRange{first, last}, missing), name)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		tables = append(tables, t)
+	}
+	return font, name, tables, nil
+}
+
+// Bitmaps returns the bitmap bytes the header would write, packed with
+// the same options, exactly as they would be laid out in flash: every
+// glyph starts at its bitmapOffset, and with Blocks the bitmaps of the
+// blocks follow each other, each block's offsets counting from its own
+// start. It fails like WriteTo if a glyph value does not fit its field.
+func (h *Header) Bitmaps() ([]byte, error) {
+	_, _, tables, err := h.tables()
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	for _, t := range tables {
+		data = append(data, t.bitmaps...)
+	}
+	return data, nil
+}
+
+// bitmapOrder returns the indexes of glyphs in the order their bitmaps are
+// packed: those of the codes in hot first, in that order, then the rest.
+func bitmapOrder(glyphs []*Glyph, hot []int) []int {
+	index := map[int]int{}
+	for i, g := range glyphs {
+		if _, ok := index[g.Code]; !ok {
+			index[g.Code] = i
+		}
+	}
+	placed := make([]bool, len(glyphs))
+	var order []int
+	for _, code := range hot {
+		if i, ok := index[code]; ok && !placed[i] {
+			order = append(order, i)
+			placed[i] = true
+		}
+	}
+	for i := range glyphs {
+		if !placed[i] {
+			order = append(order, i)
+		}
+	}
+	return order
+}
+
+// WriteTo writes the header to w. It fails without writing anything if a
+// glyph value does not fit its field in the glyph table.
+func (h *Header) WriteTo(w io.Writer) (int64, error) {
+	font, name, tables, err := h.tables()
+	if err != nil {
+		return 0, err
+	}
+	ascent, descent := font.Ascent, font.Descent
+	bpp := max(font.BitsPerPixel, 1)
+
+	if y := font.YAdvance(); y < 0 || y > 0xFF {
+		return 0, fmt.Errorf("yAdvance %d does not fit uint8_t", y)
+	}
+
+	pairs := font.KernPairs()
+	for _, p := range pairs {
+		if r := ctypeRange["int8_t"]; p.Adjust < r[0] || p.Adjust > r[1] {
+			return 0, fmt.Errorf("kerning pair 0x%04X 0x%04X: adjust %d does not fit int8_t", p.Left, p.Right, p.Adjust)
+		}
+	}
+
+	if h.Compact {
+		if err := h.checkCompact(tables[0]); err != nil {
+			return 0, err
+		}
+	}
+
+	out := &errWriter{w: w}
+	if font.XLFD != "" {
+		fmt.Fprintf(out, "// Converted from %s\n\n", commentSafe(font.XLFD))
+	}
+	if h.Compact {
+		h.writeCompact(out, tables[0])
+		fmt.Fprintf(out, "#define %s_ASCENT %d\n", name, ascent)
+		fmt.Fprintf(out, "#define %s_DESCENT %d\n", name, descent)
+		return out.n, out.err
+	}
+	if h.Part == BitmapsPart {
+		for _, t := range tables {
+			writeBitmaps(out, t, ascent+descent)
+		}
+		return out.n, out.err
+	}
+
+	fmt.Fprintf(out, "// typedef struct {\n")
+	for _, f := range h.glyphFields() {
+		fmt.Fprintf(out, "//   %-8s %s;\n", f.ctype, f.name)
+	}
+	fmt.Fprintf(out, "// } GFXglyph;\n\n")
+
+	fmt.Fprintf(out, "// typedef struct {\n")
+	fmt.Fprintf(out, "//   uint8_t  *bitmap;\n")
+	fmt.Fprintf(out, "//   GFXglyph *glyph;\n")
+	fmt.Fprintf(out, "//   uint16_t  first;\n")
+	fmt.Fprintf(out, "//   uint16_t  last;\n")
+	fmt.Fprintf(out, "//   uint8_t   yAdvance;\n// } GFXfont;\n\n")
+
+	if font.HasNotdef {
+		fmt.Fprintf(out, "#define %s_NOTDEF_INDEX 0\n\n", name)
+	}
+	if h.Planes {
+		fmt.Fprintf(out, "// Every glyph holds %s_PLANES 1-bit bitmaps one after the other, one\n", name)
+		fmt.Fprint(out, "// per bit of the gray level, most significant bit first.\n")
+		fmt.Fprintf(out, "#define %s_PLANES %d\n\n", name, bpp)
+	}
+	if h.Columns {
+		fmt.Fprint(out, "// Bitmaps are column-major: for every band of 8 rows from the top, one\n")
+		fmt.Fprint(out, "// byte per column with the top row in bit 0, as SSD1306 pages expect.\n")
+		fmt.Fprintf(out, "#define %s_COLUMN_MAJOR 1\n\n", name)
+	}
+	if font.CodeBase != 0 {
+		fmt.Fprintf(out, "// Glyph codes are rebased: add %s_CODE_BASE to get the real codepoint.\n", name)
+		fmt.Fprintf(out, "#define %s_CODE_BASE 0x%X\n\n", name, font.CodeBase)
+	}
+
+	for _, t := range tables {
+		if h.Part == MetricsPart {
+			fmt.Fprintf(out, "extern const uint8_t %sBitmaps[] PROGMEM;\n\n", t.name)
+		} else {
+			writeBitmaps(out, t, ascent+descent)
+		}
+
+		widths := []int{5, 2, 2, 2, 3, 3}
+		if h.Pretty {
+			widths = columnWidths(t.rows)
+		}
+		fmt.Fprintf(out, "const GFXglyph %sGlyphs[] PROGMEM = {\n", t.name)
+		for i, g := range t.font.Glyphs {
+			r := t.rows[i]
+			row := fmt.Sprintf("  { %*d, %*d, %*d, %*d, %*d, %*d }, ",
+				widths[0], r[0], widths[1], r[1], widths[2], r[2], widths[3], r[3], widths[4], r[4], widths[5], r[5])
+			if h.Designated {
+				row = designatedRow(h.glyphFields(), widths, r)
+			}
+			fmt.Fprintf(out, "%s%s\n", row, h.comment(len(row), fmt.Sprintf("0x%04X", g.Code)))
+		}
+		fmt.Fprint(out, "};\n\n")
+
+		if h.GlyphNames {
+			writeGlyphNames(out, name, t)
+		}
+		if h.Offsets {
+			writeOffsets(out, t)
+		}
+		if h.AdvanceIndex {
+			h.writeAdvances(out, t)
+		}
+		if h.GlyphCRC {
+			h.writeGlyphCRC(out, t)
+		}
+		if h.Delta {
+			h.writeDeltas(out, t)
+		}
+		if h.Huffman {
+			h.writeHuffman(out, t)
+		}
+
+		first, last := t.font.Range()
+		if h.CodeMap {
+			h.writeCodeMap(out, t)
+		} else {
+			fmt.Fprintf(out, "const GFXfont %s PROGMEM = {\n", t.name)
+			fmt.Fprintf(out, "  (uint8_t*)%sBitmaps,\n", t.name)
+			fmt.Fprintf(out, "  (GFXglyph*)%sGlyphs,\n", t.name)
+			fmt.Fprintf(out, "  0x%x, 0x%x, %d\n};\n\n", first, last, font.YAdvance())
+		}
+		if h.Vertical {
+			h.writeVertical(out, t)
+		}
+		if h.MetricDefines {
+			fmt.Fprintf(out, "#define %s_FIRST 0x%x\n", t.name, first)
+			fmt.Fprintf(out, "#define %s_LAST 0x%x\n", t.name, last)
+			fmt.Fprintf(out, "#define %s_GLYPH_COUNT %d\n\n", t.name, len(t.rows))
+		}
+		if h.SizeDefine {
+			h.writeSizeDefine(out, t)
+		}
+	}
+
+	if h.Blocks {
+		writeBlockIndex(out, name, tables)
+	}
+	if len(pairs) > 0 {
+		writeKerning(out, name, pairs)
+	}
+	if h.Coverage {
+		writeCoverage(out, name, font)
+	}
+
+	fmt.Fprintf(out, "#define %s_ASCENT %d\n", name, ascent)
+	fmt.Fprintf(out, "#define %s_DESCENT %d\n", name, descent)
+	if h.MetricDefines {
+		fmt.Fprintf(out, "#define %s_Y_ADVANCE %d\n", name, font.YAdvance())
+	}
+
+	if h.DebugArrays {
+		writeDebugArrays(out, name, font.Glyphs)
+	}
+	return out.n, out.err
+}
+
+// columnWidths returns the width of the widest value in every column.
+func columnWidths(rows [][]int) []int {
+	var widths []int
+	for _, r := range rows {
+		for j, v := range r {
+			if j == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[j] = max(widths[j], len(strconv.Itoa(v)))
+		}
+	}
+	return widths
+}
+
+// designatedRow formats a glyph table row with designated initializers,
+// padding the values to widths.
+func designatedRow(fields []glyphField, widths, r []int) string {
+	var b strings.Builder
+	b.WriteString("  {")
+	for j, f := range fields {
+		if j > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, " .%s = %*d", f.name, widths[j], r[j])
+	}
+	b.WriteString(" }, ")
+	return b.String()
+}
+
+// writeBitmaps emits the bitmap blob of a table, wrapping the lines every
+// wrap bytes, or not at all if wrap is not positive, as for fonts without
+// an ascent or descent.
+func writeBitmaps(w io.Writer, t *table, wrap int) {
+	if len(t.bitmaps) == 0 {
+		// C does not allow empty arrays.
+		fmt.Fprintf(w, "const uint8_t %sBitmaps[] PROGMEM = {\n", t.name)
+		fmt.Fprint(w, "  0x00, // no glyph has bitmap data\n};\n\n")
+		return
+	}
+	fmt.Fprintf(w, "const uint8_t %sBitmaps[] PROGMEM = {\n  ", t.name)
+	for i, b := range t.bitmaps {
+		if wrap > 0 && i > 0 && i%wrap == 0 {
+			fmt.Fprint(w, "\n  ")
+		}
+		fmt.Fprintf(w, "0x%02X, ", b)
+	}
+	fmt.Fprintf(w, "\n};\n\n")
+}
+
+// writeOffsets emits the bitmap offsets of a table as an array, using
+// uint32_t when they do not fit uint16_t.
+func writeOffsets(w io.Writer, t *table) {
+	ctype := "uint16_t"
+	for _, r := range t.rows {
+		if r[0] > 0xFFFF {
+			ctype = "uint32_t"
+		}
+	}
+	fmt.Fprintf(w, "const %s %sOffsets[] PROGMEM = {\n", ctype, t.name)
+	for i, g := range t.font.Glyphs {
+		fmt.Fprintf(w, "  %5d, // 0x%04X\n", t.rows[i][0], g.Code)
+	}
+	fmt.Fprint(w, "};\n\n")
+}
+
+// writeAdvances emits the advance table of a table and its lookup helper.
+func (h *Header) writeAdvances(w io.Writer, t *table) {
+	ctype, read := "uint8_t", "pgm_read_byte"
+	if h.WideAdvance {
+		ctype, read = "uint16_t", "pgm_read_word"
+	}
+	fmt.Fprintf(w, "const %s %sAdvances[] PROGMEM = {\n", ctype, t.name)
+	for i, a := range t.advances {
+		fmt.Fprintf(w, "  %5d, // %d\n", a, i)
+	}
+	fmt.Fprint(w, "};\n\n")
+	fmt.Fprint(w, "// The glyph table stores an index into the advances instead of xAdvance.\n")
+	fmt.Fprintf(w, "static inline %s %sGlyphAdvance(const GFXglyph *glyph) {\n", ctype, t.name)
+	fmt.Fprintf(w, "  return %s(&%sAdvances[pgm_read_byte(&glyph->advanceIndex)]);\n", read, t.name)
+	fmt.Fprint(w, "}\n\n")
+}
+
+// crc8 is the CRC-8 with polynomial 0x07 and initial value 0, without
+// reflection or final XOR.
+func crc8(data []byte) byte {
+	var crc byte
+	for _, b := range data {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// writeGlyphCRC emits the CRC-8 of every glyph bitmap of a table and a
+// helper that recomputes it from flash.
+func (h *Header) writeGlyphCRC(w io.Writer, t *table) {
+	fmt.Fprintf(w, "const uint8_t %sGlyphCRC[] PROGMEM = {\n", t.name)
+	for i, g := range t.font.Glyphs {
+		offset := t.rows[i][0]
+		n := (g.Width*g.Height + 7) / 8
+		fmt.Fprintf(w, "  0x%02X, // 0x%04X\n", crc8(t.bitmaps[offset:offset+n]), g.Code)
+	}
+	fmt.Fprint(w, "};\n\n")
+
+	readDim := "pgm_read_byte"
+	if h.Wide {
+		readDim = "pgm_read_word"
+	}
+	fmt.Fprint(w, "// Returns 1 if the bitmap of the glyph at index, which is code minus first,\n")
+	fmt.Fprint(w, "// matches its CRC-8 (polynomial 0x07, initial value 0), else 0.\n")
+	fmt.Fprintf(w, "static inline uint8_t %sGlyphCRCOk(uint16_t index) {\n", t.name)
+	fmt.Fprintf(w, "  const GFXglyph *glyph = &%sGlyphs[index];\n", t.name)
+	fmt.Fprintf(w, "  const uint8_t *p = &%sBitmaps[pgm_read_word(&glyph->bitmapOffset)];\n", t.name)
+	fmt.Fprintf(w, "  uint32_t n = ((uint32_t)%s(&glyph->width) * %s(&glyph->height) + 7) / 8;\n", readDim, readDim)
+	fmt.Fprint(w, "  uint8_t crc = 0;\n")
+	fmt.Fprint(w, "  while (n--) {\n")
+	fmt.Fprint(w, "    crc ^= pgm_read_byte(p++);\n")
+	fmt.Fprint(w, "    for (uint8_t i = 0; i < 8; i++) {\n")
+	fmt.Fprint(w, "      crc = (crc & 0x80) ? (uint8_t)(crc << 1) ^ 0x07 : (uint8_t)(crc << 1);\n")
+	fmt.Fprint(w, "    }\n")
+	fmt.Fprint(w, "  }\n")
+	fmt.Fprintf(w, "  return crc == pgm_read_byte(&%sGlyphCRC[index]);\n", t.name)
+	fmt.Fprint(w, "}\n\n")
+}
+
+// writeGlyphNames emits the glyph names of a table as an array of strings.
+func writeGlyphNames(w io.Writer, name string, t *table) {
+	fmt.Fprintf(w, "#ifndef %s_NO_GLYPH_NAMES\n", name)
+	fmt.Fprintf(w, "const char *const %sGlyphNames[] = {\n", t.name)
+	for _, g := range t.font.Glyphs {
+		fmt.Fprintf(w, "  %s, // 0x%04X\n", CString(g.Name), g.Code)
+	}
+	fmt.Fprint(w, "};\n")
+	fmt.Fprint(w, "#endif\n\n")
+}
+
+// CString quotes s as a C string literal, escaping quotes, backslashes and
+// bytes outside printable ASCII.
+func CString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c >= 0x7F:
+			fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// writeBlockIndex emits the array of block fonts and the helper that picks
+// the block holding a codepoint.
+func writeBlockIndex(w io.Writer, name string, tables []*table) {
+	fmt.Fprintf(w, "#define %s_BLOCK_COUNT %d\n\n", name, len(tables))
+	fmt.Fprintf(w, "const GFXfont *const %sBlocks[] PROGMEM = {\n", name)
+	for _, t := range tables {
+		first, last := t.font.Range()
+		fmt.Fprintf(w, "  &%s, // 0x%04X-0x%04X\n", t.name, first, last)
+	}
+	fmt.Fprint(w, "};\n\n")
+
+	fmt.Fprintf(w, "// %sLookup returns the block font that has a glyph for code, or NULL.\n", name)
+	fmt.Fprintf(w, "static inline const GFXfont *%sLookup(uint16_t code) {\n", name)
+	fmt.Fprintf(w, "  for (uint8_t i = 0; i < %s_BLOCK_COUNT; i++) {\n", name)
+	fmt.Fprintf(w, "    const GFXfont *font = (const GFXfont *)pgm_read_ptr(&%sBlocks[i]);\n", name)
+	fmt.Fprint(w, "    if (code >= pgm_read_word(&font->first) && code <= pgm_read_word(&font->last)) {\n")
+	fmt.Fprint(w, "      return font;\n")
+	fmt.Fprint(w, "    }\n")
+	fmt.Fprint(w, "  }\n")
+	fmt.Fprint(w, "  return NULL;\n")
+	fmt.Fprint(w, "}\n\n")
+}
+
+// writeCoverage emits a bitset of the codes that have a glyph, most
+// significant bit first, and a helper that tests a code.
+func writeCoverage(w io.Writer, name string, font *Font) {
+	first, last := font.Range()
+	bits := make([]byte, (last-first)/8+1)
+	for _, g := range font.Glyphs {
+		i := g.Code - first
+		bits[i/8] |= 0x80 >> (i % 8)
+	}
+	fmt.Fprintf(w, "// Bit 7 - (i %% 8) of byte i / 8 is set when code 0x%04X + i has a glyph.\n", first)
+	fmt.Fprintf(w, "const uint8_t %sCoverage[%d] PROGMEM = {", name, len(bits))
+	for i, b := range bits {
+		if i%16 == 0 {
+			fmt.Fprint(w, "\n  ")
+		}
+		fmt.Fprintf(w, "0x%02X, ", b)
+	}
+	fmt.Fprint(w, "\n};\n\n")
+
+	fmt.Fprintf(w, "// %sHasGlyph returns 1 if the font has a glyph for code, else 0.\n", name)
+	fmt.Fprintf(w, "static inline uint8_t %sHasGlyph(uint16_t code) {\n", name)
+	fmt.Fprintf(w, "  if (code < 0x%x || code > 0x%x) {\n", first, last)
+	fmt.Fprint(w, "    return 0;\n")
+	fmt.Fprint(w, "  }\n")
+	fmt.Fprintf(w, "  code -= 0x%x;\n", first)
+	fmt.Fprintf(w, "  return (pgm_read_byte(&%sCoverage[code >> 3]) >> (7 - (code & 7))) & 1;\n", name)
+	fmt.Fprint(w, "}\n\n")
+}
+
+// writeKerning emits the kerning pairs as a sorted table and a helper that
+// finds the adjustment of a pair with a binary search.
+func writeKerning(w io.Writer, name string, pairs []KernPair) {
+	fmt.Fprint(w, "#ifndef GFX_KERNPAIR_DEFINED\n")
+	fmt.Fprint(w, "#define GFX_KERNPAIR_DEFINED\n")
+	fmt.Fprint(w, "typedef struct {\n")
+	fmt.Fprint(w, "  uint16_t left;\n")
+	fmt.Fprint(w, "  uint16_t right;\n")
+	fmt.Fprint(w, "  int8_t   adjust;\n")
+	fmt.Fprint(w, "} GFXkernPair;\n")
+	fmt.Fprint(w, "#endif\n\n")
+
+	fmt.Fprint(w, "// Sorted by left, then right code.\n")
+	fmt.Fprintf(w, "const GFXkernPair %sKerning[] PROGMEM = {\n", name)
+	for _, p := range pairs {
+		fmt.Fprintf(w, "  { 0x%04X, 0x%04X, %3d },\n", p.Left, p.Right, p.Adjust)
+	}
+	fmt.Fprint(w, "};\n\n")
+	fmt.Fprintf(w, "#define %s_KERNING_COUNT %d\n\n", name, len(pairs))
+
+	fmt.Fprint(w, "// Add the result to the xAdvance of left when it is followed by right.\n")
+	fmt.Fprintf(w, "static inline int8_t %sKern(uint16_t left, uint16_t right) {\n", name)
+	fmt.Fprintf(w, "  int lo = 0, hi = %s_KERNING_COUNT - 1;\n", name)
+	fmt.Fprint(w, "  while (lo <= hi) {\n")
+	fmt.Fprint(w, "    int mid = (lo + hi) / 2;\n")
+	fmt.Fprintf(w, "    uint16_t l = pgm_read_word(&%sKerning[mid].left);\n", name)
+	fmt.Fprintf(w, "    uint16_t r = pgm_read_word(&%sKerning[mid].right);\n", name)
+	fmt.Fprint(w, "    if (l == left && r == right) {\n")
+	fmt.Fprintf(w, "      return (int8_t)pgm_read_byte(&%sKerning[mid].adjust);\n", name)
+	fmt.Fprint(w, "    }\n")
+	fmt.Fprint(w, "    if (l < left || (l == left && r < right)) {\n")
+	fmt.Fprint(w, "      lo = mid + 1;\n")
+	fmt.Fprint(w, "    } else {\n")
+	fmt.Fprint(w, "      hi = mid - 1;\n")
+	fmt.Fprint(w, "    }\n")
+	fmt.Fprint(w, "  }\n")
+	fmt.Fprint(w, "  return 0;\n")
+	fmt.Fprint(w, "}\n\n")
+}
+
+// gfxFontSize is the size of GFXfont on AVR: two 16-bit pointers, first,
+// last and yAdvance, without padding.
+const gfxFontSize = 2 + 2 + 2 + 2 + 1
+
+// GlyphSize is the size of one GFXglyph entry on AVR in the layout of the
+// header, such as 7 bytes for the standard one.
+func (h *Header) GlyphSize() int {
+	size := 0
+	for _, f := range h.glyphFields() {
+		size += ctypeSize[f.ctype]
+	}
+	return size
+}
+
+// writeSizeDefine emits the flash footprint of a table.
+func (h *Header) writeSizeDefine(w io.Writer, t *table) {
+	glyphSize := h.GlyphSize()
+	bitmaps := max(len(t.bitmaps), 1) // an empty blob is emitted as one byte
+	glyphs := len(t.rows) * glyphSize
+	if h.CodeMap {
+		segments := 6 * len(Segments(t.font.Glyphs))
+		fmt.Fprintf(w, "// Flash on AVR: %d bytes of bitmaps + %d glyphs * %d bytes + %d bytes of segments.\n",
+			bitmaps, len(t.rows), glyphSize, segments)
+		fmt.Fprint(w, "// 32-bit targets pad GFXglyph and need a little more.\n")
+		fmt.Fprintf(w, "#define %s_FLASH_BYTES %d\n\n", t.name, bitmaps+glyphs+segments)
+		return
+	}
+	fmt.Fprintf(w, "// Flash on AVR: %d bytes of bitmaps + %d glyphs * %d bytes + %d bytes of GFXfont.\n",
+		bitmaps, len(t.rows), glyphSize, gfxFontSize)
+	fmt.Fprint(w, "// 32-bit targets pad GFXglyph and GFXfont and need a little more.\n")
+	fmt.Fprintf(w, "#define %s_FLASH_BYTES %d\n\n", t.name, bitmaps+glyphs+gfxFontSize)
+}
+
+// writeDeltas emits the delta entries of a table and a helper that
+// decodes any glyph bitmap, delta or not, into a buffer.
+func (h *Header) writeDeltas(w io.Writer, t *table) {
+	readDim := "pgm_read_byte"
+	if h.Wide {
+		readDim = "pgm_read_word"
+	}
+	fmt.Fprint(w, "#ifndef GFX_DELTA_DEFINED\n")
+	fmt.Fprint(w, "#define GFX_DELTA_DEFINED\n")
+	fmt.Fprint(w, "typedef struct {\n")
+	fmt.Fprint(w, "  uint16_t glyph;\n")
+	fmt.Fprint(w, "  uint16_t base;\n")
+	fmt.Fprint(w, "} GFXdelta;\n")
+	fmt.Fprint(w, "#endif\n\n")
+
+	fmt.Fprint(w, "// Glyphs whose bitmapOffset points at a delta record against the bitmap\n")
+	fmt.Fprint(w, "// of the base glyph, sorted by glyph index. A record holds runs of a byte\n")
+	fmt.Fprint(w, "// count to keep, a byte count n and n bytes to XOR into the base bitmap.\n")
+	fmt.Fprintf(w, "const GFXdelta %sDeltas[] PROGMEM = {\n", t.name)
+	for _, d := range t.deltas {
+		fmt.Fprintf(w, "  { %d, %d }, // 0x%04X from 0x%04X\n", d.Glyph, d.Base, t.font.Glyphs[d.Glyph].Code, t.font.Glyphs[d.Base].Code)
+	}
+	if len(t.deltas) == 0 {
+		fmt.Fprint(w, "  { 0xFFFF, 0xFFFF }, // no glyph is stored as a delta\n")
+	}
+	fmt.Fprint(w, "};\n\n")
+	fmt.Fprintf(w, "#define %s_DELTA_COUNT %d\n\n", t.name, len(t.deltas))
+
+	fmt.Fprint(w, "// Copies the bitmap of the glyph at index into out, which must hold\n")
+	fmt.Fprint(w, "// (width * height + 7) / 8 bytes.\n")
+	fmt.Fprintf(w, "static inline void %sDecodeBitmap(uint16_t index, uint8_t *out) {\n", t.name)
+	fmt.Fprintf(w, "  const GFXglyph *glyph = &%sGlyphs[index];\n", t.name)
+	fmt.Fprintf(w, "  uint16_t size = ((uint16_t)%s(&glyph->width) * %s(&glyph->height) + 7) / 8;\n", readDim, readDim)
+	fmt.Fprint(w, "  uint16_t base = index;\n")
+	fmt.Fprintf(w, "  int lo = 0, hi = %s_DELTA_COUNT - 1;\n", t.name)
+	fmt.Fprint(w, "  while (lo <= hi) {\n")
+	fmt.Fprint(w, "    int mid = (lo + hi) / 2;\n")
+	fmt.Fprintf(w, "    uint16_t g = pgm_read_word(&%sDeltas[mid].glyph);\n", t.name)
+	fmt.Fprint(w, "    if (g == index) {\n")
+	fmt.Fprintf(w, "      base = pgm_read_word(&%sDeltas[mid].base);\n", t.name)
+	fmt.Fprint(w, "      break;\n")
+	fmt.Fprint(w, "    }\n")
+	fmt.Fprint(w, "    if (g < index) {\n")
+	fmt.Fprint(w, "      lo = mid + 1;\n")
+	fmt.Fprint(w, "    } else {\n")
+	fmt.Fprint(w, "      hi = mid - 1;\n")
+	fmt.Fprint(w, "    }\n")
+	fmt.Fprint(w, "  }\n")
+	fmt.Fprintf(w, "  const uint8_t *src = &%sBitmaps[pgm_read_word(&%sGlyphs[base].bitmapOffset)];\n", t.name, t.name)
+	fmt.Fprint(w, "  for (uint16_t i = 0; i < size; i++) {\n")
+	fmt.Fprint(w, "    out[i] = pgm_read_byte(&src[i]);\n")
+	fmt.Fprint(w, "  }\n")
+	fmt.Fprint(w, "  if (base == index) {\n")
+	fmt.Fprint(w, "    return;\n")
+	fmt.Fprint(w, "  }\n")
+	fmt.Fprintf(w, "  const uint8_t *rec = &%sBitmaps[pgm_read_word(&glyph->bitmapOffset)];\n", t.name)
+	fmt.Fprint(w, "  uint16_t pos = 0;\n")
+	fmt.Fprint(w, "  while (pos < size) {\n")
+	fmt.Fprint(w, "    pos += pgm_read_byte(rec++);\n")
+	fmt.Fprint(w, "    uint8_t n = pgm_read_byte(rec++);\n")
+	fmt.Fprint(w, "    while (n--) {\n")
+	fmt.Fprint(w, "      out[pos++] ^= pgm_read_byte(rec++);\n")
+	fmt.Fprint(w, "    }\n")
+	fmt.Fprint(w, "  }\n")
+	fmt.Fprint(w, "}\n\n")
+}
+
+// comment returns text as a line comment starting at column col, cut to
+// end at CommentWidth.
+func (h *Header) comment(col int, text string) string {
+	c := "// " + text
+	if h.CommentWidth <= 0 || col+len(c) <= h.CommentWidth {
+		return c
+	}
+	const ellipsis = "..."
+	n := h.CommentWidth - col - len(ellipsis)
+	if n < len("//") {
+		n = len("//")
+	}
+	return c[:n] + ellipsis
+}
+
+// writeDebugArrays emits every glyph as a 2D <name>_glyph_XXXX array with
+// one row of bytes per bitmap row and the row drawn as ASCII art in a
+// comment.
+func writeDebugArrays(w io.Writer, name string, glyphs []*Glyph) {
+	for _, g := range glyphs {
+		fmt.Fprintf(w, "\n// 0x%04X %s: %dx%d, xOffset %d, yOffset %d, xAdvance %d\n",
+			g.Code, commentSafe(g.Name), g.Width, g.Height, g.XOffset, g.YOffsetTFT(), g.XAdvance)
+		if g.Width == 0 || g.Height == 0 {
+			fmt.Fprintf(w, "// (no bitmap)\n")
+			continue
+		}
+		bpr := g.BytesPerRow()
+		art := strings.Split(g.ASCII(), "\n")
+		fmt.Fprintf(w, "const uint8_t %s_glyph_%04X[%d][%d] PROGMEM = {\n", name, g.Code, g.Height, bpr)
+		for y := 0; y < g.Height; y++ {
+			fmt.Fprint(w, "  {")
+			for i, b := range g.Row(y) {
+				if i > 0 {
+					fmt.Fprint(w, ",")
+				}
+				fmt.Fprintf(w, " 0x%02X", b)
+			}
+			fmt.Fprintf(w, " }, // %s\n", art[y])
+		}
+		fmt.Fprint(w, "};\n")
+	}
+}
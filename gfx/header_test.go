@@ -0,0 +1,380 @@
+package gfx
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPackGlyphsSpaces(t *testing.T) {
+	space := func(code int) string { return testGlyph("space", code, 0, 0) }
+	font := parseTest(t, testBDF(space(0x20), testBox(0x21), space(0x22), space(0x23), testBox(0x24), space(0x25), space(0x26)))
+
+	// Spaces take no room and are not aligned; they share the offset of
+	// the next box, or of the last one at the end.
+	for _, tt := range []struct {
+		align, size int
+		want        []int
+	}{
+		// A packed box takes 2 bytes.
+		{1, 4, []int{0, 0, 2, 2, 2, 2, 2}},
+		{3, 5, []int{0, 0, 3, 3, 3, 3, 3}},
+	} {
+		align := tt.align
+		blob, offsets := PackGlyphs(font.Glyphs, align)
+		if len(blob) != tt.size {
+			t.Errorf("align %d: blob of %d bytes, want %d", align, len(blob), tt.size)
+		}
+		if !slices.Equal(offsets, tt.want) {
+			t.Errorf("align %d: offsets %d, want %d", align, offsets, tt.want)
+		}
+		// A renderer reads no bytes for a space, but the offset must
+		// still be inside the blob.
+		for i, off := range offsets {
+			if off >= len(blob) {
+				t.Errorf("align %d: glyph 0x%04X at %d, past the end", align, font.Glyphs[i].Code, off)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if _, err := (&Header{Font: font, Name: "Test"}).WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "{     2,  0,  0,  1,   0,   0 }, // 0x0026\n") {
+		t.Errorf("the last space does not share the offset of the last box:\n%s", &buf)
+	}
+}
+
+func TestHeaderOnlySpaces(t *testing.T) {
+	font := parseTest(t, testBDF(testGlyph("space", 0x20, 0, 0), testGlyph("nbsp", 0x21, 0, 0)))
+	var buf bytes.Buffer
+	if _, err := (&Header{Font: font, Name: "Test"}).WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	// const uint8_t x[] = {}; is not valid C.
+	want := "const uint8_t TestBitmaps[] PROGMEM = {\n  0x00, // no glyph has bitmap data\n};\n"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("header without the placeholder byte:\n%s", &buf)
+	}
+	if !strings.Contains(buf.String(), "{     0,  0,  0,  1,   0,   0 }, // 0x0021\n") {
+		t.Errorf("spaces do not point at the placeholder byte:\n%s", &buf)
+	}
+}
+
+func TestHeaderCoverage(t *testing.T) {
+	font := parseTest(t, testBDF(testBox(0x41), testBox(0x43), testBox(0x49), testBox(0x4A)))
+	var buf bytes.Buffer
+	if _, err := (&Header{Font: font, Name: "Test", Coverage: true}).WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	// Bits 0 and 2 of the first byte and 0 and 1 of the second, counted
+	// from the most significant bit.
+	for _, want := range []string{
+		"const uint8_t TestCoverage[2] PROGMEM = {\n  0xA0, 0xC0, \n};\n",
+		"  if (code < 0x41 || code > 0x4a) {\n",
+		"  code -= 0x41;\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("header without %q:\n%s", want, &buf)
+		}
+	}
+}
+
+func TestHeaderMetricDefines(t *testing.T) {
+	font := parseTest(t, testBDF(testBox(0x41), testBox(0x43)))
+	var buf bytes.Buffer
+	if _, err := (&Header{Font: font, Name: "Test", MetricDefines: true}).WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	// The glyph table has an entry for the missing 0x42 too.
+	for _, want := range []string{
+		"#define Test_FIRST 0x41\n#define Test_LAST 0x43\n#define Test_GLYPH_COUNT 3\n",
+		"#define Test_Y_ADVANCE 8\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("header without %q:\n%s", want, &buf)
+		}
+	}
+
+	buf.Reset()
+	if _, err := (&Header{Font: font, Name: "Test"}).WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "Test_FIRST") || strings.Contains(buf.String(), "Test_Y_ADVANCE") {
+		t.Errorf("default header has the metric defines:\n%s", &buf)
+	}
+}
+
+func TestHeaderGlyphCRC(t *testing.T) {
+	// The check value of the CRC-8 with polynomial 0x07.
+	if got := crc8([]byte("123456789")); got != 0xF4 {
+		t.Errorf("crc8(123456789) = 0x%02X, want 0xF4", got)
+	}
+
+	font := parseTest(t, testBDF(testBox(0x41), testGlyph("dot", 0x42, 1, 1, "80"), testGlyph("space", 0x43, 0, 0)))
+	var buf bytes.Buffer
+	if _, err := (&Header{Font: font, Name: "Test", GlyphCRC: true}).WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("const uint8_t TestGlyphCRC[] PROGMEM = {\n  0x%02X, // 0x0041\n  0x%02X, // 0x0042\n  0x00, // 0x0043\n};\n",
+		crc8([]byte{0xFF, 0xFF}), crc8([]byte{0x80}))
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("header without %q:\n%s", want, &buf)
+	}
+	if !strings.Contains(buf.String(), "static inline uint8_t TestGlyphCRCOk(uint16_t index) {\n") {
+		t.Errorf("header without TestGlyphCRCOk:\n%s", &buf)
+	}
+
+	if _, err := (&Header{Font: font, GlyphCRC: true, Delta: true}).WriteTo(&buf); err == nil {
+		t.Error("GlyphCRC with Delta did not fail")
+	}
+}
+
+func TestHeaderBitmaps(t *testing.T) {
+	font := parseTest(t, testBDF(testBox(0x41), testGlyph("space", 0x42, 0, 0), testGlyph("ring", 0x43, 3, 3, "E0", "A0", "E0")))
+	h := &Header{Font: font, Name: "Test", Align: 4}
+	data, err := h.Bitmaps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The ring starts at the aligned offset 4.
+	if want := []byte{0xFF, 0xFF, 0x00, 0x00, 0xF7, 0x80}; !bytes.Equal(data, want) {
+		t.Errorf("Bitmaps() = % X, want % X", data, want)
+	}
+
+	var buf bytes.Buffer
+	if _, err := h.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "0xFF, 0xFF, 0x00, 0x00, 0xF7, 0x80") {
+		t.Errorf("the header bitmaps differ from Bitmaps():\n%s", &buf)
+	}
+}
+
+func TestHeaderBitmapOrder(t *testing.T) {
+	font := parseTest(t, testBDF(testBox(0x41), testGlyph("ring", 0x42, 3, 3, "E0", "A0", "E0"), testGlyph("dot", 0x43, 1, 1, "80")))
+	// 0x44 has no glyph and is ignored.
+	h := &Header{Font: font, Name: "Test", BitmapOrder: []int{0x43, 0x44, 0x41}}
+	data, err := h.Bitmaps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{0x80, 0xFF, 0xFF, 0xF7, 0x80}; !bytes.Equal(data, want) {
+		t.Errorf("Bitmaps() = % X, want % X", data, want)
+	}
+
+	// The glyph table stays in code order, pointing into the new order.
+	var buf bytes.Buffer
+	if _, err := h.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"{     1,  4,  4,  5,   0,  -4 }, // 0x0041\n",
+		"{     3,  3,  3,  4,   0,  -3 }, // 0x0042\n",
+		"{     0,  1,  1,  2,   0,  -1 }, // 0x0043\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("header without %q:\n%s", want, &buf)
+		}
+	}
+
+	if _, err := (&Header{Font: font, BitmapOrder: []int{0x41}, Delta: true}).WriteTo(&buf); err == nil {
+		t.Error("BitmapOrder with Delta did not fail")
+	}
+}
+
+func TestCString(t *testing.T) {
+	for _, tt := range []struct{ in, want string }{
+		{"abc", `"abc"`},
+		{`a"b\c`, `"a\"b\\c"`},
+		// Octal escapes, which a following hex digit cannot extend.
+		{"\n\xE9a", `"\012\351a"`},
+	} {
+		if got := CString(tt.in); got != tt.want {
+			t.Errorf("CString(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHeaderDesignated(t *testing.T) {
+	font := parseTest(t, testBDF(testBox(0x41)))
+	for _, tt := range []struct {
+		header Header
+		want   string
+	}{
+		{Header{Designated: true},
+			"  { .bitmapOffset =     0, .width =  4, .height =  4, .xAdvance =  5, .xOffset =   0, .yOffset =  -4 }, // 0x0041\n"},
+		// -pretty sizes the values to fit.
+		{Header{Designated: true, Pretty: true},
+			"  { .bitmapOffset = 0, .width = 4, .height = 4, .xAdvance = 5, .xOffset = 0, .yOffset = -4 }, // 0x0041\n"},
+	} {
+		tt.header.Font, tt.header.Name = font, "Test"
+		var buf bytes.Buffer
+		if _, err := tt.header.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), tt.want) {
+			t.Errorf("header without %q:\n%s", tt.want, &buf)
+		}
+	}
+}
+
+func TestHeaderAdvanceIndex(t *testing.T) {
+	bar := testGlyph("bar", 0x42, 2, 4, "C0", "C0", "C0", "C0")
+	font := parseTest(t, testBDF(testBox(0x41), bar, testBox(0x43)))
+	if got, want := font.Advances(), []int{3, 5}; !slices.Equal(got, want) {
+		t.Fatalf("Advances() = %v, want %v", got, want)
+	}
+	var buf bytes.Buffer
+	if _, err := (&Header{Font: font, Name: "Test", AdvanceIndex: true}).WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"//   uint8_t  advanceIndex;\n",
+		"{     0,  4,  4,  1,   0,  -4 }, // 0x0041\n",
+		"{     2,  2,  4,  0,   0,  -4 }, // 0x0042\n",
+		"const uint8_t TestAdvances[] PROGMEM = {\n      3, // 0\n      5, // 1\n};\n",
+		"static inline uint8_t TestGlyphAdvance(const GFXglyph *glyph) {\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("header without %q:\n%s", want, &buf)
+		}
+	}
+}
+
+// headerOffsets returns the number of bytes in the Bitmaps array of a
+// header and the bitmapOffset of every row of its glyph table.
+func headerOffsets(t *testing.T, header string) (int, []int) {
+	t.Helper()
+	_, bitmaps, _ := strings.Cut(header, "Bitmaps[] PROGMEM = {\n")
+	bitmaps, _, _ = strings.Cut(bitmaps, "};")
+	_, table, _ := strings.Cut(header, "Glyphs[] PROGMEM = {\n")
+	table, _, _ = strings.Cut(table, "};")
+	var offsets []int
+	for _, row := range strings.Split(strings.TrimSpace(table), "\n") {
+		var off int
+		if _, err := fmt.Sscanf(strings.TrimSpace(row), "{ %d,", &off); err != nil {
+			t.Fatalf("glyph table row %q: %v", row, err)
+		}
+		offsets = append(offsets, off)
+	}
+	return strings.Count(bitmaps, "0x"), offsets
+}
+
+func TestHeaderTrailingSpaceOffset(t *testing.T) {
+	font := parseTest(t, testBDF(testBox(0x41), testGlyph("space", 0x42, 0, 0), testGlyph("nbsp", 0x44, 0, 0)))
+	for _, h := range []*Header{
+		{Font: font},
+		{Font: font, Align: 4},
+		{Font: font, Pretty: true},
+	} {
+		var buf bytes.Buffer
+		if _, err := h.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		size, offsets := headerOffsets(t, buf.String())
+		// 0x41, 0x42, the padding entry for 0x43 and 0x44.
+		if len(offsets) != 4 {
+			t.Fatalf("align %d: %d glyph table rows, want 4:\n%s", h.Align, len(offsets), &buf)
+		}
+		for i, off := range offsets {
+			if off >= size {
+				t.Errorf("align %d: glyph 0x%04X at offset %d of %d bitmap bytes", h.Align, 0x41+i, off, size)
+			}
+		}
+	}
+
+	// The compact decoder computes the offsets at run time.
+	var buf bytes.Buffer
+	if _, err := (&Header{Font: font, Compact: true}).WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "glyph->bitmapOffset = width && height ? offset : 0;\n") {
+		t.Errorf("compact decoder does not keep empty glyphs in bounds:\n%s", &buf)
+	}
+}
+
+func TestHeaderMissingBox(t *testing.T) {
+	font := parseTest(t, testBDF(testBox(0x41), testBox(0x45)))
+	var buf bytes.Buffer
+	if _, err := (&Header{Font: font, Name: "Test", MissingBox: true}).WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	// The 4x6 outline of the 5 pixel cell is stored once for the three
+	// missing codes.
+	for _, want := range []string{
+		"  0xFF, 0xFF, 0xF9, 0x99, 0x9F, 0xFF, 0xFF, \n",
+		"{     2,  4,  6,  5,   0,  -6 }, // 0x0042\n",
+		"{     2,  4,  6,  5,   0,  -6 }, // 0x0044\n",
+		"{     5,  4,  4,  5,   0,  -4 }, // 0x0045\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("header without %q:\n%s", want, &buf)
+		}
+	}
+	if _, err := (&Header{Font: font, Name: "Test", MissingBox: true, Compact: true}).WriteTo(&buf); err == nil {
+		t.Error("missing boxes combined with compact records")
+	}
+}
+
+func TestHeaderDebugArrays(t *testing.T) {
+	// Two fonts in one sketch must not both define glyph_0041.
+	font := parseTest(t, testBDF(testBox(0x41)))
+	var buf bytes.Buffer
+	if _, err := (&Header{Font: font, Name: "Test", DebugArrays: true}).WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if want := "const uint8_t Test_glyph_0041[4][1] PROGMEM = {\n  { 0xF0 }, // ####\n"; !strings.Contains(buf.String(), want) {
+		t.Errorf("header without %q:\n%s", want, &buf)
+	}
+}
+
+func TestHeaderGlyphSize(t *testing.T) {
+	for _, tt := range []struct {
+		header Header
+		want   int
+	}{
+		{Header{}, 7},
+		{Header{Wide: true}, 9},
+		{Header{WideAdvance: true}, 8},
+		{Header{Wide: true, WideAdvance: true}, 10},
+		// The advance index is a uint8_t whatever the advance type.
+		{Header{WideAdvance: true, AdvanceIndex: true}, 7},
+	} {
+		if got := tt.header.GlyphSize(); got != tt.want {
+			t.Errorf("GlyphSize() with wide %v, wide advance %v, advance index %v = %d, want %d",
+				tt.header.Wide, tt.header.WideAdvance, tt.header.AdvanceIndex, got, tt.want)
+		}
+	}
+}
+
+func TestHeaderTypedefComments(t *testing.T) {
+	// The typedefs document the layout of Adafruit_GFX.h, which defines
+	// them; a closer outside the comment does not compile.
+	font := parseTest(t, testBDF(testBox(0x41)))
+	for _, h := range []*Header{{Font: font, Name: "Test"}, {Font: font, Name: "Test", Wide: true}} {
+		var buf bytes.Buffer
+		if _, err := h.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		commented := false
+		for i, line := range strings.Split(buf.String(), "\n") {
+			switch {
+			case line == "// typedef struct {":
+				commented = true
+			case commented && strings.HasPrefix(line, "// } GFX"):
+				commented = false
+			case commented && !strings.HasPrefix(line, "//"):
+				t.Errorf("line %d %q inside a commented typedef:\n%s", i+1, line, &buf)
+			case strings.HasPrefix(line, "} GFXglyph;") || strings.HasPrefix(line, "} GFXfont;"):
+				t.Errorf("line %d %q closes a commented typedef outside the comment", i+1, line)
+			}
+		}
+		if commented {
+			t.Errorf("commented typedef is not closed:\n%s", &buf)
+		}
+	}
+}
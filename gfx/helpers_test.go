@@ -0,0 +1,67 @@
+package gfx
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// testBDF builds a BDF font with an ascent of 6 and a descent of 2 around
+// glyph definitions from testGlyph.
+func testBDF(glyphs ...string) string {
+	var b strings.Builder
+	b.WriteString("STARTFONT 2.1\n")
+	b.WriteString("FONT -Test-Fixed-Medium-R-Normal--8-80-75-75-C-60-ISO10646-1\n")
+	b.WriteString("SIZE 8 75 75\n")
+	b.WriteString("FONTBOUNDINGBOX 6 8 0 -2\n")
+	b.WriteString("STARTPROPERTIES 2\n")
+	b.WriteString("FONT_ASCENT 6\n")
+	b.WriteString("FONT_DESCENT 2\n")
+	b.WriteString("ENDPROPERTIES\n")
+	fmt.Fprintf(&b, "CHARS %d\n", len(glyphs))
+	for _, g := range glyphs {
+		b.WriteString(g)
+	}
+	b.WriteString("ENDFONT\n")
+	return b.String()
+}
+
+// testGlyph returns a glyph definition with a width x height BBX at the
+// baseline and the given hex rows.
+func testGlyph(name string, code, width, height int, rows ...string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "STARTCHAR %s\n", name)
+	fmt.Fprintf(&b, "ENCODING %d\n", code)
+	fmt.Fprintf(&b, "DWIDTH %d 0\n", width+1)
+	fmt.Fprintf(&b, "BBX %d %d 0 0\n", width, height)
+	b.WriteString("BITMAP\n")
+	for _, r := range rows {
+		b.WriteString(r + "\n")
+	}
+	b.WriteString("ENDCHAR\n")
+	return b.String()
+}
+
+// testBox returns a glyph definition whose bitmap is a filled 4x4 box.
+func testBox(code int) string {
+	return testGlyph(fmt.Sprintf("u%04X", code), code, 4, 4, "F0", "F0", "F0", "F0")
+}
+
+// parseTest parses a BDF font from a string and fails the test on errors.
+func parseTest(t *testing.T, bdf string) *Font {
+	t.Helper()
+	font, err := ParseBDF(strings.NewReader(bdf))
+	if err != nil {
+		t.Fatalf("ParseBDF: %v", err)
+	}
+	return font
+}
+
+// codes returns the codepoints of the glyphs of a font, in order.
+func codes(font *Font) []int {
+	var cs []int
+	for _, g := range font.Glyphs {
+		cs = append(cs, g.Code)
+	}
+	return cs
+}
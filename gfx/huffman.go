@@ -0,0 +1,225 @@
+package gfx
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// HuffmanMaxBits is the longest code of a HuffmanTable. It keeps the
+// decoder's arithmetic within a 16-bit int.
+const HuffmanMaxBits = 15
+
+// HuffmanTable is a canonical Huffman code over bitmap bytes. Counts[n] is
+// the number of codes of n bits and Symbols lists the coded bytes by code
+// length, then by value. The first code of each length follows the last
+// code of the previous length, shifted left by one bit, as in DEFLATE.
+type HuffmanTable struct {
+	Counts  [HuffmanMaxBits + 1]int
+	Symbols []byte
+}
+
+// NewHuffmanTable builds the code for the byte frequencies of data. A
+// single distinct byte gets a 1-bit code, so every byte takes a bit.
+func NewHuffmanTable(data []byte) *HuffmanTable {
+	var freq [256]int
+	for _, b := range data {
+		freq[b]++
+	}
+	lengths := huffmanLengths(freq)
+	// Longer codes than HuffmanMaxBits only come from skewed counts;
+	// flattening them until the code fits costs almost nothing.
+	for maxLength(lengths) > HuffmanMaxBits {
+		for i := range freq {
+			if freq[i] > 0 {
+				freq[i] = (freq[i] + 1) / 2
+			}
+		}
+		lengths = huffmanLengths(freq)
+	}
+
+	t := &HuffmanTable{}
+	for n := 1; n <= HuffmanMaxBits; n++ {
+		for s := 0; s < 256; s++ {
+			if lengths[s] == n {
+				t.Counts[n]++
+				t.Symbols = append(t.Symbols, byte(s))
+			}
+		}
+	}
+	return t
+}
+
+// huffmanLengths returns the code length of every byte with a non-zero
+// frequency, merging the two least frequent nodes until one is left.
+func huffmanLengths(freq [256]int) [256]int {
+	type node struct {
+		weight  int
+		symbols []int
+	}
+	var nodes []node
+	for s, f := range freq {
+		if f > 0 {
+			nodes = append(nodes, node{f, []int{s}})
+		}
+	}
+	var lengths [256]int
+	if len(nodes) == 1 {
+		lengths[nodes[0].symbols[0]] = 1
+		return lengths
+	}
+	for len(nodes) > 1 {
+		sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].weight < nodes[j].weight })
+		a, b := nodes[0], nodes[1]
+		for _, s := range a.symbols {
+			lengths[s]++
+		}
+		for _, s := range b.symbols {
+			lengths[s]++
+		}
+		merged := node{a.weight + b.weight, append(append([]int{}, a.symbols...), b.symbols...)}
+		nodes = append([]node{merged}, nodes[2:]...)
+	}
+	return lengths
+}
+
+func maxLength(lengths [256]int) int {
+	m := 0
+	for _, n := range lengths {
+		m = max(m, n)
+	}
+	return m
+}
+
+// codes returns the code and its length in bits of every symbol.
+func (t *HuffmanTable) codes() map[byte][2]int {
+	codes := map[byte][2]int{}
+	first, index := 0, 0
+	for n := 1; n <= HuffmanMaxBits; n++ {
+		for k := 0; k < t.Counts[n]; k++ {
+			codes[t.Symbols[index+k]] = [2]int{first + k, n}
+		}
+		index += t.Counts[n]
+		first = (first + t.Counts[n]) << 1
+	}
+	return codes
+}
+
+// Encode returns the codes of data, most significant bit first, with the
+// last byte padded with zero bits. Every byte of data must be in t.
+func (t *HuffmanTable) Encode(data []byte) []byte {
+	codes := t.codes()
+	var out []byte
+	bits := 0
+	for _, b := range data {
+		c := codes[b]
+		for i := c[1] - 1; i >= 0; i-- {
+			if bits%8 == 0 {
+				out = append(out, 0)
+			}
+			if c[0]>>i&1 != 0 {
+				out[len(out)-1] |= 0x80 >> (bits % 8)
+			}
+			bits++
+		}
+	}
+	return out
+}
+
+// Decode decodes n bytes from the start of data, the way the generated
+// decoder does.
+func (t *HuffmanTable) Decode(data []byte, n int) ([]byte, error) {
+	out := make([]byte, 0, n)
+	bit := 0
+	for len(out) < n {
+		code, first, index := 0, 0, 0
+		for length := 1; ; length++ {
+			if length > HuffmanMaxBits || bit >= 8*len(data) {
+				return nil, fmt.Errorf("bad Huffman code at bit %d", bit)
+			}
+			code |= int(data[bit/8]>>(7-bit%8)) & 1
+			bit++
+			count := t.Counts[length]
+			if code-count < first {
+				out = append(out, t.Symbols[index+code-first])
+				break
+			}
+			index += count
+			first = (first + count) << 1
+			code <<= 1
+		}
+	}
+	return out, nil
+}
+
+// PackGlyphsHuffman is like PackGlyphs with an align of one, but stores
+// every bitmap Huffman coded with one table built over the bitmaps of all
+// glyphs. Each glyph starts on a byte, so the offsets stay byte offsets.
+func PackGlyphsHuffman(glyphs []*Glyph) ([]byte, []int, *HuffmanTable) {
+	var all []byte
+	for _, g := range glyphs {
+		all = append(all, g.PackedBitmap()...)
+	}
+	t := NewHuffmanTable(all)
+	blob, offsets := packWith(glyphs, 1, func(g *Glyph) []byte { return t.Encode(g.PackedBitmap()) })
+	return blob, offsets, t
+}
+
+// writeHuffman emits the Huffman table of a table and a helper that
+// decodes a glyph bitmap into a buffer.
+func (h *Header) writeHuffman(w io.Writer, t *table) {
+	readDim := "pgm_read_byte"
+	if h.Wide {
+		readDim = "pgm_read_word"
+	}
+	fmt.Fprint(w, "// Canonical Huffman code of the bitmap bytes: Counts[n] codes have n bits,\n")
+	fmt.Fprint(w, "// and Symbols lists their bytes by length, then value.\n")
+	fmt.Fprintf(w, "const uint16_t %sHuffmanCounts[%d] PROGMEM = {", t.name, HuffmanMaxBits+1)
+	for i, c := range t.huffman.Counts {
+		if i > 0 {
+			fmt.Fprint(w, ",")
+		}
+		fmt.Fprintf(w, " %d", c)
+	}
+	fmt.Fprint(w, " };\n")
+	symbols := t.huffman.Symbols
+	if len(symbols) == 0 {
+		symbols = []byte{0} // C does not allow empty arrays
+	}
+	fmt.Fprintf(w, "const uint8_t %sHuffmanSymbols[%d] PROGMEM = {", t.name, len(symbols))
+	for i, s := range symbols {
+		if i%16 == 0 {
+			fmt.Fprint(w, "\n  ")
+		}
+		fmt.Fprintf(w, "0x%02X, ", s)
+	}
+	fmt.Fprint(w, "\n};\n\n")
+
+	fmt.Fprint(w, "// Decodes the bitmap of the glyph at index into out, which must hold\n")
+	fmt.Fprint(w, "// (width * height + 7) / 8 bytes. The codes of a glyph start on a byte at\n")
+	fmt.Fprint(w, "// its bitmapOffset and are read most significant bit first.\n")
+	fmt.Fprintf(w, "static inline void %sDecodeBitmap(uint16_t index, uint8_t *out) {\n", t.name)
+	fmt.Fprintf(w, "  const GFXglyph *glyph = &%sGlyphs[index];\n", t.name)
+	fmt.Fprintf(w, "  uint16_t size = ((uint16_t)%s(&glyph->width) * %s(&glyph->height) + 7) / 8;\n", readDim, readDim)
+	fmt.Fprintf(w, "  const uint8_t *src = &%sBitmaps[pgm_read_word(&glyph->bitmapOffset)];\n", t.name)
+	fmt.Fprint(w, "  uint8_t byte = 0, bits = 0;\n")
+	fmt.Fprint(w, "  for (uint16_t i = 0; i < size; i++) {\n")
+	fmt.Fprint(w, "    int code = 0, first = 0, symbol = 0;\n")
+	fmt.Fprintf(w, "    for (uint8_t length = 1; length <= %d; length++) {\n", HuffmanMaxBits)
+	fmt.Fprint(w, "      if (bits == 0) {\n")
+	fmt.Fprint(w, "        byte = pgm_read_byte(src++);\n")
+	fmt.Fprint(w, "        bits = 8;\n")
+	fmt.Fprint(w, "      }\n")
+	fmt.Fprint(w, "      code |= (byte >> --bits) & 1;\n")
+	fmt.Fprintf(w, "      int count = pgm_read_word(&%sHuffmanCounts[length]);\n", t.name)
+	fmt.Fprint(w, "      if (code - count < first) {\n")
+	fmt.Fprintf(w, "        out[i] = pgm_read_byte(&%sHuffmanSymbols[symbol + code - first]);\n", t.name)
+	fmt.Fprint(w, "        break;\n")
+	fmt.Fprint(w, "      }\n")
+	fmt.Fprint(w, "      symbol += count;\n")
+	fmt.Fprint(w, "      first = (first + count) << 1;\n")
+	fmt.Fprint(w, "      code <<= 1;\n")
+	fmt.Fprint(w, "    }\n")
+	fmt.Fprint(w, "  }\n")
+	fmt.Fprint(w, "}\n\n")
+}
@@ -0,0 +1,59 @@
+package gfx
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestHuffmanRoundTrip(t *testing.T) {
+	// Fibonacci counts give a code as deep as there are symbols, so 20
+	// of them only fit after flattening.
+	var skewed []byte
+	a, b := 1, 1
+	for s := range 20 {
+		skewed = append(skewed, bytes.Repeat([]byte{byte(s)}, a)...)
+		a, b = b, a+b
+	}
+	for _, data := range [][]byte{
+		{0xFF, 0xFF, 0xFF},
+		{0x00, 0xFF, 0xFF, 0x81, 0x00, 0xFF},
+		skewed,
+	} {
+		table := NewHuffmanTable(data)
+		for n, count := range table.Counts {
+			if count > 0 && n > HuffmanMaxBits {
+				t.Errorf("%d codes of %d bits", count, n)
+			}
+		}
+		coded := table.Encode(data)
+		decoded, err := table.Decode(coded, len(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decoded, data) {
+			t.Errorf("decoded % X, want % X", decoded, data)
+		}
+	}
+
+	// A single distinct byte takes one bit.
+	if coded := NewHuffmanTable([]byte{0xFF}).Encode(bytes.Repeat([]byte{0xFF}, 9)); len(coded) != 2 {
+		t.Errorf("9 bytes coded in %d bytes, want 2", len(coded))
+	}
+}
+
+func TestPackGlyphsHuffman(t *testing.T) {
+	font := parseTest(t, testBDF(
+		testBox(0x41), testGlyph("bar", 0x42, 1, 3, "80", "80", "80"),
+		testGlyph("space", 0x43, 0, 0), testGlyph("wide", 0x44, 12, 2, "FFF0", "8010")))
+	blob, offsets, table := PackGlyphsHuffman(font.Glyphs)
+	for i, g := range font.Glyphs {
+		want := g.PackedBitmap()
+		got, err := table.Decode(blob[offsets[i]:], len(want))
+		if err != nil {
+			t.Fatalf("glyph 0x%04X: %v", g.Code, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("glyph 0x%04X decoded to % X, want % X", g.Code, got, want)
+		}
+	}
+}
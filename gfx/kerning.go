@@ -0,0 +1,47 @@
+package gfx
+
+import "slices"
+
+// KernPair adjusts the advance of glyph Left by Adjust pixels when it is
+// followed by glyph Right. BDF has no kerning, so pairs come from the
+// STARTKERNING extension block:
+//
+//	STARTKERNING 2
+//	KERNPAIR 65 86 -1
+//	KERNPAIR 86 65 -1
+//	ENDKERNING
+type KernPair struct {
+	Left   int `json:"left"`
+	Right  int `json:"right"`
+	Adjust int `json:"adjust"`
+}
+
+// KernPairs returns the pairs whose glyphs are both in the font, sorted by
+// Left and then Right code. Of repeated pairs the last one wins.
+func (f *Font) KernPairs() []KernPair {
+	var pairs []KernPair
+	for _, p := range f.Kerning {
+		if _, ok := f.Glyph(p.Left); !ok {
+			continue
+		}
+		if _, ok := f.Glyph(p.Right); !ok {
+			continue
+		}
+		pairs = append(pairs, p)
+	}
+	slices.SortStableFunc(pairs, func(a, b KernPair) int {
+		if a.Left != b.Left {
+			return a.Left - b.Left
+		}
+		return a.Right - b.Right
+	})
+	var unique []KernPair
+	for _, p := range pairs {
+		if n := len(unique); n > 0 && unique[n-1].Left == p.Left && unique[n-1].Right == p.Right {
+			unique[n-1] = p
+			continue
+		}
+		unique = append(unique, p)
+	}
+	return unique
+}
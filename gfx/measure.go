@@ -0,0 +1,132 @@
+package gfx
+
+import "sort"
+
+// PlacedGlyph is a glyph of a laid out string, with its origin X pixels
+// right of the origin of the string.
+type PlacedGlyph struct {
+	Glyph *Glyph
+	X     int
+}
+
+// Layout places the glyphs of s on one line without kerning and returns
+// them together with the total advance. Codepoints missing from the font
+// use the DEFAULT_CHAR glyph if there is one and are skipped otherwise.
+func (f *Font) Layout(s string) (glyphs []PlacedGlyph, advance int) {
+	fallback := -1
+	if f.DefaultChar >= 0 {
+		fallback = f.glyphIndex(f.DefaultChar)
+	}
+	for _, r := range s {
+		idx := f.glyphIndex(int(r))
+		if idx < 0 {
+			idx = fallback
+		}
+		if idx < 0 {
+			continue
+		}
+		g := f.Glyphs[idx]
+		glyphs = append(glyphs, PlacedGlyph{g, advance})
+		advance += g.XAdvance
+	}
+	return glyphs, advance
+}
+
+// MeasureString lays out s like Layout and returns its width in pixels
+// together with the largest extents above (ascent) and below (descent) the
+// baseline.
+func (f *Font) MeasureString(s string) (width, ascent, descent int) {
+	glyphs, advance := f.Layout(s)
+	for _, p := range glyphs {
+		if g := p.Glyph; g.Width > 0 && g.Height > 0 {
+			width = max(width, p.X+g.XOffset+g.Width)
+			ascent = max(ascent, g.YOffset+g.Height)
+			descent = max(descent, -g.YOffset)
+		}
+	}
+	return max(width, advance), ascent, descent
+}
+
+// InkBounds returns the union of the bounding boxes of all glyphs relative
+// to their origin, in BDF coordinates with y growing upwards: x from minX
+// up to but excluding maxX, y from minY up to but excluding maxY. Glyphs
+// without a bitmap are ignored; a font without any returns all zeros.
+func (f *Font) InkBounds() (minX, maxX, minY, maxY int) {
+	first := true
+	for _, g := range f.Glyphs {
+		if g.Width <= 0 || g.Height <= 0 {
+			continue
+		}
+		if first {
+			minX, maxX = g.XOffset, g.XOffset+g.Width
+			minY, maxY = g.YOffset, g.YOffset+g.Height
+			first = false
+			continue
+		}
+		minX = min(minX, g.XOffset)
+		maxX = max(maxX, g.XOffset+g.Width)
+		minY = min(minY, g.YOffset)
+		maxY = max(maxY, g.YOffset+g.Height)
+	}
+	return minX, maxX, minY, maxY
+}
+
+// OutsideBoundingBox returns the glyphs whose BBX reaches outside the
+// FONTBOUNDINGBOX, which often means a corrupt or misparsed glyph. Glyphs
+// without a bitmap are not checked, nor are fonts without a
+// FONTBOUNDINGBOX.
+func (f *Font) OutsideBoundingBox() []*Glyph {
+	bb := f.BoundingBox
+	if bb == nil {
+		return nil
+	}
+	var outside []*Glyph
+	for _, g := range f.Glyphs {
+		if g.Width <= 0 || g.Height <= 0 {
+			continue
+		}
+		if g.XOffset < bb.XOffset || g.YOffset < bb.YOffset ||
+			g.XOffset+g.Width > bb.XOffset+bb.Width || g.YOffset+g.Height > bb.YOffset+bb.Height {
+			outside = append(outside, g)
+		}
+	}
+	return outside
+}
+
+// Monospace returns the most common non-zero advance of the font and the
+// glyphs with a different non-zero advance, which a fixed-cell renderer
+// would misplace. Glyphs with a zero advance, such as combining marks, are
+// not counted.
+func (f *Font) Monospace() (advance int, offenders []*Glyph) {
+	counts := map[int]int{}
+	for _, g := range f.Glyphs {
+		if g.XAdvance != 0 {
+			counts[g.XAdvance]++
+		}
+	}
+	for a, n := range counts {
+		if n > counts[advance] || (n == counts[advance] && a < advance) {
+			advance = a
+		}
+	}
+	for _, g := range f.Glyphs {
+		if g.XAdvance != 0 && g.XAdvance != advance {
+			offenders = append(offenders, g)
+		}
+	}
+	return advance, offenders
+}
+
+// Advances returns the distinct advances of the font in ascending order.
+func (f *Font) Advances() []int {
+	seen := map[int]bool{}
+	var advances []int
+	for _, g := range f.Glyphs {
+		if !seen[g.XAdvance] {
+			seen[g.XAdvance] = true
+			advances = append(advances, g.XAdvance)
+		}
+	}
+	sort.Ints(advances)
+	return advances
+}
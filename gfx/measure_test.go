@@ -0,0 +1,51 @@
+package gfx
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestOutsideBoundingBox(t *testing.T) {
+	// The test fonts have a FONTBOUNDINGBOX of 6 8 0 -2.
+	tall := testGlyph("tall", 0x42, 2, 7, "C0", "C0", "C0", "C0", "C0", "C0", "C0")
+	low := strings.Replace(testGlyph("low", 0x43, 1, 1, "80"), "BBX 1 1 0 0", "BBX 1 1 0 -3", 1)
+	descender := strings.Replace(testGlyph("descender", 0x44, 6, 8, "FC", "FC", "FC", "FC", "FC", "FC", "FC", "FC"), "BBX 6 8 0 0", "BBX 6 8 0 -2", 1)
+	font := parseTest(t, testBDF(testBox(0x41), tall, low, descender, testGlyph("space", 0x20, 0, 0)))
+	if bb := font.BoundingBox; bb == nil || *bb != (BBX{6, 8, 0, -2}) {
+		t.Fatalf("BoundingBox = %v, want 6 8 0 -2", bb)
+	}
+
+	var outside []int
+	for _, g := range font.OutsideBoundingBox() {
+		outside = append(outside, g.Code)
+	}
+	if want := []int{0x42, 0x43}; !slices.Equal(outside, want) {
+		t.Errorf("OutsideBoundingBox() codes %#x, want %#x", outside, want)
+	}
+
+	font.BoundingBox = nil
+	if got := font.OutsideBoundingBox(); got != nil {
+		t.Errorf("OutsideBoundingBox() without FONTBOUNDINGBOX = %v", got)
+	}
+}
+
+func TestLayout(t *testing.T) {
+	font := parseTest(t, testBDF(testBox(0x41), testGlyph("dot", 0x42, 1, 1, "80"), testBox(0x3F)))
+	font.DefaultChar = -1
+	glyphs, advance := font.Layout("ABxA")
+	var placed []int
+	for _, p := range glyphs {
+		placed = append(placed, p.Glyph.Code, p.X)
+	}
+	// x has no glyph and is skipped.
+	if want := []int{0x41, 0, 0x42, 5, 0x41, 7}; !slices.Equal(placed, want) || advance != 12 {
+		t.Errorf("Layout() = %#x with an advance of %d, want %#x and 12", placed, advance, want)
+	}
+
+	// With a DEFAULT_CHAR it takes the place of missing codes.
+	font.DefaultChar = 0x3F
+	if glyphs, advance := font.Layout("xB"); len(glyphs) != 2 || glyphs[0].Glyph.Code != 0x3F || glyphs[1].X != 5 || advance != 7 {
+		t.Errorf("Layout() with DEFAULT_CHAR = %v with an advance of %d", glyphs, advance)
+	}
+}
@@ -0,0 +1,41 @@
+package gfx
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Merge adds the glyphs of other whose codes f does not define yet and
+// returns the codes both fonts define; those keep the glyph of f. Ascent and
+// descent grow to cover both fonts and the kerning pairs are combined. Both
+// fonts must use the same bits per pixel.
+func (f *Font) Merge(other *Font) (conflicts []int, err error) {
+	if max(f.BitsPerPixel, 1) != max(other.BitsPerPixel, 1) {
+		return nil, fmt.Errorf("cannot merge a %d bpp font into a %d bpp font", max(other.BitsPerPixel, 1), max(f.BitsPerPixel, 1))
+	}
+	// The glyphs are added at once, so the lookups share one code index;
+	// repeated codes of other are adjacent.
+	var added []*Glyph
+	for _, g := range other.Glyphs {
+		if _, ok := f.Glyph(g.Code); ok || (len(added) > 0 && added[len(added)-1].Code == g.Code) {
+			conflicts = append(conflicts, g.Code)
+			continue
+		}
+		added = append(added, g)
+	}
+	glyphs := append(slices.Clip(f.Glyphs), added...)
+	slices.SortStableFunc(glyphs, func(a, b *Glyph) int { return a.Code - b.Code })
+	f.Glyphs = glyphs
+	f.Ascent = max(f.Ascent, other.Ascent)
+	f.Descent = max(f.Descent, other.Descent)
+	f.Kerning = append(f.Kerning, other.Kerning...)
+	return conflicts, nil
+}
+
+// Contiguous returns a copy of the font covering every code from its first
+// to its last glyph, see Sub, and the number of empty glyphs that were added.
+func (f *Font) Contiguous() (*Font, int) {
+	first, last := f.Range()
+	sub := f.Sub(CodeRange{first, last})
+	return sub, len(sub.Glyphs) - len(f.Glyphs)
+}
@@ -0,0 +1,142 @@
+package gfx
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// MoveNotdefFirst makes the glyph selected by spec the first entry of the
+// glyph table. spec is a codepoint, a STARTCHAR name, which may be that of
+// an unencoded glyph, or "default" for the DEFAULT_CHAR property. To keep
+// `code - first` indexing valid the notdef glyph takes the codepoint just
+// below the lowest real glyph; a glyph of the font that is not already the
+// lowest is copied there and keeps rendering at its own codepoint. When the
+// glyph is not in the font an empty box is synthesized in its place and
+// synthesized is true.
+func (f *Font) MoveNotdefFirst(spec string) (synthesized bool, err error) {
+	notdef, err := f.NotdefGlyph(spec)
+	if err != nil {
+		return false, err
+	}
+	idx := slices.Index(f.Glyphs, notdef)
+	unencoded := -1
+	if idx < 0 {
+		for i, g := range f.unencoded {
+			if g.Name == spec {
+				unencoded = i
+				break
+			}
+		}
+	}
+
+	synthesized = idx < 0 && unencoded < 0
+	if idx == 0 {
+		// Already first, at its own codepoint.
+		f.HasNotdef = true
+		return false, nil
+	}
+	code := 0
+	if len(f.Glyphs) > 0 {
+		if f.Glyphs[0].Code == 0 {
+			return synthesized, fmt.Errorf("no free codepoint below 0x%04X for the notdef glyph", f.Glyphs[0].Code)
+		}
+		code = f.Glyphs[0].Code - 1
+	}
+
+	var g *Glyph
+	switch {
+	case synthesized:
+		width, height := f.cellSize()
+		g = BoxGlyph(width, height)
+	case unencoded >= 0:
+		g = f.unencoded[unencoded]
+		f.unencoded = append(f.unencoded[:unencoded:unencoded], f.unencoded[unencoded+1:]...)
+	default:
+		copied := *f.Glyphs[idx]
+		copied.Bitmap = slices.Clone(copied.Bitmap)
+		copied.Gray = slices.Clone(copied.Gray)
+		g = &copied
+	}
+	g.Code = code
+	f.Glyphs = append([]*Glyph{g}, f.Glyphs...)
+	f.HasNotdef = true
+	return synthesized, nil
+}
+
+// NotdefGlyph returns the glyph of the font that spec selects for
+// MoveNotdefFirst, or nil when none of the encoded glyphs matches.
+func (f *Font) NotdefGlyph(spec string) (*Glyph, error) {
+	idx := -1
+	switch code, err := strconv.ParseInt(spec, 0, 32); {
+	case spec == "default":
+		if f.DefaultChar < 0 {
+			return nil, fmt.Errorf("notdef: font has no DEFAULT_CHAR property")
+		}
+		idx = f.glyphIndex(f.DefaultChar)
+	case err == nil:
+		idx = f.glyphIndex(int(code))
+	default:
+		idx = slices.IndexFunc(f.Glyphs, func(g *Glyph) bool { return g.Name == spec })
+	}
+	if idx < 0 {
+		return nil, nil
+	}
+	return f.Glyphs[idx], nil
+}
+
+// cellSize guesses the font's character cell: the most common advance and
+// the ascent.
+func (f *Font) cellSize() (int, int) {
+	counts := map[int]int{}
+	width := 0
+	for _, g := range f.Glyphs {
+		counts[g.XAdvance]++
+		if counts[g.XAdvance] > counts[width] || (counts[g.XAdvance] == counts[width] && g.XAdvance > width) {
+			width = g.XAdvance
+		}
+	}
+	return width, f.Ascent
+}
+
+// missingBoxes returns a function that makes the box glyph of a missing
+// code, see BoxGlyph, in the cell of the font. The boxes are placeholders
+// of one shape, so the bitmaps store one of them for all.
+func (f *Font) missingBoxes() func(code int) *Glyph {
+	width, height := f.cellSize()
+	box := BoxGlyph(width, height)
+	box.placeholder = true
+	return func(code int) *Glyph {
+		g := *box
+		g.Code = code
+		return &g
+	}
+}
+
+// BoxGlyph builds a rectangle outline sitting on the baseline, one pixel
+// narrower than the advance so neighbouring boxes do not touch.
+func BoxGlyph(advance, height int) *Glyph {
+	width := advance - 1
+	if width < 2 {
+		width = 2
+	}
+	if height < 2 {
+		height = 2
+	}
+	bytesPerRow := (width + 7) / 8
+	row := func(full bool) []byte {
+		r := make([]byte, bytesPerRow)
+		for x := 0; x < width; x++ {
+			if full || x == 0 || x == width-1 {
+				r[x/8] |= 0x80 >> (x % 8)
+			}
+		}
+		return r
+	}
+
+	g := &Glyph{Name: ".notdef", Width: width, Height: height, XAdvance: width + 1}
+	for y := 0; y < height; y++ {
+		g.Bitmap = append(g.Bitmap, row(y == 0 || y == height-1)...)
+	}
+	return g
+}
@@ -0,0 +1,72 @@
+package gfx
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestMoveNotdefFirstUnencoded(t *testing.T) {
+	notdef := testGlyph(".notdef", -1, 3, 3, "E0", "A0", "E0")
+	font := parseTest(t, testBDF(testBox(0x41), notdef, testBox(0x42)))
+	synthesized, err := font.MoveNotdefFirst(".notdef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if synthesized {
+		t.Error("the unencoded .notdef was not found by name")
+	}
+	g := font.Glyphs[0]
+	if g.Name != ".notdef" || g.Code != 0x40 || !bytes.Equal(g.Bitmap, []byte{0xE0, 0xA0, 0xE0}) {
+		t.Errorf("first glyph %q 0x%04X %X, want .notdef at 0x0040 with its bitmap", g.Name, g.Code, g.Bitmap)
+	}
+	if got, want := codes(font), []int{0x40, 0x41, 0x42}; !slices.Equal(got, want) {
+		t.Errorf("codes %#x, want %#x", got, want)
+	}
+	if !font.HasNotdef {
+		t.Error("HasNotdef is not set")
+	}
+}
+
+func TestMoveNotdefFirstMissing(t *testing.T) {
+	font := parseTest(t, testBDF(testBox(0x41)))
+	synthesized, err := font.MoveNotdefFirst(".notdef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !synthesized || font.Glyphs[0].Code != 0x40 || font.Glyphs[0].IsBlank() {
+		t.Errorf("synthesized %v, first glyph 0x%04X; want a box at 0x0040", synthesized, font.Glyphs[0].Code)
+	}
+}
+
+func TestMoveNotdefFirstCopiesGlyph(t *testing.T) {
+	font := parseTest(t, testBDF(testBox(0x41), testGlyph("question", 0x3F+0x10, 3, 2, "E0", "40")))
+	synthesized, err := font.MoveNotdefFirst("0x4F")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if synthesized {
+		t.Error("glyph 0x4F was not found")
+	}
+	if got, want := codes(font), []int{0x40, 0x41, 0x4F}; !slices.Equal(got, want) {
+		t.Fatalf("codes %#x, want %#x", got, want)
+	}
+	first, original := font.Glyphs[0], font.Glyphs[2]
+	if first == original || first.Name != "question" || !bytes.Equal(first.Bitmap, original.Bitmap) {
+		t.Errorf("first glyph %+v is not a copy of %+v", first, original)
+	}
+	first.Bitmap[0] = 0
+	if original.Bitmap[0] != 0xE0 {
+		t.Error("the copy shares its bitmap with the original")
+	}
+}
+
+func TestMoveNotdefFirstLowest(t *testing.T) {
+	font := parseTest(t, testBDF(testBox(0x20), testBox(0x41)))
+	if _, err := font.MoveNotdefFirst("0x20"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := codes(font), []int{0x20, 0x41}; !slices.Equal(got, want) {
+		t.Errorf("codes %#x, want %#x", got, want)
+	}
+}
@@ -0,0 +1,110 @@
+package gfx
+
+import (
+	"fmt"
+	"io"
+)
+
+// Options configures WriteGFX. The zero value writes every glyph of the
+// font as a standard GFX header named "Font".
+type Options struct {
+	// Header holds the layout of the C header. WriteGFX sets its Font and
+	// the fields Format selects.
+	Header Header
+
+	// Format selects the layout like the -format flag of bdf2tft: gfx,
+	// gfx-blocks, gfx-cmap, gfx-compact, gfx-planes or debug-c. Empty
+	// means gfx. gfx-blocks splits at gaps wider than Header.BlockGap.
+	Format string
+
+	// Ranges keeps only the glyphs of these codes; all glyphs are written
+	// when it is empty.
+	Ranges CodeRanges
+}
+
+// An Option changes the Options of WriteGFX.
+type Option func(*Options)
+
+// WithName sets the symbol name of the font.
+func WithName(name string) Option {
+	return func(o *Options) { o.Header.Name = name }
+}
+
+// WithFormat selects the header layout, see Options.Format.
+func WithFormat(format string) Option {
+	return func(o *Options) { o.Format = format }
+}
+
+// WithRange keeps the glyphs of the codes from first to last, in addition
+// to those of earlier WithRange options.
+func WithRange(first, last int) Option {
+	return func(o *Options) { o.Ranges = append(o.Ranges, CodeRange{first, last}) }
+}
+
+// WithTransform calls fn on a copy of every glyph before it is written,
+// after the transforms of earlier WithTransform options, see
+// Header.GlyphTransform.
+func WithTransform(fn func(*Glyph)) Option {
+	return func(o *Options) {
+		if prev := o.Header.GlyphTransform; prev != nil {
+			o.Header.GlyphTransform = func(g *Glyph) {
+				prev(g)
+				fn(g)
+			}
+			return
+		}
+		o.Header.GlyphTransform = fn
+	}
+}
+
+// WithAlign pads the bitmaps so every glyph offset is a multiple of n, see
+// Header.Align.
+func WithAlign(n int) Option {
+	return func(o *Options) { o.Header.Align = n }
+}
+
+// WithHeader calls fn on the Header, for the layout options that have no
+// option function of their own, such as Wide or GlyphNames.
+func WithHeader(fn func(*Header)) Option {
+	return func(o *Options) { fn(&o.Header) }
+}
+
+// WriteGFX writes font to w as a C header configured by opts, applied in
+// order. The font itself is not changed. Like Header.WriteTo it fails
+// without writing anything if a value does not fit the glyph table, and
+// it fails if no glyph is left.
+func WriteGFX(w io.Writer, font *Font, opts ...Option) error {
+	var o Options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	h := o.Header
+	switch o.Format {
+	case "", "gfx":
+	case "gfx-blocks":
+		h.Blocks = true
+	case "gfx-cmap":
+		h.CodeMap = true
+	case "gfx-compact":
+		h.Compact = true
+	case "gfx-planes":
+		h.Planes = true
+	case "debug-c":
+		h.DebugArrays = true
+	default:
+		return fmt.Errorf("unknown GFX format %q", o.Format)
+	}
+
+	selected := *font
+	if len(o.Ranges) > 0 {
+		selected.Glyphs = nil
+		for _, g := range font.Glyphs {
+			if o.Ranges.Contains(g.Code) {
+				selected.Glyphs = append(selected.Glyphs, g)
+			}
+		}
+	}
+	h.Font = &selected
+	_, err := h.WriteTo(w)
+	return err
+}
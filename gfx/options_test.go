@@ -0,0 +1,77 @@
+package gfx
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWriteGFXOptions(t *testing.T) {
+	font := parseTest(t, testBDF(testBox(0x41), testBox(0x42), testGlyph("dot", 0x44, 1, 1, "80"), testBox(0x100)))
+	header := func(h *Header) string {
+		t.Helper()
+		if h.Font == nil {
+			h.Font = font
+		}
+		var buf bytes.Buffer
+		if _, err := h.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+	invert := func(g *Glyph) {
+		for i := range g.Bitmap {
+			g.Bitmap[i] = ^g.Bitmap[i]
+		}
+	}
+	ranged := parseTest(t, testBDF(testBox(0x41), testBox(0x42)))
+
+	def := header(&Header{})
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"none", nil, def},
+		{"WithName", []Option{WithName("Foo")}, header(&Header{Name: "Foo"})},
+		{"WithFormat", []Option{WithFormat("gfx-cmap")}, header(&Header{CodeMap: true})},
+		{"WithRange", []Option{WithRange(0x41, 0x42)}, header(&Header{Font: ranged})},
+		{"WithTransform", []Option{WithTransform(invert)}, header(&Header{GlyphTransform: invert})},
+		{"WithAlign", []Option{WithAlign(4)}, header(&Header{Align: 4})},
+		{"WithHeader", []Option{WithHeader(func(h *Header) { h.Wide = true })}, header(&Header{Wide: true})},
+		{
+			"combined",
+			[]Option{WithName("Foo"), WithRange(0x41, 0x41), WithRange(0x42, 0x42), WithFormat("debug-c")},
+			header(&Header{Font: ranged, Name: "Foo", DebugArrays: true}),
+		},
+		// A second transform runs after the first, which undoes it.
+		{"two transforms", []Option{WithTransform(invert), WithTransform(invert)}, def},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteGFX(&buf, font, tt.opts...); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("WriteGFX wrote\n%s\nwant\n%s", &buf, tt.want)
+			}
+		})
+	}
+
+	if got := codes(font); len(got) != 4 || !bytes.Equal(font.Glyphs[0].Bitmap, []byte{0xF0, 0xF0, 0xF0, 0xF0}) {
+		t.Errorf("WriteGFX changed the font: codes %#x, bitmap % X", got, font.Glyphs[0].Bitmap)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteGFX(&buf, font, WithFormat("gfx-svg")); err == nil || !strings.Contains(err.Error(), `"gfx-svg"`) {
+		t.Errorf("WriteGFX error %v for an unknown format", err)
+	}
+	if err := WriteGFX(&buf, font, WithRange(0x200, 0x2FF)); !errors.Is(err, ErrNoGlyphs) {
+		t.Errorf("WriteGFX error %v for an empty range, want ErrNoGlyphs", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("failed WriteGFX calls wrote %d bytes", buf.Len())
+	}
+}
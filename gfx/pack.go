@@ -0,0 +1,150 @@
+package gfx
+
+// PackedBitmap returns the bitmap as GFX renderers read it, see
+// PackBitmap. Rows missing from the bitmap are unset.
+func (g *Glyph) PackedBitmap() []byte {
+	rows := make([][]byte, g.Height)
+	for y := range rows {
+		rows[y] = g.Row(y)
+	}
+	return PackBitmap(rows, g.Width, g.Height)
+}
+
+// PackBitmap packs the MSB-first rows of a width x height bitmap, each of
+// (width+7)/8 bytes as in BDF, into the bit stream of GFX: the rows one
+// after the other without padding, MSB first, so only the last byte may
+// have unused bits, which are zero. Bits past the end of a short row, or
+// of missing rows, are taken as unset.
+func PackBitmap(rows [][]byte, width, height int) []byte {
+	packed := make([]byte, (width*height+7)/8)
+	bit := 0
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if y < len(rows) && x/8 < len(rows[y]) && rows[y][x/8]&(0x80>>(x%8)) != 0 {
+				packed[bit/8] |= 0x80 >> (bit % 8)
+			}
+			bit++
+		}
+	}
+	return packed
+}
+
+// UnpackBitmap is the inverse of PackBitmap: it returns the height rows of
+// (width+7)/8 bytes of a bitmap packed by GFX, with the unused low bits of
+// every row zero. Bits past the end of data are taken as unset.
+func UnpackBitmap(data []byte, width, height int) [][]byte {
+	rows := make([][]byte, height)
+	bit := 0
+	for y := range rows {
+		rows[y] = make([]byte, (width+7)/8)
+		for x := 0; x < width; x++ {
+			if bit/8 < len(data) && data[bit/8]&(0x80>>(bit%8)) != 0 {
+				rows[y][x/8] |= 0x80 >> (x % 8)
+			}
+			bit++
+		}
+	}
+	return rows
+}
+
+// ColumnBitmap returns the bitmap in the page layout of SSD1306-style
+// displays: one byte per column of every band of 8 rows, the top row in
+// the least significant bit, the bands one after the other from the top.
+// When the height is not a multiple of 8 the unused high bits of the last
+// band are zero.
+func (g *Glyph) ColumnBitmap() []byte {
+	pages := (g.Height + 7) / 8
+	columns := make([]byte, pages*g.Width)
+	for y := 0; y < g.Height; y++ {
+		for x := 0; x < g.Width; x++ {
+			if g.Pixel(x, y) {
+				columns[y/8*g.Width+x] |= 1 << (y % 8)
+			}
+		}
+	}
+	return columns
+}
+
+// packedPlanes returns one packed bitmap per bit of the gray level, most
+// significant bit first, one after the other.
+func (g *Glyph) packedPlanes(bpp int) []byte {
+	var planes []byte
+	for bit := bpp - 1; bit >= 0; bit-- {
+		plane := &Glyph{Width: g.Width, Height: g.Height}
+		plane.Bitmap = make([]byte, g.Height*g.BytesPerRow())
+		for y := 0; y < g.Height; y++ {
+			for x := 0; x < g.Width; x++ {
+				if g.Level(x, y, bpp)&(1<<bit) != 0 {
+					plane.SetPixel(x, y)
+				}
+			}
+		}
+		planes = append(planes, plane.PackedBitmap()...)
+	}
+	return planes
+}
+
+// PackGlyphs concatenates the packed glyph bitmaps and returns the blob
+// together with the offset of every glyph inside it. With an align above
+// one every glyph starts at a multiple of align, the gaps filled with
+// zeros.
+//
+// Glyphs without bitmap bytes, such as spaces, take no room and are not
+// aligned. They share the offset of the next glyph that has a bitmap, or of
+// the last one at the end of the font, so the offset always points into
+// the blob.
+func PackGlyphs(glyphs []*Glyph, align int) ([]byte, []int) {
+	return packWith(glyphs, align, (*Glyph).PackedBitmap)
+}
+
+// PackGlyphsPadded is like PackGlyphs but keeps the BDF row padding, as
+// bdf2gfx did before it packed bitmaps continuously. GFX renderers misread
+// such bitmaps; it is kept for comparisons.
+func PackGlyphsPadded(glyphs []*Glyph) ([]byte, []int) {
+	return packWith(glyphs, 1, func(g *Glyph) []byte { return g.Bitmap })
+}
+
+func packWith(glyphs []*Glyph, align int, bitmapOf func(*Glyph) []byte) ([]byte, []int) {
+	var bitmapData []byte
+	offsets := make([]int, len(glyphs))
+	offset := 0
+	placeholder := -1
+	for i, g := range glyphs {
+		bitmap := bitmapOf(g)
+		if len(bitmap) == 0 {
+			offsets[i] = -1
+			continue
+		}
+		if g.placeholder && placeholder >= 0 {
+			offsets[i] = placeholder
+			continue
+		}
+		if align > 1 && offset%align != 0 {
+			pad := align - offset%align
+			bitmapData = append(bitmapData, make([]byte, pad)...)
+			offset += pad
+		}
+		offsets[i] = offset
+		if g.placeholder {
+			placeholder = offset
+		}
+		bitmapData = append(bitmapData, bitmap...)
+		offset += len(bitmap)
+	}
+
+	next := 0
+	for i := len(glyphs) - 1; i >= 0; i-- {
+		if offsets[i] >= 0 {
+			next = offsets[i]
+			break
+		}
+	}
+	for i := len(glyphs) - 1; i >= 0; i-- {
+		if offsets[i] < 0 {
+			offsets[i] = next
+		} else {
+			next = offsets[i]
+		}
+	}
+	return bitmapData, offsets
+}
@@ -0,0 +1,87 @@
+package gfx
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// testRows returns height rows of (width+7)/8 bytes with a pattern that
+// differs from row to row and leaves the unused low bits zero.
+func testRows(width, height int) [][]byte {
+	rows := make([][]byte, height)
+	for y := range rows {
+		rows[y] = make([]byte, (width+7)/8)
+		for x := 0; x < width; x++ {
+			if (x*7+y*3)%5 < 2 {
+				rows[y][x/8] |= 0x80 >> (x % 8)
+			}
+		}
+	}
+	return rows
+}
+
+func TestPackBitmapRoundTrip(t *testing.T) {
+	for width := 1; width <= 17; width++ {
+		for _, height := range []int{1, 3, 8} {
+			t.Run(fmt.Sprintf("%dx%d", width, height), func(t *testing.T) {
+				rows := testRows(width, height)
+				packed := PackBitmap(rows, width, height)
+				if want := (width*height + 7) / 8; len(packed) != want {
+					t.Fatalf("packed %d bytes, want %d", len(packed), want)
+				}
+				if unused := len(packed)*8 - width*height; packed[len(packed)-1]&(1<<unused-1) != 0 {
+					t.Errorf("unused low bits of the last byte are set: %08b", packed[len(packed)-1])
+				}
+				got := UnpackBitmap(packed, width, height)
+				if !slices.EqualFunc(got, rows, bytes.Equal) {
+					t.Errorf("UnpackBitmap(PackBitmap(rows)) = %X, want %X", got, rows)
+				}
+			})
+		}
+	}
+}
+
+func TestPackedBitmapContinuous(t *testing.T) {
+	// 3x3 ring: the rows follow each other without padding.
+	font := parseTest(t, testBDF(testGlyph("ring", 0x41, 3, 3, "E0", "A0", "E0")))
+	if got, want := font.Glyphs[0].PackedBitmap(), []byte{0xF7, 0x80}; !bytes.Equal(got, want) {
+		t.Errorf("PackedBitmap() = %X, want %X", got, want)
+	}
+	if blob, _ := PackGlyphsPadded(font.Glyphs); !bytes.Equal(blob, []byte{0xE0, 0xA0, 0xE0}) {
+		t.Errorf("PackGlyphsPadded blob %X, want E0A0E0", blob)
+	}
+}
+
+func TestPackedBitmapShortBitmap(t *testing.T) {
+	// Two of four rows: the missing rows are unset instead of a panic.
+	g := &Glyph{Width: 8, Height: 4, Bitmap: []byte{0xFF, 0x81}}
+	if got, want := g.PackedBitmap(), []byte{0xFF, 0x81, 0x00, 0x00}; !bytes.Equal(got, want) {
+		t.Errorf("PackedBitmap() = %X, want %X", got, want)
+	}
+	if g.Pixel(0, 3) {
+		t.Error("Pixel(0, 3) of a missing row is set")
+	}
+	g.SetPixel(0, 3)
+	if !bytes.Equal(g.Row(3), []byte{0}) {
+		t.Errorf("Row(3) = %X, want 00", g.Row(3))
+	}
+
+	var buf bytes.Buffer
+	writeDebugArrays(&buf, "Test", []*Glyph{g})
+	if !bytes.Contains(buf.Bytes(), []byte("{ 0x00 }")) {
+		t.Errorf("debug arrays do not show the missing rows as zero:\n%s", buf.String())
+	}
+}
+
+func TestColumnBitmap(t *testing.T) {
+	// A diagonal in the first page and a bar and a dot in the second, of
+	// which only two rows are used.
+	font := parseTest(t, testBDF(testGlyph("A", 0x41, 3, 10, "80", "40", "20", "00", "00", "00", "00", "00", "E0", "80")))
+	got := font.Glyphs[0].ColumnBitmap()
+	want := []byte{0x01, 0x02, 0x04, 0x03, 0x01, 0x01}
+	if !bytes.Equal(got, want) {
+		t.Errorf("ColumnBitmap() = % X, want % X", got, want)
+	}
+}
@@ -0,0 +1,471 @@
+package gfx
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"slices"
+	"strconv"
+)
+
+// pcfMagic starts every PCF file, the compiled form of BDF fonts that X11
+// and many Linux distributions ship.
+const pcfMagic = "\x01fcp"
+
+// Types of the tables of a PCF file.
+const (
+	pcfProperties      = 1 << 0
+	pcfAccelerators    = 1 << 1
+	pcfMetrics         = 1 << 2
+	pcfBitmaps         = 1 << 3
+	pcfBDFEncodings    = 1 << 5
+	pcfGlyphNames      = 1 << 7
+	pcfBDFAccelerators = 1 << 8
+)
+
+// Bits of the format word of a PCF table.
+const (
+	pcfCompressedMetrics = 0x100
+	pcfGlyphPadMask      = 3 << 0
+	pcfByteMSB           = 1 << 2
+	pcfBitMSB            = 1 << 3
+	pcfScanUnitMask      = 3 << 4
+)
+
+var ErrNoPCFTable = errors.New("missing PCF table")
+
+// IsPCF reports whether data, the start of a file, is the magic of a PCF
+// font.
+func IsPCF(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(pcfMagic))
+}
+
+// ParseFont reads a PCF font if the input starts with the PCF magic, and a
+// BDF font otherwise.
+func (p *Parser) ParseFont(r io.Reader) (*Font, error) {
+	in := bufio.NewReader(r)
+	magic, _ := in.Peek(len(pcfMagic))
+	if IsPCF(magic) {
+		return p.ParsePCF(in)
+	}
+	return p.Parse(in)
+}
+
+// pcfTable reads the values of one table of a PCF file in the byte order
+// its format gives. The first read past the end of the table sets err, and
+// all reads after it return 0.
+type pcfTable struct {
+	name   string
+	data   []byte
+	format uint32
+	pos    int
+	err    error
+}
+
+func (t *pcfTable) bytes(n int) []byte {
+	if t.err == nil && (n < 0 || n > len(t.data)-t.pos) {
+		t.err = fmt.Errorf("PCF %s table is truncated", t.name)
+	}
+	if t.err != nil {
+		return nil
+	}
+	b := t.data[t.pos : t.pos+n]
+	t.pos += n
+	return b
+}
+
+func (t *pcfTable) order() binary.ByteOrder {
+	if t.format&pcfByteMSB != 0 {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
+func (t *pcfTable) uint8() int {
+	if b := t.bytes(1); b != nil {
+		return int(b[0])
+	}
+	return 0
+}
+
+func (t *pcfTable) uint16() int {
+	if b := t.bytes(2); b != nil {
+		return int(t.order().Uint16(b))
+	}
+	return 0
+}
+
+func (t *pcfTable) int16() int {
+	return int(int16(t.uint16()))
+}
+
+func (t *pcfTable) int32() int {
+	if b := t.bytes(4); b != nil {
+		return int(int32(t.order().Uint32(b)))
+	}
+	return 0
+}
+
+// pcfString returns the NUL terminated string at offset in a string table.
+func pcfString(strings []byte, offset int) string {
+	if offset < 0 || offset >= len(strings) {
+		return ""
+	}
+	s := strings[offset:]
+	if end := bytes.IndexByte(s, 0); end >= 0 {
+		s = s[:end]
+	}
+	return string(s)
+}
+
+// pcfMetric is the metrics record of a PCF glyph, with the bearings
+// relative to the origin and y growing upwards.
+type pcfMetric struct {
+	left, right, width, ascent, descent, attributes int
+}
+
+func (t *pcfTable) metric(compressed bool) pcfMetric {
+	if compressed {
+		return pcfMetric{t.uint8() - 0x80, t.uint8() - 0x80, t.uint8() - 0x80, t.uint8() - 0x80, t.uint8() - 0x80, 0}
+	}
+	return pcfMetric{t.int16(), t.int16(), t.int16(), t.int16(), t.int16(), t.uint16()}
+}
+
+// ParsePCF reads a PCF font into the same structures as a BDF font: the
+// glyph metrics become BBX and DWIDTH values, the accelerator table gives
+// the ascent, descent and bounding box, and the properties are kept like a
+// STARTPROPERTIES block. Glyphs are named from the glyph names table and
+// left unnamed without one. SkipBadGlyphs, MetricsOnly, DiscardBitmaps and
+// Warn apply as for BDF; the file records its own bit and byte order, so
+// LSBFirst and ReverseBytes do not.
+func (p *Parser) ParsePCF(r io.Reader) (*Font, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if !IsPCF(data) {
+		return nil, errors.New("not a PCF file")
+	}
+
+	toc := &pcfTable{name: "header", data: data, pos: len(pcfMagic)}
+	count := toc.int32()
+	if count < 0 || count > len(data)/16 {
+		return nil, fmt.Errorf("bad PCF table count %d", count)
+	}
+	type entry struct{ format, size, offset int }
+	entries := map[int]entry{}
+	for range count {
+		kind := toc.int32()
+		entries[kind] = entry{toc.int32(), toc.int32(), toc.int32()}
+	}
+	if toc.err != nil {
+		return nil, toc.err
+	}
+	table := func(kind int, name string) (*pcfTable, error) {
+		e, ok := entries[kind]
+		if !ok {
+			return nil, nil
+		}
+		if e.offset < 0 || e.size < 4 || e.size > len(data)-e.offset {
+			return nil, fmt.Errorf("PCF %s table is outside the file", name)
+		}
+		t := &pcfTable{name: name, data: data[e.offset : e.offset+e.size]}
+		// The format word itself is always least significant byte first.
+		t.format = binary.LittleEndian.Uint32(t.data)
+		t.pos = 4
+		if int(t.format) != e.format {
+			return nil, fmt.Errorf("PCF %s table has format 0x%X, the table of contents says 0x%X", name, t.format, e.format)
+		}
+		return t, nil
+	}
+
+	font := &Font{DefaultChar: -1}
+	if t, err := table(pcfProperties, "properties"); err != nil {
+		return nil, err
+	} else if t != nil {
+		if err := pcfReadProperties(t, font); err != nil {
+			return nil, err
+		}
+	}
+
+	t, err := table(pcfMetrics, "metrics")
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, fmt.Errorf("%w: metrics", ErrNoPCFTable)
+	}
+	compressed := t.format&pcfCompressedMetrics != 0
+	var n int
+	if compressed {
+		n = t.uint16()
+	} else {
+		n = t.int32()
+	}
+	if n < 0 || n > len(t.data) {
+		return nil, fmt.Errorf("bad PCF glyph count %d", n)
+	}
+	metrics := make([]pcfMetric, n)
+	for i := range metrics {
+		metrics[i] = t.metric(compressed)
+	}
+	if t.err != nil {
+		return nil, t.err
+	}
+
+	hasAccelerators := false
+	for _, kind := range []int{pcfBDFAccelerators, pcfAccelerators} {
+		t, err := table(kind, "accelerators")
+		if err != nil {
+			return nil, err
+		}
+		if t == nil {
+			continue
+		}
+		// Flags such as constant width, which the metrics already show.
+		t.bytes(8)
+		font.Ascent = t.int32()
+		font.Descent = t.int32()
+		t.int32() // maximum overlap
+		minBounds, maxBounds := t.metric(false), t.metric(false)
+		if t.err != nil {
+			return nil, t.err
+		}
+		font.BoundingBox = &BBX{
+			Width:   maxBounds.right - minBounds.left,
+			Height:  maxBounds.ascent + maxBounds.descent,
+			XOffset: minBounds.left,
+			YOffset: -maxBounds.descent,
+		}
+		hasAccelerators = true
+		break
+	}
+	if !hasAccelerators {
+		ascent, okAscent := font.Properties["FONT_ASCENT"]
+		descent, okDescent := font.Properties["FONT_DESCENT"]
+		if !okAscent || !okDescent {
+			return nil, fmt.Errorf("%w: accelerators, and no FONT_ASCENT and FONT_DESCENT properties", ErrNoPCFTable)
+		}
+		font.Ascent, _ = strconv.Atoi(ascent)
+		font.Descent, _ = strconv.Atoi(descent)
+	}
+
+	var names []string
+	if t, err := table(pcfGlyphNames, "glyph names"); err != nil {
+		return nil, err
+	} else if t != nil {
+		n := t.int32()
+		if n < 0 || n > len(t.data)/4 {
+			return nil, fmt.Errorf("bad PCF glyph name count %d", n)
+		}
+		offsets := make([]int, n)
+		for i := range offsets {
+			offsets[i] = t.int32()
+		}
+		strings := t.bytes(t.int32())
+		if t.err != nil {
+			return nil, t.err
+		}
+		for _, offset := range offsets {
+			names = append(names, pcfString(strings, offset))
+		}
+	}
+
+	var bitmaps []byte
+	var offsets []int
+	var pad int
+	if !p.MetricsOnly {
+		if bitmaps, offsets, pad, err = pcfReadBitmaps(table(pcfBitmaps, "bitmaps")); err != nil {
+			return nil, err
+		}
+		if len(offsets) != len(metrics) {
+			return nil, fmt.Errorf("PCF bitmaps table has %d glyphs, the metrics table %d", len(offsets), len(metrics))
+		}
+	}
+
+	t, err = table(pcfBDFEncodings, "encodings")
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, fmt.Errorf("%w: encodings", ErrNoPCFTable)
+	}
+	min2, max2, min1, max1 := t.int16(), t.int16(), t.int16(), t.int16()
+	if defaultChar := t.int16(); defaultChar != -1 {
+		if _, ok := font.Properties["DEFAULT_CHAR"]; !ok {
+			font.DefaultChar = defaultChar & 0xFFFF
+		}
+	}
+	if t.err != nil {
+		return nil, t.err
+	}
+	if min2 < 0 || max2 > 0xFF || min1 < 0 || max1 > 0xFF {
+		return nil, fmt.Errorf("bad PCF encoding range %d-%d, %d-%d", min1, max1, min2, max2)
+	}
+
+	var glyphs []*Glyph
+	for b1 := min1; b1 <= max1; b1++ {
+		for b2 := min2; b2 <= max2; b2++ {
+			index := t.uint16()
+			if t.err != nil {
+				return nil, t.err
+			}
+			if index == 0xFFFF {
+				continue
+			}
+			g := &Glyph{Code: b1<<8 | b2}
+			if index < len(names) {
+				g.Name = names[index]
+			}
+			if index >= len(metrics) {
+				err := &GlyphError{Code: g.Code, Name: g.Name, Err: fmt.Errorf("glyph index %d, the font has %d glyphs", index, len(metrics))}
+				if !p.SkipBadGlyphs {
+					return nil, err
+				}
+				err.Skipped = true
+				p.warn(err)
+				continue
+			}
+			m := metrics[index]
+			g.Width = m.right - m.left
+			g.Height = m.ascent + m.descent
+			g.XOffset = m.left
+			g.YOffset = -m.descent
+			g.dwidth, g.hasDWidth = m.width, true
+			g.XAdvance = m.width
+			if m.attributes != 0 {
+				g.Attributes = fmt.Sprintf("%04X", m.attributes)
+			}
+			if p.MetricsOnly {
+				g.Bitmap = []byte{}
+				glyphs = append(glyphs, g)
+				continue
+			}
+			// Rows are padded to whole pad bytes in the file.
+			stride := (g.Width + 8*pad - 1) / (8 * pad) * pad
+			offset := offsets[index]
+			if g.Width < 0 || g.Height < 0 || offset < 0 || stride*g.Height > len(bitmaps)-offset {
+				err := &GlyphError{Code: g.Code, Name: g.Name, Err: fmt.Errorf("%w: the %dx%d bitmap at offset %d is outside the %d bytes of bitmap data", ErrBitmapSize, g.Width, g.Height, offset, len(bitmaps))}
+				if !p.SkipBadGlyphs {
+					return nil, err
+				}
+				err.Skipped = true
+				p.warn(err)
+				continue
+			}
+			if !p.DiscardBitmaps {
+				// A new slice for every code, as several codes may share
+				// a bitmap and transforms change them in place.
+				g.Bitmap = []byte{}
+				for y := range g.Height {
+					row := bitmaps[offset+y*stride:]
+					g.Bitmap = append(g.Bitmap, row[:(g.Width+7)/8]...)
+				}
+			}
+			glyphs = append(glyphs, g)
+		}
+	}
+	font.Glyphs = glyphs
+	return font, nil
+}
+
+// pcfReadProperties keeps the properties of a PCF file like a BDF
+// STARTPROPERTIES block, and sets the font fields BDF takes from them.
+func pcfReadProperties(t *pcfTable, font *Font) error {
+	n := t.int32()
+	if n < 0 || n > len(t.data)/9 {
+		return fmt.Errorf("bad PCF property count %d", n)
+	}
+	type property struct {
+		name     int
+		isString bool
+		value    int
+	}
+	properties := make([]property, n)
+	for i := range properties {
+		properties[i] = property{t.int32(), t.uint8() != 0, t.int32()}
+	}
+	if n%4 != 0 {
+		t.bytes(4 - n%4)
+	}
+	strings := t.bytes(t.int32())
+	if t.err != nil {
+		return t.err
+	}
+
+	font.Properties = map[string]string{}
+	for _, prop := range properties {
+		name := pcfString(strings, prop.name)
+		value := strconv.Itoa(prop.value)
+		if prop.isString {
+			value = pcfString(strings, prop.value)
+		}
+		font.Properties[name] = value
+		switch name {
+		case "FONT":
+			font.XLFD = value
+		case "CHARSET_REGISTRY":
+			font.CharsetRegistry = value
+		case "CHARSET_ENCODING":
+			font.CharsetEncoding = value
+		case "RAW_ASCENT":
+			font.RawAscent = prop.value
+		case "RAW_DESCENT":
+			font.RawDescent = prop.value
+		case "DEFAULT_CHAR":
+			font.DefaultChar = prop.value
+		case "CAP_HEIGHT":
+			font.CapHeight = prop.value
+		case "X_HEIGHT":
+			font.XHeight = prop.value
+		}
+	}
+	return nil
+}
+
+// pcfReadBitmaps returns the bitmap data of a PCF bitmaps table, most
+// significant bit first, with the offset of every glyph in it and the
+// number of bytes its rows are padded to.
+func pcfReadBitmaps(t *pcfTable, err error) ([]byte, []int, int, error) {
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if t == nil {
+		return nil, nil, 0, fmt.Errorf("%w: bitmaps", ErrNoPCFTable)
+	}
+	n := t.int32()
+	if n < 0 || n > len(t.data)/4 {
+		return nil, nil, 0, fmt.Errorf("bad PCF bitmap count %d", n)
+	}
+	offsets := make([]int, n)
+	for i := range offsets {
+		offsets[i] = t.int32()
+	}
+	var sizes [4]int
+	for i := range sizes {
+		sizes[i] = t.int32()
+	}
+	pad := t.format & pcfGlyphPadMask
+	data := t.bytes(sizes[pad])
+	if t.err != nil {
+		return nil, nil, 0, t.err
+	}
+
+	// Bring the data into MSB first bit order with the bytes of every scan
+	// unit in reading order, as the X server does.
+	if t.format&pcfBitMSB == 0 {
+		for i, b := range data {
+			data[i] = bits.Reverse8(b)
+		}
+	}
+	if unit := 1 << (t.format & pcfScanUnitMask >> 4); unit > 1 && (t.format&pcfByteMSB == 0) != (t.format&pcfBitMSB == 0) {
+		for i := 0; i+unit <= len(data); i += unit {
+			slices.Reverse(data[i : i+unit])
+		}
+	}
+	return data, offsets, 1 << pad, nil
+}
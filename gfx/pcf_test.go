@@ -0,0 +1,300 @@
+package gfx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/bits"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// pcfLayout is how testPCF lays out a font: the format bits of its bitmaps
+// and whether the metrics are compressed, and the optional tables it
+// writes.
+type pcfLayout struct {
+	format       uint32
+	compressed   bool
+	accelerators bool
+	names        bool
+}
+
+// pcfWriter appends the values of one table in the byte order of its
+// format, after the format word itself.
+type pcfWriter struct {
+	format uint32
+	buf    []byte
+}
+
+func newPCFWriter(format uint32) *pcfWriter {
+	return &pcfWriter{format: format, buf: binary.LittleEndian.AppendUint32(nil, format)}
+}
+
+func (w *pcfWriter) order() binary.AppendByteOrder {
+	if w.format&pcfByteMSB != 0 {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
+func (w *pcfWriter) u8(v int)  { w.buf = append(w.buf, byte(v)) }
+func (w *pcfWriter) u16(v int) { w.buf = w.order().AppendUint16(w.buf, uint16(v)) }
+func (w *pcfWriter) u32(v int) { w.buf = w.order().AppendUint32(w.buf, uint32(v)) }
+
+func (w *pcfWriter) metric(g *Glyph, compressed bool) {
+	m := []int{g.XOffset, g.XOffset + g.Width, g.XAdvance, g.YOffset + g.Height, -g.YOffset}
+	for _, v := range m {
+		if compressed {
+			w.u8(v + 0x80)
+		} else {
+			w.u16(v)
+		}
+	}
+	if !compressed {
+		w.u16(0)
+	}
+}
+
+// testPCF compiles a parsed BDF font into a PCF file, as bdftopcf would.
+func testPCF(font *Font, layout pcfLayout) []byte {
+	type table struct {
+		kind int
+		w    *pcfWriter
+	}
+	var tables []table
+
+	props := newPCFWriter(0)
+	names := []string{"FONT"}
+	for name := range font.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	var strs []byte
+	str := func(s string) int {
+		offset := len(strs)
+		strs = append(append(strs, s...), 0)
+		return offset
+	}
+	props.u32(len(names))
+	for _, name := range names {
+		props.u32(str(name))
+		value := font.XLFD
+		if name != "FONT" {
+			value = font.Properties[name]
+		}
+		if n, err := strconv.Atoi(value); err == nil {
+			props.u8(0)
+			props.u32(n)
+		} else {
+			props.u8(1)
+			props.u32(str(value))
+		}
+	}
+	if len(names)%4 != 0 {
+		props.buf = append(props.buf, make([]byte, 4-len(names)%4)...)
+	}
+	props.u32(len(strs))
+	props.buf = append(props.buf, strs...)
+	tables = append(tables, table{pcfProperties, props})
+
+	if layout.accelerators {
+		accel := newPCFWriter(0)
+		accel.buf = append(accel.buf, make([]byte, 8)...)
+		accel.u32(font.Ascent)
+		accel.u32(font.Descent)
+		accel.u32(0)
+		bb := font.BoundingBox
+		accel.metric(&Glyph{XOffset: bb.XOffset, Width: 0, YOffset: 0}, false)
+		accel.metric(&Glyph{XOffset: 0, Width: bb.XOffset + bb.Width, YOffset: bb.YOffset, Height: bb.Height}, false)
+		tables = append(tables, table{pcfBDFAccelerators, accel})
+	}
+
+	var metricsFormat uint32
+	if layout.compressed {
+		metricsFormat = pcfCompressedMetrics
+	}
+	metrics := newPCFWriter(metricsFormat)
+	if layout.compressed {
+		metrics.u16(len(font.Glyphs))
+	} else {
+		metrics.u32(len(font.Glyphs))
+	}
+	for _, g := range font.Glyphs {
+		metrics.metric(g, layout.compressed)
+	}
+	tables = append(tables, table{pcfMetrics, metrics})
+
+	bitmaps := newPCFWriter(layout.format)
+	pad := 1 << (layout.format & pcfGlyphPadMask)
+	unit := 1 << (layout.format & pcfScanUnitMask >> 4)
+	var data []byte
+	bitmaps.u32(len(font.Glyphs))
+	for _, g := range font.Glyphs {
+		bitmaps.u32(len(data))
+		stride := (g.Width + 8*pad - 1) / (8 * pad) * pad
+		// Only the rows the bitmap has, so tests can write short ones.
+		rowBytes := (g.Width + 7) / 8
+		for y := 0; y < g.Height && (y+1)*rowBytes <= len(g.Bitmap); y++ {
+			row := make([]byte, stride)
+			copy(row, g.Bitmap[y*rowBytes:])
+			data = append(data, row...)
+		}
+	}
+	if unit > 1 && (layout.format&pcfByteMSB == 0) != (layout.format&pcfBitMSB == 0) {
+		for i := 0; i+unit <= len(data); i += unit {
+			slices.Reverse(data[i : i+unit])
+		}
+	}
+	if layout.format&pcfBitMSB == 0 {
+		for i, b := range data {
+			data[i] = bits.Reverse8(b)
+		}
+	}
+	// Only the size of the layout in use matters to readers.
+	for i := range 4 {
+		if i == int(layout.format&pcfGlyphPadMask) {
+			bitmaps.u32(len(data))
+		} else {
+			bitmaps.u32(0)
+		}
+	}
+	bitmaps.buf = append(bitmaps.buf, data...)
+	tables = append(tables, table{pcfBitmaps, bitmaps})
+
+	encodings := newPCFWriter(0)
+	min1, max1, min2, max2 := 0xFF, 0, 0xFF, 0
+	for _, g := range font.Glyphs {
+		min1, max1 = min(min1, g.Code>>8), max(max1, g.Code>>8)
+		min2, max2 = min(min2, g.Code&0xFF), max(max2, g.Code&0xFF)
+	}
+	for _, v := range []int{min2, max2, min1, max1, 0xFFFF} {
+		encodings.u16(v)
+	}
+	for b1 := min1; b1 <= max1; b1++ {
+		for b2 := min2; b2 <= max2; b2++ {
+			index := slices.IndexFunc(font.Glyphs, func(g *Glyph) bool { return g.Code == b1<<8|b2 })
+			encodings.u16(index)
+		}
+	}
+	tables = append(tables, table{pcfBDFEncodings, encodings})
+
+	if layout.names {
+		glyphNames := newPCFWriter(0)
+		strs = nil
+		glyphNames.u32(len(font.Glyphs))
+		for _, g := range font.Glyphs {
+			glyphNames.u32(str(g.Name))
+		}
+		glyphNames.u32(len(strs))
+		glyphNames.buf = append(glyphNames.buf, strs...)
+		tables = append(tables, table{pcfGlyphNames, glyphNames})
+	}
+
+	out := []byte(pcfMagic)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(tables)))
+	offset := len(out) + 16*len(tables)
+	for _, t := range tables {
+		for _, v := range []int{t.kind, int(t.w.format), len(t.w.buf), offset} {
+			out = binary.LittleEndian.AppendUint32(out, uint32(v))
+		}
+		offset += len(t.w.buf)
+	}
+	for _, t := range tables {
+		out = append(out, t.w.buf...)
+	}
+	return out
+}
+
+// pcfTestFont has glyphs with negative offsets, rows wider than a byte
+// and a code past 0xFF.
+var pcfTestFont = testBDF(
+	testGlyph("space", 0x20, 0, 0),
+	testBox(0x41),
+	"STARTCHAR g\nENCODING 103\nDWIDTH 5 0\nBBX 3 5 1 -2\nBITMAP\n60\nA0\n60\n20\nC0\nENDCHAR\n",
+	testGlyph("wide", 0x100, 12, 2, "FFF0", "8010"),
+)
+
+func TestParsePCF(t *testing.T) {
+	want := parseTest(t, pcfTestFont)
+	for _, layout := range []pcfLayout{
+		{format: pcfByteMSB | pcfBitMSB, accelerators: true, names: true},
+		{format: 2 | 2<<4, compressed: true},
+		{format: 2 | 2<<4 | pcfBitMSB, names: true},
+		{format: 3 | 1<<4 | pcfByteMSB, accelerators: true},
+		{format: 1, compressed: true, names: true},
+	} {
+		font, err := (&Parser{}).ParseFont(bytes.NewReader(testPCF(want, layout)))
+		if err != nil {
+			t.Fatalf("format 0x%X: %v", layout.format, err)
+		}
+		if font.XLFD != want.XLFD || font.Ascent != 6 || font.Descent != 2 {
+			t.Errorf("format 0x%X: font %q with ascent %d and descent %d, want %q, 6 and 2", layout.format, font.XLFD, font.Ascent, font.Descent, want.XLFD)
+		}
+		if layout.accelerators && *font.BoundingBox != *want.BoundingBox {
+			t.Errorf("format 0x%X: bounding box %+v, want %+v", layout.format, *font.BoundingBox, *want.BoundingBox)
+		}
+		if !slices.Equal(codes(font), codes(want)) {
+			t.Fatalf("format 0x%X: codes %v, want %v", layout.format, codes(font), codes(want))
+		}
+		for i, g := range font.Glyphs {
+			w := want.Glyphs[i]
+			if !layout.names && g.Name != "" {
+				t.Errorf("format 0x%X: 0x%04X named %q without a glyph names table", layout.format, g.Code, g.Name)
+			}
+			if layout.names && g.Name != w.Name {
+				t.Errorf("format 0x%X: 0x%04X named %q, want %q", layout.format, g.Code, g.Name, w.Name)
+			}
+			if g.Width != w.Width || g.Height != w.Height || g.XOffset != w.XOffset || g.YOffset != w.YOffset || g.XAdvance != w.XAdvance {
+				t.Errorf("format 0x%X: 0x%04X metrics %+v, want %+v", layout.format, g.Code, *g, *w)
+			}
+			if !bytes.Equal(g.Bitmap, w.Bitmap) {
+				t.Errorf("format 0x%X: 0x%04X bitmap % X, want % X", layout.format, g.Code, g.Bitmap, w.Bitmap)
+			}
+		}
+	}
+}
+
+func TestParsePCFErrors(t *testing.T) {
+	font := parseTest(t, pcfTestFont)
+	data := testPCF(font, pcfLayout{format: 1, names: true})
+
+	if _, err := (&Parser{}).ParsePCF(bytes.NewReader(data[:len(data)-1])); err == nil {
+		t.Error("truncated file parsed without error")
+	}
+	if _, err := (&Parser{}).ParsePCF(bytes.NewReader([]byte(pcfTestFont))); err == nil {
+		t.Error("BDF input parsed as PCF without error")
+	}
+
+	// A bitmap offset past the data is a glyph error without a line.
+	font.Glyphs[1].Height = 100
+	huge := testPCF(font, pcfLayout{format: 1, names: true})
+	_, err := (&Parser{}).ParsePCF(bytes.NewReader(huge))
+	var glyphErr *GlyphError
+	if !errors.As(err, &glyphErr) || !errors.Is(err, ErrBitmapSize) {
+		t.Fatalf("error %v, want a GlyphError for the bitmap size", err)
+	}
+	if want := "glyph 0x0041 (u0041): "; !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("error %q, want it to start with %q", err, want)
+	}
+
+	var warnings []error
+	p := &Parser{SkipBadGlyphs: true, Warn: func(err error) { warnings = append(warnings, err) }}
+	skipped, err := p.ParsePCF(bytes.NewReader(huge))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{0x20, 0x67, 0x100}; !slices.Equal(codes(skipped), want) || len(warnings) != 1 {
+		t.Errorf("codes %v with %d warnings, want %v and 1", codes(skipped), len(warnings), want)
+	}
+
+	// Without accelerators or the ascent and descent properties the
+	// vertical metrics are unknown.
+	font = parseTest(t, pcfTestFont)
+	delete(font.Properties, "FONT_ASCENT")
+	if _, err := (&Parser{}).ParsePCF(bytes.NewReader(testPCF(font, pcfLayout{format: 1}))); !errors.Is(err, ErrNoPCFTable) {
+		t.Errorf("error %v, want ErrNoPCFTable", err)
+	}
+}
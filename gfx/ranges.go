@@ -0,0 +1,91 @@
+package gfx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CodeRange is an inclusive range of codepoints.
+type CodeRange struct {
+	First, Last int
+}
+
+// CodeRanges is a set of codepoints given as ranges.
+type CodeRanges []CodeRange
+
+// ParseRanges parses a comma separated list of codepoints and inclusive
+// ranges, such as "0x20-0x7E,0xB0". Numbers may be decimal or use a 0x
+// prefix.
+func ParseRanges(s string) (CodeRanges, error) {
+	var ranges CodeRanges
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		lo, hi, isRange := strings.Cut(part, "-")
+		first, err := strconv.ParseInt(strings.TrimSpace(lo), 0, 32)
+		if err != nil {
+			return nil, fmt.Errorf("bad codepoint range %q: %v", part, err)
+		}
+		last := first
+		if isRange {
+			last, err = strconv.ParseInt(strings.TrimSpace(hi), 0, 32)
+			if err != nil {
+				return nil, fmt.Errorf("bad codepoint range %q: %v", part, err)
+			}
+		}
+		if last < first {
+			return nil, fmt.Errorf("bad codepoint range %q: end before start", part)
+		}
+		ranges = append(ranges, CodeRange{int(first), int(last)})
+	}
+	return ranges, nil
+}
+
+// Contains reports whether code falls into one of the ranges.
+func (rs CodeRanges) Contains(code int) bool {
+	for _, r := range rs {
+		if code >= r.First && code <= r.Last {
+			return true
+		}
+	}
+	return false
+}
+
+// String formats the ranges the way ParseRanges reads them.
+func (rs CodeRanges) String() string {
+	var parts []string
+	for _, r := range rs {
+		if r.First == r.Last {
+			parts = append(parts, fmt.Sprintf("0x%04X", r.First))
+		} else {
+			parts = append(parts, fmt.Sprintf("0x%04X-0x%04X", r.First, r.Last))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// Missing returns the codepoints of rs that have no glyph in the font,
+// merged into ranges.
+func (f *Font) Missing(rs CodeRanges) CodeRanges {
+	has := map[int]bool{}
+	for _, g := range f.Glyphs {
+		has[g.Code] = true
+	}
+	var missing CodeRanges
+	for _, r := range rs {
+		for code := r.First; code <= r.Last; code++ {
+			if has[code] {
+				continue
+			}
+			if n := len(missing); n > 0 && missing[n-1].Last == code-1 {
+				missing[n-1].Last = code
+				continue
+			}
+			missing = append(missing, CodeRange{code, code})
+		}
+	}
+	return missing
+}
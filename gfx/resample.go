@@ -0,0 +1,73 @@
+package gfx
+
+import "fmt"
+
+// maxResampledPixels bounds the bitmap of a single resampled glyph, so a
+// typo in the target height cannot exhaust memory.
+const maxResampledPixels = 1 << 24
+
+// Resample scales the font with nearest-neighbor sampling so that ascent
+// plus descent becomes height pixels. Widths, offsets and advances are
+// scaled by the same ratio.
+func (f *Font) Resample(height int) error {
+	from := f.Ascent + f.Descent
+	if from <= 0 {
+		return fmt.Errorf("font has no height to resample from")
+	}
+	if height <= 0 {
+		return fmt.Errorf("invalid target height %d", height)
+	}
+	num, den := height, from
+	scale := func(v int) int { return floorDiv(2*v*num+den, 2*den) }
+
+	for _, g := range f.Glyphs {
+		x0, x1 := scale(g.XOffset), scale(g.XOffset+g.Width)
+		y0, y1 := scale(g.YOffset), scale(g.YOffset+g.Height)
+		if g.Width == 0 || g.Height == 0 {
+			x1, y1 = x0, y0
+		}
+		if int64(x1-x0)*int64(y1-y0) > maxResampledPixels {
+			return fmt.Errorf("glyph 0x%04X: resampled size %dx%d is too large", g.Code, x1-x0, y1-y0)
+		}
+		n := &Glyph{Width: x1 - x0, Height: y1 - y0}
+		n.Bitmap = make([]byte, n.Height*n.BytesPerRow())
+		if g.Gray != nil {
+			n.Gray = make([]byte, n.Width*n.Height)
+		}
+		top := g.YOffset + g.Height
+		for y := 0; y < n.Height; y++ {
+			// Sample at the pixel centers, in BDF coordinates.
+			sy := floorDiv((2*(y1-y)-1)*den, 2*num)
+			for x := 0; x < n.Width; x++ {
+				sx := floorDiv((2*(x0+x)+1)*den, 2*num)
+				if g.Pixel(sx-g.XOffset, top-1-sy) {
+					n.SetPixel(x, y)
+				}
+				if n.Gray != nil {
+					n.Gray[y*n.Width+x] = byte(g.Level(sx-g.XOffset, top-1-sy, 8))
+				}
+			}
+		}
+		g.Width, g.Height, g.Bitmap, g.Gray = n.Width, n.Height, n.Bitmap, n.Gray
+		g.XOffset, g.YOffset = x0, y0
+		g.XAdvance = scale(g.XAdvance)
+	}
+	f.Ascent = scale(f.Ascent)
+	f.Descent = height - f.Ascent
+	if f.CapHeight > 0 {
+		f.CapHeight = scale(f.CapHeight)
+	}
+	if f.XHeight > 0 {
+		f.XHeight = scale(f.XHeight)
+	}
+	return nil
+}
+
+// floorDiv divides rounding towards negative infinity.
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
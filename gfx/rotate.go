@@ -0,0 +1,66 @@
+package gfx
+
+import "fmt"
+
+// Rotate turns the glyph clockwise about its origin by 90, 180 or 270
+// degrees, moving the bitmap, width, height and offsets with it. The
+// advance is left alone.
+func (g *Glyph) Rotate(degrees int) error {
+	w, h := g.Width, g.Height
+	var width, height, xOffset, yOffset int
+	var to func(x, y int) (int, int)
+	switch degrees {
+	case 90:
+		width, height = h, w
+		xOffset, yOffset = g.YOffset, -(g.XOffset + w)
+		to = func(x, y int) (int, int) { return h - 1 - y, x }
+	case 180:
+		width, height = w, h
+		xOffset, yOffset = -(g.XOffset + w), -(g.YOffset + h)
+		to = func(x, y int) (int, int) { return w - 1 - x, h - 1 - y }
+	case 270:
+		width, height = h, w
+		xOffset, yOffset = -(g.YOffset + h), g.XOffset
+		to = func(x, y int) (int, int) { return y, w - 1 - x }
+	default:
+		return fmt.Errorf("cannot rotate by %d degrees, only by 90, 180 or 270", degrees)
+	}
+
+	n := &Glyph{Width: width, Height: height}
+	n.Bitmap = make([]byte, height*n.BytesPerRow())
+	if len(g.Gray) == w*h {
+		n.Gray = make([]byte, width*height)
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			nx, ny := to(x, y)
+			if g.Pixel(x, y) {
+				n.SetPixel(nx, ny)
+			}
+			if n.Gray != nil {
+				n.Gray[ny*width+nx] = g.Gray[y*w+x]
+			}
+		}
+	}
+	g.Width, g.Height, g.Bitmap, g.Gray = width, height, n.Bitmap, n.Gray
+	g.XOffset, g.YOffset = xOffset, yOffset
+	return nil
+}
+
+// Rotate rotates every glyph, see Glyph.Rotate. By 180 degrees ascent and
+// descent swap; by 90 or 270 degrees they are set to cover the rotated ink.
+func (f *Font) Rotate(degrees int) error {
+	for _, g := range f.Glyphs {
+		if err := g.Rotate(degrees); err != nil {
+			return err
+		}
+	}
+	if degrees == 180 {
+		f.Ascent, f.Descent = f.Descent, f.Ascent
+	} else {
+		_, _, minY, maxY := f.InkBounds()
+		f.Ascent, f.Descent = max(maxY, 0), max(-minY, 0)
+	}
+	f.CapHeight, f.XHeight = 0, 0
+	return nil
+}
@@ -0,0 +1,52 @@
+package gfx
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRotate(t *testing.T) {
+	// An L, one column right of the origin and one row below the baseline:
+	//   X..
+	//   XXX
+	bdf := strings.Replace(testBDF(testGlyph("L", 0x4C, 3, 2, "80", "E0")), "BBX 3 2 0 0", "BBX 3 2 1 -1", 1)
+	tests := []struct {
+		degrees                         int
+		width, height, xOffset, yOffset int
+		bitmap                          []byte
+	}{
+		{90, 2, 3, -1, -4, []byte{0xC0, 0x80, 0x80}},
+		{180, 3, 2, -4, -1, []byte{0xE0, 0x20}},
+		{270, 2, 3, -1, 1, []byte{0x40, 0x40, 0xC0}},
+	}
+	for _, tt := range tests {
+		g := parseTest(t, bdf).Glyphs[0]
+		if err := g.Rotate(tt.degrees); err != nil {
+			t.Fatal(err)
+		}
+		if g.Width != tt.width || g.Height != tt.height || g.XOffset != tt.xOffset || g.YOffset != tt.yOffset || !bytes.Equal(g.Bitmap, tt.bitmap) {
+			t.Errorf("Rotate(%d) = BBX %d %d %d %d % X, want BBX %d %d %d %d % X", tt.degrees,
+				g.Width, g.Height, g.XOffset, g.YOffset, g.Bitmap,
+				tt.width, tt.height, tt.xOffset, tt.yOffset, tt.bitmap)
+		}
+		if g.XAdvance != 4 {
+			t.Errorf("Rotate(%d) changed the advance to %d", tt.degrees, g.XAdvance)
+		}
+	}
+
+	// Four quarter turns are the identity.
+	g, want := parseTest(t, bdf).Glyphs[0], parseTest(t, bdf).Glyphs[0]
+	for range 4 {
+		if err := g.Rotate(90); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if g.Width != want.Width || g.XOffset != want.XOffset || g.YOffset != want.YOffset || !bytes.Equal(g.Bitmap, want.Bitmap) {
+		t.Errorf("four turns gave %+v, want %+v", g, want)
+	}
+
+	if err := g.Rotate(45); err == nil {
+		t.Error("Rotate(45) did not fail")
+	}
+}
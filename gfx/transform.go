@@ -0,0 +1,219 @@
+package gfx
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"sort"
+)
+
+// transformed returns a copy of the font with fn applied to a copy of
+// every glyph, sorted by codepoint again.
+func (f *Font) transformed(fn func(*Glyph)) *Font {
+	t := *f
+	t.Glyphs = make([]*Glyph, len(f.Glyphs))
+	for i, g := range f.Glyphs {
+		c := *g
+		c.Bitmap = slices.Clone(g.Bitmap)
+		c.Gray = slices.Clone(g.Gray)
+		fn(&c)
+		t.Glyphs[i] = &c
+	}
+	sort.SliceStable(t.Glyphs, func(i, j int) bool { return t.Glyphs[i].Code < t.Glyphs[j].Code })
+	return &t
+}
+
+// BakeXOffset moves a positive xOffset into the bitmap by padding it on the
+// left, so renderers that ignore xOffset still place the ink correctly.
+// Glyphs with a negative xOffset would need cropping and are left alone;
+// BakeXOffset returns false for them.
+func (g *Glyph) BakeXOffset() bool {
+	if g.XOffset < 0 {
+		return false
+	}
+	if g.XOffset > 0 && g.Width > 0 {
+		g.reframe(g.Width+g.XOffset, g.Height, g.XOffset, 0)
+	}
+	g.XOffset = 0
+	return true
+}
+
+// Embolden fakes a bold weight like a double strike: every pixel right of
+// a set pixel is set too, so the bitmap grows by one column on the right.
+// Gray levels take the darker of the two. The advance grows by one, except
+// for glyphs with an advance of zero or less, usually combining marks.
+func (g *Glyph) Embolden() {
+	if g.Width > 0 && g.Height > 0 {
+		orig := *g
+		g.reframe(g.Width+1, g.Height, 0, 0)
+		for y := 0; y < orig.Height; y++ {
+			for x := 0; x < orig.Width; x++ {
+				if orig.Pixel(x, y) {
+					g.SetPixel(x+1, y)
+				}
+				if g.Gray != nil {
+					i := y*g.Width + x + 1
+					g.Gray[i] = max(g.Gray[i], orig.Gray[y*orig.Width+x])
+				}
+			}
+		}
+	}
+	if g.XAdvance > 0 {
+		g.XAdvance++
+	}
+}
+
+// Embolden emboldens every glyph, see Glyph.Embolden.
+func (f *Font) Embolden() {
+	for _, g := range f.Glyphs {
+		g.Embolden()
+	}
+}
+
+// Shear slants the glyph for a faux italic: the row r pixels above the
+// baseline moves right by r * slope pixels, rounded, and rows below the
+// baseline move left, so the baseline stays put. The bitmap widens to
+// hold the slanted rows and xOffset moves with its left edge; the advance
+// is kept.
+func (g *Glyph) Shear(slope float64) {
+	if g.Width <= 0 || g.Height <= 0 {
+		return
+	}
+	shift := func(y int) int {
+		return int(math.Round(float64(g.YOffset+g.Height-1-y) * slope))
+	}
+	minShift, maxShift := min(shift(0), shift(g.Height-1)), max(shift(0), shift(g.Height-1))
+
+	n := &Glyph{Width: g.Width + maxShift - minShift, Height: g.Height}
+	n.Bitmap = make([]byte, n.Height*n.BytesPerRow())
+	if len(g.Gray) == g.Width*g.Height {
+		n.Gray = make([]byte, n.Width*n.Height)
+	}
+	for y := 0; y < g.Height; y++ {
+		dx := shift(y) - minShift
+		for x := 0; x < g.Width; x++ {
+			if g.Pixel(x, y) {
+				n.SetPixel(x+dx, y)
+			}
+			if n.Gray != nil {
+				n.Gray[y*n.Width+x+dx] = g.Gray[y*g.Width+x]
+			}
+		}
+	}
+	g.Width, g.Bitmap, g.Gray = n.Width, n.Bitmap, n.Gray
+	g.XOffset += minShift
+}
+
+// Shear slants every glyph, see Glyph.Shear.
+func (f *Font) Shear(slope float64) {
+	for _, g := range f.Glyphs {
+		g.Shear(slope)
+	}
+}
+
+// Shift moves every glyph up by dy pixels relative to the baseline (down
+// for negative dy) and adjusts ascent and descent to match.
+func (f *Font) Shift(dy int) {
+	for _, g := range f.Glyphs {
+		g.YOffset += dy
+	}
+	f.Ascent += dy
+	f.Descent -= dy
+	if f.CapHeight != 0 {
+		f.CapHeight += dy
+	}
+	if f.XHeight != 0 {
+		f.XHeight += dy
+	}
+}
+
+// AlignCapHeight shifts the glyphs so that the top of capital letters sits
+// target pixels above the baseline; with target 0 the GFX cursor marks the
+// cap line, which lines up fonts of different sizes. The CAP_HEIGHT property
+// is used, falling back to the ink height of 'H'. It returns the shift.
+func (f *Font) AlignCapHeight(target int) (int, error) {
+	capHeight := f.CapHeight
+	if capHeight == 0 {
+		if h, ok := f.Glyph('H'); ok {
+			capHeight = h.YOffset + h.Height
+		}
+	}
+	if capHeight == 0 {
+		return 0, errors.New("font has no CAP_HEIGHT property and no 'H' glyph")
+	}
+	shift := target - capHeight
+	f.Shift(shift)
+	return shift, nil
+}
+
+// ClampAdvances sets negative advances, as used by some combining marks,
+// to zero and returns the glyphs that were changed. The marks then no
+// longer move the pen back over the preceding base letter.
+func (f *Font) ClampAdvances() []*Glyph {
+	var clamped []*Glyph
+	for _, g := range f.Glyphs {
+		if g.XAdvance < 0 {
+			g.XAdvance = 0
+			clamped = append(clamped, g)
+		}
+	}
+	return clamped
+}
+
+// AdvanceChange records a glyph whose advance was changed.
+type AdvanceChange struct {
+	Code     int
+	From, To int
+}
+
+// NormalizeAdvances fixes advances that end inside the ink, which makes
+// simple renderers overlap neighbouring glyphs: they are set to
+// xOffset + width + spacing. Glyphs without a bitmap and those with an
+// advance of zero or less, usually combining marks, are left alone.
+func (f *Font) NormalizeAdvances(spacing int) []AdvanceChange {
+	var changed []AdvanceChange
+	for _, g := range f.Glyphs {
+		if g.Width <= 0 || g.Height <= 0 || g.XAdvance <= 0 || g.XAdvance >= g.XOffset+g.Width {
+			continue
+		}
+		to := g.XOffset + g.Width + spacing
+		changed = append(changed, AdvanceChange{g.Code, g.XAdvance, to})
+		g.XAdvance = to
+	}
+	return changed
+}
+
+// MinAdvance raises advances below min to min, putting more space between
+// glyphs that are set too tightly. Glyphs with an advance of zero or less,
+// usually combining marks, are left alone.
+func (f *Font) MinAdvance(min int) []AdvanceChange {
+	var changed []AdvanceChange
+	for _, g := range f.Glyphs {
+		if g.XAdvance <= 0 || g.XAdvance >= min {
+			continue
+		}
+		changed = append(changed, AdvanceChange{g.Code, g.XAdvance, min})
+		g.XAdvance = min
+	}
+	return changed
+}
+
+// NormalizeXOffsets moves the ink of glyphs with a negative xOffset right to
+// the pen position, for renderers that cannot draw left of the cursor: the
+// xOffset becomes 0 and the advance grows by as much, so the glyph keeps
+// its shape and the space before the next glyph. Glyphs without a bitmap
+// and those with an advance of zero or less, usually combining marks that
+// are meant to reach back over the previous glyph, are left alone.
+func (f *Font) NormalizeXOffsets() []AdvanceChange {
+	var changed []AdvanceChange
+	for _, g := range f.Glyphs {
+		if g.XOffset >= 0 || g.Width <= 0 || g.Height <= 0 || g.XAdvance <= 0 {
+			continue
+		}
+		to := g.XAdvance - g.XOffset
+		changed = append(changed, AdvanceChange{g.Code, g.XAdvance, to})
+		g.XAdvance = to
+		g.XOffset = 0
+	}
+	return changed
+}
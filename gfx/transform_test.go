@@ -0,0 +1,107 @@
+package gfx
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestClampAdvances(t *testing.T) {
+	// A combining mark that moves the pen back over the base letter.
+	mark := strings.Replace(testGlyph("gravecomb", 0x300, 2, 2, "C0", "C0"), "DWIDTH 3 0", "DWIDTH -3 0", 1)
+	font := parseTest(t, testBDF(testBox(0x41), mark))
+	if got := font.Glyphs[1].XAdvance; got != -3 {
+		t.Fatalf("parsed advance %d, want -3", got)
+	}
+
+	clamped := font.ClampAdvances()
+	if len(clamped) != 1 || clamped[0].Code != 0x300 {
+		t.Errorf("ClampAdvances() = %v, want only 0x300", clamped)
+	}
+	if a, b := font.Glyphs[0].XAdvance, font.Glyphs[1].XAdvance; a != 5 || b != 0 {
+		t.Errorf("advances %d and %d, want 5 and 0", a, b)
+	}
+	if got := font.ClampAdvances(); len(got) != 0 {
+		t.Errorf("second ClampAdvances() = %v, want none", got)
+	}
+}
+
+func TestMinAdvance(t *testing.T) {
+	mark := strings.Replace(testGlyph("gravecomb", 0x300, 2, 2, "C0", "C0"), "DWIDTH 3 0", "DWIDTH 0 0", 1)
+	narrow := testGlyph("i", 0x69, 1, 4, "80", "80", "80", "80")
+	font := parseTest(t, testBDF(testBox(0x41), narrow, mark))
+
+	changed := font.MinAdvance(4)
+	if want := []AdvanceChange{{0x69, 2, 4}}; !slices.Equal(changed, want) {
+		t.Errorf("MinAdvance(4) = %v, want %v", changed, want)
+	}
+	var advances []int
+	for _, g := range font.Glyphs {
+		advances = append(advances, g.XAdvance)
+	}
+	// The box keeps 5 and the mark 0.
+	if want := []int{5, 4, 0}; !slices.Equal(advances, want) {
+		t.Errorf("advances %d, want %d", advances, want)
+	}
+}
+
+func TestEmbolden(t *testing.T) {
+	mark := strings.Replace(testGlyph("acutecomb", 0x301, 1, 1, "80"), "DWIDTH 2 0", "DWIDTH 0 0", 1)
+	font := parseTest(t, testBDF(testGlyph("v", 0x76, 3, 2, "A0", "40"), mark))
+	font.Embolden()
+
+	v, acute := font.Glyphs[0], font.Glyphs[1]
+	// 101 and 010 ORed with themselves one pixel to the right.
+	if v.Width != 4 || !bytes.Equal(v.Bitmap, []byte{0xF0, 0x60}) || v.XAdvance != 5 {
+		t.Errorf("bold v is %d wide with % X and an advance of %d, want 4, F0 60 and 5", v.Width, v.Bitmap, v.XAdvance)
+	}
+	if acute.Width != 2 || !bytes.Equal(acute.Bitmap, []byte{0xC0}) || acute.XAdvance != 0 {
+		t.Errorf("bold mark is %d wide with % X and an advance of %d, want 2, C0 and 0", acute.Width, acute.Bitmap, acute.XAdvance)
+	}
+}
+
+func TestShear(t *testing.T) {
+	// A bar from one row above the baseline to two below turns into a
+	// diagonal: every row moves one pixel per row from the baseline.
+	bdf := strings.Replace(testBDF(testGlyph("bar", 0x7C, 1, 4, "80", "80", "80", "80")), "BBX 1 4 0 0", "BBX 1 4 0 -2", 1)
+	font := parseTest(t, bdf)
+	font.Shear(1)
+	g := font.Glyphs[0]
+	if g.Width != 4 || g.Height != 4 || g.XOffset != -2 || g.YOffset != -2 || g.XAdvance != 2 {
+		t.Errorf("BBX %d %d %d %d advance %d, want BBX 4 4 -2 -2 advance 2", g.Width, g.Height, g.XOffset, g.YOffset, g.XAdvance)
+	}
+	if want := []byte{0x10, 0x20, 0x40, 0x80}; !bytes.Equal(g.Bitmap, want) {
+		t.Errorf("bitmap % X, want % X", g.Bitmap, want)
+	}
+
+	// Half a pixel per row shifts every other row.
+	font = parseTest(t, testBDF(testGlyph("bar", 0x7C, 1, 4, "80", "80", "80", "80")))
+	font.Shear(0.5)
+	if g := font.Glyphs[0]; g.Width != 3 || g.XOffset != 0 || !bytes.Equal(g.Bitmap, []byte{0x20, 0x40, 0x40, 0x80}) {
+		t.Errorf("slope 0.5: width %d, xOffset %d, bitmap % X, want 3, 0, 20 40 40 80", g.Width, g.XOffset, g.Bitmap)
+	}
+}
+
+func TestNormalizeXOffsets(t *testing.T) {
+	// A j whose tail reaches two pixels left of the pen, and a combining
+	// mark that reaches back over the previous glyph.
+	j := strings.Replace(testBox(0x6A), "BBX 4 4 0 0", "BBX 4 4 -2 0", 1)
+	mark := strings.Replace(testGlyph("gravecomb", 0x300, 2, 2, "C0", "C0"), "BBX 2 2 0 0", "BBX 2 2 -3 0", 1)
+	mark = strings.Replace(mark, "DWIDTH 3 0", "DWIDTH 0 0", 1)
+	font := parseTest(t, testBDF(testBox(0x41), j, mark))
+
+	changed := font.NormalizeXOffsets()
+	if want := []AdvanceChange{{0x6A, 5, 7}}; !slices.Equal(changed, want) {
+		t.Errorf("NormalizeXOffsets() = %v, want %v", changed, want)
+	}
+	if g := font.Glyphs[1]; g.XOffset != 0 || g.XAdvance != 7 || g.Width != 4 || !bytes.Equal(g.Bitmap, []byte{0xF0, 0xF0, 0xF0, 0xF0}) {
+		t.Errorf("j: xOffset %d, advance %d, width %d, bitmap % X, want 0, 7 and the unchanged 4x4 box", g.XOffset, g.XAdvance, g.Width, g.Bitmap)
+	}
+	if g := font.Glyphs[2]; g.XOffset != -3 || g.XAdvance != 0 {
+		t.Errorf("mark: xOffset %d and advance %d, want -3 and 0", g.XOffset, g.XAdvance)
+	}
+	if g := font.Glyphs[0]; g.XOffset != 0 || g.XAdvance != 5 {
+		t.Errorf("A: xOffset %d and advance %d, want 0 and 5", g.XOffset, g.XAdvance)
+	}
+}
@@ -0,0 +1,31 @@
+package gfx
+
+import "testing"
+
+func TestBlockOf(t *testing.T) {
+	for i, b := range unicodeBlocks {
+		if b.First > b.Last || i > 0 && b.First <= unicodeBlocks[i-1].Last {
+			t.Errorf("block %q U+%04X-U+%04X is empty or overlaps the one before", b.Name, b.First, b.Last)
+		}
+	}
+	for _, tt := range []struct {
+		code        int
+		first, last int
+		name        string
+		ok          bool
+	}{
+		{0x41, 0x0000, 0x007F, "Basic Latin", true},
+		{0xE9, 0x0080, 0x00FF, "Latin-1 Supplement", true},
+		{0x2190, 0x2190, 0x21FF, "Arrows", true},
+		{0x10FFFF, 0x100000, 0x10FFFF, "Supplementary Private Use Area-B", true},
+		// The gaps between Kangxi Radicals and Ideographic Description
+		// Characters, and between the Tags and Variation Selectors.
+		{0x2FE0, 0x2FE0, 0x2FEF, "No_Block", false},
+		{0xE00FF, 0xE0080, 0xE00FF, "No_Block", false},
+	} {
+		b, ok := BlockOf(tt.code)
+		if b != (UnicodeBlock{tt.first, tt.last, tt.name}) || ok != tt.ok {
+			t.Errorf("BlockOf(0x%04X) = %+v, %v, want U+%04X-U+%04X %q, %v", tt.code, b, ok, tt.first, tt.last, tt.name, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package gfx
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Severity tells whether a Diagnostic is a problem the standard GFX layout
+// cannot hold (SeverityError) or one it holds badly (SeverityWarning).
+type Severity int
+
+const (
+	SeverityWarning Severity = iota
+	SeverityError
+)
+
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+var (
+	ErrNoGlyphs        = errors.New("font has no glyphs")
+	ErrTablePadding    = errors.New("glyph table padding")
+	ErrYAdvance        = errors.New("bad yAdvance")
+	ErrFieldRange      = errors.New("value out of range")
+	ErrNegativeAdvance = errors.New("negative advance")
+	ErrBitmapSize      = errors.New("bitmap size does not match BBX")
+	ErrOutsideBBX      = errors.New("BBX outside FONTBOUNDINGBOX")
+)
+
+// Diagnostic is one problem found by Validate. Err wraps one of the Err
+// values above, so callers can pick diagnostics with errors.Is.
+type Diagnostic struct {
+	Severity Severity
+	Code     int // codepoint of the glyph, or -1 for the whole font
+	Err      error
+}
+
+func (d Diagnostic) String() string {
+	if d.Code < 0 {
+		return fmt.Sprintf("%s: %v", d.Severity, d.Err)
+	}
+	return fmt.Sprintf("%s: glyph 0x%04X: %v", d.Severity, d.Code, d.Err)
+}
+
+// diagError is a detailed message that unwraps to the kind of problem.
+type diagError struct {
+	kind error
+	msg  string
+}
+
+func (e *diagError) Error() string { return e.msg }
+func (e *diagError) Unwrap() error { return e.kind }
+
+// Validate checks the font against the standard GFX layout, as written by
+// a Header without options, and returns what it found, font-wide problems
+// first and then glyph problems in codepoint order:
+//
+//   - errors for codes, metrics, bitmap offsets and kerning adjustments
+//     that do not fit their fields, for negative advances, which
+//     ClampAdvances fixes, and for bitmaps that do not match the BBX,
+//   - warnings for a yAdvance of zero, for gaps that take more glyph table
+//     entries than there are glyphs, and for glyphs outside the
+//     FONTBOUNDINGBOX.
+//
+// Layout options such as Header.Wide accept more than Validate does.
+func (f *Font) Validate() []Diagnostic {
+	if len(f.Glyphs) == 0 {
+		return []Diagnostic{{SeverityError, -1, ErrNoGlyphs}}
+	}
+	var font, glyphs []Diagnostic
+	fontError := func(sev Severity, kind error, format string, args ...any) {
+		font = append(font, Diagnostic{sev, -1, &diagError{kind, fmt.Sprintf(format, args...)}})
+	}
+	glyphError := func(g *Glyph, sev Severity, kind error, format string, args ...any) {
+		glyphs = append(glyphs, Diagnostic{sev, g.Code, &diagError{kind, fmt.Sprintf(format, args...)}})
+	}
+
+	if y := f.YAdvance(); y == 0 {
+		fontError(SeverityWarning, ErrYAdvance, "yAdvance is 0, lines would be drawn on top of each other")
+	} else if y < 0 || y > 0xFF {
+		fontError(SeverityError, ErrFieldRange, "yAdvance %d does not fit uint8_t", y)
+	}
+	if _, padding := f.Contiguous(); padding > len(f.Glyphs) {
+		first, last := f.Range()
+		fontError(SeverityWarning, ErrTablePadding, "%d of the %d codes 0x%04X-0x%04X have no glyph; padding the glyph table costs %d bytes",
+			padding, last-first+1, first, last, 7*padding)
+	}
+	for _, p := range f.KernPairs() {
+		if p.Adjust < -0x80 || p.Adjust > 0x7F {
+			fontError(SeverityError, ErrFieldRange, "kerning pair 0x%04X 0x%04X: adjust %d does not fit int8_t", p.Left, p.Right, p.Adjust)
+		}
+	}
+
+	fields := (&Header{}).glyphFields()
+	offset := 0
+	for _, g := range f.Glyphs {
+		if g.Code < 0 || g.Code > 0xFFFF {
+			glyphError(g, SeverityError, ErrFieldRange, "code does not fit the uint16_t first/last of GFXfont")
+		}
+		// Fonts parsed with MetricsOnly have no bitmaps at all.
+		if g.Bitmap != nil && g.Width >= 0 && g.Height >= 0 && len(g.Bitmap) != g.Height*g.BytesPerRow() {
+			glyphError(g, SeverityError, ErrBitmapSize, "%d bitmap bytes for a %dx%d BBX", len(g.Bitmap), g.Width, g.Height)
+		}
+		if g.XAdvance < 0 {
+			glyphError(g, SeverityError, ErrNegativeAdvance, "advance %d does not fit uint8_t", g.XAdvance)
+		}
+		row := []int{offset, g.Width, g.Height, g.XAdvance, g.XOffset, g.YOffsetTFT()}
+		for j, v := range row {
+			r := ctypeRange[fields[j].ctype]
+			if (v < r[0] || v > r[1]) && !(fields[j].name == "xAdvance" && v < 0) {
+				glyphError(g, SeverityError, ErrFieldRange, "%s %d does not fit %s", fields[j].name, v, fields[j].ctype)
+			}
+		}
+		offset += (max(g.Width, 0)*max(g.Height, 0) + 7) / 8
+	}
+	for _, g := range f.OutsideBoundingBox() {
+		glyphError(g, SeverityWarning, ErrOutsideBBX, "BBX %d %d %d %d is outside the FONTBOUNDINGBOX", g.Width, g.Height, g.XOffset, g.YOffset)
+	}
+	// A stable sort keeps the order of the checks for each glyph.
+	sort.SliceStable(glyphs, func(i, j int) bool { return glyphs[i].Code < glyphs[j].Code })
+	return append(font, glyphs...)
+}
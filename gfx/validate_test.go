@@ -0,0 +1,64 @@
+package gfx
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateDiagnostics(t *testing.T) {
+	font := parseTest(t, testBDF(
+		testBox(0x41),
+		// Two of four rows.
+		testGlyph("short", 0x42, 4, 4, "F0", "F0"),
+		testBox(0x300),
+	))
+	font.Glyphs[0].XAdvance = -2
+
+	type want struct {
+		severity Severity
+		code     int
+		kind     error
+	}
+	wants := []want{
+		{SeverityWarning, -1, ErrTablePadding},
+		{SeverityError, 0x41, ErrNegativeAdvance},
+		{SeverityError, 0x42, ErrBitmapSize},
+	}
+	got := font.Validate()
+	if len(got) != len(wants) {
+		t.Fatalf("Validate() = %v, want %d diagnostics", got, len(wants))
+	}
+	for i, w := range wants {
+		d := got[i]
+		if d.Severity != w.severity || d.Code != w.code || !errors.Is(d.Err, w.kind) {
+			t.Errorf("diagnostic %d = %v, want %v for 0x%04X matching %v", i, d, w.severity, w.code, w.kind)
+		}
+	}
+}
+
+func TestValidateZeroYAdvance(t *testing.T) {
+	font := parseTest(t, testBDF(testBox(0x41)))
+	font.Ascent, font.Descent = 0, 0
+	got := font.Validate()
+	if len(got) != 1 || got[0].Severity != SeverityWarning || !errors.Is(got[0].Err, ErrYAdvance) {
+		t.Fatalf("Validate() = %v, want a yAdvance warning", got)
+	}
+
+	// The bitmap lines wrap at the glyph height, which is 0 here.
+	var buf bytes.Buffer
+	if _, err := (&Header{Font: font}).WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "0xFF, 0xFF, ") {
+		t.Errorf("no bitmap data in the header:\n%s", buf.String())
+	}
+}
+
+func TestValidateEmpty(t *testing.T) {
+	got := (&Font{}).Validate()
+	if len(got) != 1 || got[0].Severity != SeverityError || !errors.Is(got[0].Err, ErrNoGlyphs) {
+		t.Errorf("Validate() = %v, want ErrNoGlyphs", got)
+	}
+}
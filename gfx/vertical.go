@@ -0,0 +1,137 @@
+package gfx
+
+import (
+	"fmt"
+	"io"
+)
+
+// VerticalMetrics place a glyph in top-to-bottom text. The pen sits at the
+// vertical origin of the glyph; the top left corner of the bitmap is at
+// XOffset, YOffset from it, with y growing downwards like the GFX yOffset,
+// and the pen moves YAdvance pixels down to the next glyph.
+type VerticalMetrics struct {
+	YAdvance int
+	XOffset  int
+	YOffset  int
+}
+
+// HasVerticalMetrics reports whether the font has a VVECTOR or any glyph a
+// DWIDTH1 or VVECTOR of its own.
+func (f *Font) HasVerticalMetrics() bool {
+	if f.VVector != nil {
+		return true
+	}
+	for _, g := range f.Glyphs {
+		if g.hasDWidth1 || g.hasVVector {
+			return true
+		}
+	}
+	return false
+}
+
+// VerticalMetrics returns the vertical metrics of a glyph of the font from
+// its DWIDTH1 and VVECTOR. Without a DWIDTH1 the vertical advance is the
+// line height of the font, and without a VVECTOR of the glyph or the font
+// the vertical origin is the top center of the horizontal cell, half the
+// advance right of the horizontal origin and the ascent above it. Empty
+// glyphs without a DWIDTH1, such as the gaps of a glyph table, do not
+// advance.
+func (f *Font) VerticalMetrics(g *Glyph) VerticalMetrics {
+	origin := [2]int{g.XAdvance / 2, f.Ascent}
+	if g.hasVVector {
+		origin = g.vvector
+	} else if f.VVector != nil {
+		origin = *f.VVector
+	}
+	advance := f.YAdvance()
+	switch {
+	case g.hasDWidth1:
+		// DWIDTH1 points down for top-to-bottom text.
+		advance = max(g.dwidth1, -g.dwidth1)
+	case g.Width == 0 && g.XAdvance == 0:
+		advance = 0
+	}
+	return VerticalMetrics{
+		YAdvance: advance,
+		XOffset:  g.XOffset - origin[0],
+		YOffset:  origin[1] + g.YOffsetTFT(),
+	}
+}
+
+// verticalRows returns the GFXvglyph values of every glyph of a table and
+// checks them against their fields.
+func verticalRows(t *table) ([][3]int, error) {
+	rows := make([][3]int, len(t.font.Glyphs))
+	for i, g := range t.font.Glyphs {
+		m := t.font.VerticalMetrics(g)
+		rows[i] = [3]int{m.YAdvance, m.XOffset, m.YOffset}
+		for j, field := range []glyphField{{"uint8_t", "yAdvance"}, {"int8_t", "xOffset"}, {"int8_t", "yOffset"}} {
+			if r := ctypeRange[field.ctype]; rows[i][j] < r[0] || rows[i][j] > r[1] {
+				return nil, fmt.Errorf("glyph 0x%04X: vertical %s %d does not fit %s", g.Code, field.name, rows[i][j], field.ctype)
+			}
+		}
+	}
+	return rows, nil
+}
+
+// writeVertical emits the vertical metrics of a table, parallel to its
+// glyph table, and a helper that draws a glyph in top-to-bottom text.
+func (h *Header) writeVertical(w io.Writer, t *table) {
+	fmt.Fprint(w, "#ifndef GFX_VGLYPH_DEFINED\n")
+	fmt.Fprint(w, "#define GFX_VGLYPH_DEFINED\n")
+	fmt.Fprint(w, "typedef struct {\n")
+	fmt.Fprint(w, "  uint8_t  yAdvance;\n")
+	fmt.Fprint(w, "  int8_t   xOffset;\n")
+	fmt.Fprint(w, "  int8_t   yOffset;\n")
+	fmt.Fprint(w, "} GFXvglyph;\n")
+	fmt.Fprint(w, "#endif\n\n")
+
+	fmt.Fprintf(w, "// Vertical metrics, parallel to %sGlyphs: the bitmap is drawn at xOffset,\n", t.name)
+	fmt.Fprint(w, "// yOffset from the vertical origin, and the pen then moves yAdvance down.\n")
+	fmt.Fprintf(w, "const GFXvglyph %sVGlyphs[] PROGMEM = {\n", t.name)
+	for i, g := range t.font.Glyphs {
+		r := t.vertical[i]
+		row := fmt.Sprintf("  { %3d, %3d, %3d }, ", r[0], r[1], r[2])
+		fmt.Fprintf(w, "%s%s\n", row, h.comment(len(row), fmt.Sprintf("0x%04X", g.Code)))
+	}
+	fmt.Fprint(w, "};\n\n")
+
+	readDim := "pgm_read_byte"
+	if h.Wide {
+		readDim = "pgm_read_word"
+	}
+	first, last := t.font.Range()
+	fmt.Fprintf(w, "// %sDrawVertical draws the glyph of code with its vertical origin at\n", t.name)
+	fmt.Fprint(w, "// (x, *y), one drawPixel call per set pixel, and moves *y down to the\n")
+	fmt.Fprint(w, "// origin of the next glyph. Codes without a glyph are skipped.\n")
+	fmt.Fprintf(w, "static inline void %sDrawVertical(uint16_t code, int16_t x, int16_t *y, void (*drawPixel)(int16_t x, int16_t y)) {\n", t.name)
+	if h.CodeMap {
+		fmt.Fprintf(w, "  int32_t index = %sGlyphIndex(code);\n", t.name)
+		fmt.Fprint(w, "  if (index < 0) {\n")
+	} else {
+		fmt.Fprintf(w, "  uint16_t index = code - 0x%x;\n", first)
+		fmt.Fprintf(w, "  if (code < 0x%x || code > 0x%x) {\n", first, last)
+	}
+	fmt.Fprint(w, "    return;\n")
+	fmt.Fprint(w, "  }\n")
+	fmt.Fprintf(w, "  const GFXglyph *glyph = &%sGlyphs[index];\n", t.name)
+	fmt.Fprintf(w, "  const GFXvglyph *v = &%sVGlyphs[index];\n", t.name)
+	fmt.Fprintf(w, "  const uint8_t *bitmap = &%sBitmaps[pgm_read_word(&glyph->bitmapOffset)];\n", t.name)
+	fmt.Fprintf(w, "  uint16_t width = %s(&glyph->width), height = %s(&glyph->height);\n", readDim, readDim)
+	fmt.Fprint(w, "  int16_t left = x + (int8_t)pgm_read_byte(&v->xOffset);\n")
+	fmt.Fprint(w, "  int16_t top = *y + (int8_t)pgm_read_byte(&v->yOffset);\n")
+	fmt.Fprint(w, "  uint8_t bits = 0, bit = 0;\n")
+	fmt.Fprint(w, "  for (uint16_t row = 0; row < height; row++) {\n")
+	fmt.Fprint(w, "    for (uint16_t column = 0; column < width; column++) {\n")
+	fmt.Fprint(w, "      if ((bit++ & 7) == 0) {\n")
+	fmt.Fprint(w, "        bits = pgm_read_byte(bitmap++);\n")
+	fmt.Fprint(w, "      }\n")
+	fmt.Fprint(w, "      if (bits & 0x80) {\n")
+	fmt.Fprint(w, "        drawPixel(left + column, top + row);\n")
+	fmt.Fprint(w, "      }\n")
+	fmt.Fprint(w, "      bits <<= 1;\n")
+	fmt.Fprint(w, "    }\n")
+	fmt.Fprint(w, "  }\n")
+	fmt.Fprint(w, "  *y += pgm_read_byte(&v->yAdvance);\n")
+	fmt.Fprint(w, "}\n\n")
+}
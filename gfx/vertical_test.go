@@ -0,0 +1,54 @@
+package gfx
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestVerticalMetrics(t *testing.T) {
+	plain := parseTest(t, testBDF(testBox(0x41)))
+	if plain.HasVerticalMetrics() {
+		t.Error("HasVerticalMetrics() without DWIDTH1 and VVECTOR")
+	}
+	// The top center of the 5 pixel wide cell, with the line height.
+	if m, want := plain.VerticalMetrics(plain.Glyphs[0]), (VerticalMetrics{8, -2, 2}); m != want {
+		t.Errorf("VerticalMetrics() = %+v, want %+v", m, want)
+	}
+
+	// A font VVECTOR, overridden by the glyph of B, which also has a
+	// DWIDTH1.
+	b := strings.Replace(testBox(0x42), "BBX", "DWIDTH1 0 -9\nVVECTOR 2 6\nBBX", 1)
+	bdf := strings.Replace(testBDF(testBox(0x41), b, testBox(0x44)), "FONTBOUNDINGBOX 6 8 0 -2\n", "FONTBOUNDINGBOX 6 8 0 -2\nVVECTOR 3 7\n", 1)
+	font := parseTest(t, bdf)
+	if !font.HasVerticalMetrics() || font.VVector == nil || *font.VVector != [2]int{3, 7} {
+		t.Fatalf("VVector = %v, want 3 7", font.VVector)
+	}
+	for i, want := range []VerticalMetrics{{8, -3, 3}, {9, -2, 2}} {
+		if m := font.VerticalMetrics(font.Glyphs[i]); m != want {
+			t.Errorf("VerticalMetrics(0x%04X) = %+v, want %+v", font.Glyphs[i].Code, m, want)
+		}
+	}
+
+	var buf bytes.Buffer
+	if _, err := (&Header{Font: font, Name: "Test", Vertical: true}).WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	// The gap at 0x43 does not advance.
+	want := "const GFXvglyph TestVGlyphs[] PROGMEM = {\n" +
+		"  {   8,  -3,   3 }, // 0x0041\n" +
+		"  {   9,  -2,   2 }, // 0x0042\n" +
+		"  {   0,  -3,   7 }, // 0x0043\n" +
+		"  {   8,  -3,   3 }, // 0x0044\n" +
+		"};\n"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("header without the vertical metrics %q:\n%s", want, &buf)
+	}
+	if !strings.Contains(buf.String(), "static inline void TestDrawVertical(uint16_t code, int16_t x, int16_t *y,") {
+		t.Errorf("header without the draw helper:\n%s", &buf)
+	}
+
+	if _, err := (&Header{Font: font, Name: "Test", Vertical: true, Delta: true}).WriteTo(&buf); err == nil {
+		t.Error("vertical metrics combined with delta bitmaps")
+	}
+}
@@ -0,0 +1,79 @@
+package gfx
+
+import (
+	"strings"
+	"unicode"
+)
+
+// xlfdFields splits an XLFD name such as
+// "-Misc-Fixed-Medium-R-Normal--8-80-75-75-C-50-ISO10646-1" into its 14
+// fields. It returns nil for names that are not well-formed XLFDs.
+func xlfdFields(name string) []string {
+	if !strings.HasPrefix(name, "-") {
+		return nil
+	}
+	parts := strings.Split(name[1:], "-")
+	if len(parts) != 14 {
+		return nil
+	}
+	return parts
+}
+
+// Family returns the family name from the XLFD name, or "" when the font
+// has no well-formed XLFD.
+func (f *Font) Family() string {
+	if fields := xlfdFields(f.XLFD); fields != nil {
+		return fields[1]
+	}
+	return ""
+}
+
+// SymbolName derives a C identifier from the XLFD family, weight, slant
+// and pixel size, e.g. "HelveticaBoldItalic12". Regular weights and
+// upright slants are left out. It returns "" when the font has no
+// well-formed XLFD.
+func (f *Font) SymbolName() string {
+	fields := xlfdFields(f.XLFD)
+	if fields == nil || fields[1] == "" {
+		return ""
+	}
+	words := []string{fields[1]}
+	switch strings.ToLower(fields[2]) {
+	case "", "medium", "regular", "normal", "book":
+	default:
+		words = append(words, fields[2])
+	}
+	switch strings.ToUpper(fields[3]) {
+	case "I":
+		words = append(words, "Italic")
+	case "O":
+		words = append(words, "Oblique")
+	}
+	if fields[6] != "" && fields[6] != "0" {
+		words = append(words, fields[6])
+	}
+
+	var name strings.Builder
+	for _, w := range words {
+		upper := true
+		for _, r := range w {
+			if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+				upper = true
+				continue
+			}
+			if upper {
+				r = unicode.ToUpper(r)
+				upper = false
+			}
+			name.WriteRune(r)
+		}
+	}
+	s := name.String()
+	if s == "" {
+		return ""
+	}
+	if unicode.IsDigit(rune(s[0])) {
+		s = "Font" + s
+	}
+	return s
+}
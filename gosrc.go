@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"log"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+// generateGo writes the font as Go source for Go-based tooling. The
+// GFXGlyph type mirrors the fields of the C GFXglyph struct; withTypes
+// controls whether it is declared in this file.
+func generateGo(filename string, font *gfx.Font, name, pkg string, withTypes bool) {
+	contiguous, _ := font.Contiguous()
+	glyphs := contiguous.Glyphs
+	bitmapData, offsets := gfx.PackGlyphs(glyphs, 1)
+	if err := checkGlyphTable(glyphs, offsets, goGlyphFields); err != nil {
+		log.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by bdf2gfx. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+
+	if withTypes {
+		fmt.Fprintf(&buf, "// GFXGlyph mirrors the GFXglyph struct of Adafruit GFX.\n")
+		fmt.Fprintf(&buf, "type GFXGlyph struct {\n")
+		for _, f := range goGlyphFields {
+			fmt.Fprintf(&buf, "%s %s\n", f.name, f.typ)
+		}
+		fmt.Fprintf(&buf, "}\n\n")
+	}
+
+	first, last := font.Range()
+	fmt.Fprintf(&buf, "const (\n")
+	fmt.Fprintf(&buf, "%sAscent = %d\n", name, font.Ascent)
+	fmt.Fprintf(&buf, "%sDescent = %d\n", name, font.Descent)
+	fmt.Fprintf(&buf, "%sFirst = 0x%x\n", name, first)
+	fmt.Fprintf(&buf, "%sLast = 0x%x\n", name, last)
+	fmt.Fprintf(&buf, "%sYAdvance = %d\n", name, font.YAdvance())
+	if font.HasNotdef {
+		fmt.Fprintf(&buf, "%sNotdefIndex = 0\n", name)
+	}
+	if font.CodeBase != 0 {
+		fmt.Fprintf(&buf, "%sCodeBase = 0x%X // add to a glyph code to get the real codepoint\n", name, font.CodeBase)
+	}
+	fmt.Fprintf(&buf, ")\n\n")
+
+	fmt.Fprintf(&buf, "var %sBitmaps = []byte{\n", name)
+	// A line per glyph height, unless the font has none.
+	wrap := font.Ascent + font.Descent
+	for i, b := range bitmapData {
+		if wrap > 0 && i > 0 && i%wrap == 0 {
+			fmt.Fprint(&buf, "\n")
+		}
+		fmt.Fprintf(&buf, "0x%02X, ", b)
+	}
+	fmt.Fprintf(&buf, "\n}\n\n")
+
+	fmt.Fprintf(&buf, "var %sGlyphs = []GFXGlyph{\n", name)
+	for i, g := range glyphs {
+		fmt.Fprintf(&buf, "{%d, %d, %d, %d, %d, %d}, // 0x%04X\n",
+			offsets[i], g.Width, g.Height, g.XAdvance, g.XOffset, g.YOffsetTFT(), g.Code)
+	}
+	fmt.Fprint(&buf, "}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatalf("Formatting generated Go source: %v", err)
+	}
+	if err := writeOutput(filename, src); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// outputField is a field of the GFXglyph struct as declared by the Go and
+// Rust emitters.
+type outputField struct {
+	name, typ string
+}
+
+// goGlyphFields is the GFXGlyph struct of the Go output.
+var goGlyphFields = []outputField{
+	{"BitmapOffset", "uint16"},
+	{"Width", "uint8"},
+	{"Height", "uint8"},
+	{"XAdvance", "uint8"},
+	{"XOffset", "int8"},
+	{"YOffset", "int8"},
+}
+
+// outputTypeRange holds the value range of the Go and Rust integer types
+// used in the glyph table.
+var outputTypeRange = map[string][2]int{
+	"uint8": {0, 0xFF}, "u8": {0, 0xFF},
+	"int8": {-0x80, 0x7F}, "i8": {-0x80, 0x7F},
+	"uint16": {0, 0xFFFF}, "u16": {0, 0xFFFF},
+}
+
+// checkGlyphTable returns an error for the first value of the glyph table
+// that does not fit its field, so that the output compiles. fields lists the
+// bitmap offset, width, height, advance and offsets in that order.
+func checkGlyphTable(glyphs []*gfx.Glyph, offsets []int, fields []outputField) error {
+	for i, g := range glyphs {
+		values := []int{offsets[i], g.Width, g.Height, g.XAdvance, g.XOffset, g.YOffsetTFT()}
+		for j, v := range values {
+			if r := outputTypeRange[fields[j].typ]; v < r[0] || v > r[1] {
+				return fmt.Errorf("glyph 0x%04X: %s %d does not fit %s", g.Code, fields[j].name, v, fields[j].typ)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+func TestCheckGlyphTable(t *testing.T) {
+	glyph := func(width, height, advance, xOffset, yOffset int) *gfx.Glyph {
+		return &gfx.Glyph{Code: 0x41, Width: width, Height: height, XAdvance: advance, XOffset: xOffset, YOffset: yOffset}
+	}
+	tests := []struct {
+		glyph  *gfx.Glyph
+		offset int
+		want   string // empty if the glyph fits
+	}{
+		{glyph(8, 8, 9, 0, -2), 0xFFFF, ""},
+		{glyph(255, 1, 255, -128, -1), 0, ""},
+		{glyph(8, 8, 9, 0, -2), 0x10000, "BitmapOffset 65536 does not fit uint16"},
+		{glyph(256, 8, 9, 0, -2), 0, "Width 256 does not fit uint8"},
+		{glyph(8, 300, 9, 0, -2), 0, "Height 300 does not fit uint8"},
+		{glyph(8, 8, -1, 0, -2), 0, "XAdvance -1 does not fit uint8"},
+		{glyph(8, 8, 9, 128, -2), 0, "XOffset 128 does not fit int8"},
+		// The table stores the offset from the baseline to the top.
+		{glyph(8, 8, 9, 0, -140), 0, "YOffset 132 does not fit int8"},
+	}
+	for _, tt := range tests {
+		err := checkGlyphTable([]*gfx.Glyph{tt.glyph}, []int{tt.offset}, goGlyphFields)
+		switch {
+		case tt.want == "" && err != nil:
+			t.Errorf("%+v at %d: %v", *tt.glyph, tt.offset, err)
+		case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+			t.Errorf("%+v at %d: got %v, want %q", *tt.glyph, tt.offset, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+// hexBytesPerLine is the number of bitmap bytes on each line of the plain
+// hex output and in each Intel HEX data record.
+const hexBytesPerLine = 16
+
+// writeHexBitmaps writes the bitmap blob of the header as text without any
+// C around it, for loaders that stream the font into a device. The bytes
+// are those of the header's bitmap array in the same order, so glyph i
+// starts at its bitmapOffset; with the default layout each glyph's pixels
+// run row by row from the top left, packed without row padding, most
+// significant bit first. The plain format writes two uppercase hex digits
+// per byte, 16 bytes per line; ihex writes Intel HEX records starting at
+// address 0.
+func writeHexBitmaps(filename, format string, header *gfx.Header) {
+	data, err := header.Bitmaps()
+	if err != nil {
+		log.Fatal(err)
+	}
+	var out bytes.Buffer
+	switch format {
+	case "plain":
+		for i := 0; i < len(data); i += hexBytesPerLine {
+			fmt.Fprintf(&out, "%X\n", data[i:min(i+hexBytesPerLine, len(data))])
+		}
+	case "ihex":
+		writeIntelHex(&out, data)
+	default:
+		log.Fatalf("Unknown hex format %q", format)
+	}
+	if err := writeOutput(filename, out.Bytes()); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Wrote %d bitmap bytes to %s\n", len(data), filename)
+}
+
+// writeIntelHex writes data as Intel HEX data records, with an extended
+// linear address record before every 64 KiB, and the end of file record.
+func writeIntelHex(out *bytes.Buffer, data []byte) {
+	record := func(kind byte, address int, payload []byte) {
+		rec := append([]byte{byte(len(payload)), byte(address >> 8), byte(address), kind}, payload...)
+		sum := byte(0)
+		for _, b := range rec {
+			sum += b
+		}
+		fmt.Fprintf(out, ":%X%02X\n", rec, -sum)
+	}
+	for i := 0; i < len(data); i += hexBytesPerLine {
+		if i > 0 && i%0x10000 == 0 {
+			record(0x04, 0, []byte{byte(i >> 24), byte(i >> 16)})
+		}
+		record(0x00, i&0xFFFF, data[i:min(i+hexBytesPerLine, len(data))])
+	}
+	record(0x01, 0, nil)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteIntelHex(t *testing.T) {
+	var out bytes.Buffer
+	writeIntelHex(&out, []byte{1, 2, 3})
+	if got, want := out.String(), ":03000000010203F7\n:00000001FF\n"; got != want {
+		t.Errorf("Intel HEX\n%s\nwant\n%s", got, want)
+	}
+
+	// Past 64 KiB an extended linear address record sets the upper half.
+	out.Reset()
+	writeIntelHex(&out, make([]byte, 0x10010))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if want := 0x1001 + 2; len(lines) != want {
+		t.Fatalf("%d records, want %d", len(lines), want)
+	}
+	if got := lines[0x1000]; got != ":020000040001F9" {
+		t.Errorf("record at 64 KiB %s, want :020000040001F9", got)
+	}
+	if got := lines[0x1001]; got != ":1000000000000000000000000000000000000000F0" {
+		t.Errorf("first record past 64 KiB %s, want one at address 0", got)
+	}
+}
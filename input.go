@@ -0,0 +1,66 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// zipMember splits an "archive.zip:member.bdf" input name. ok is false
+// for plain file names.
+func zipMember(name string) (archive, member string, ok bool) {
+	i := strings.LastIndex(strings.ToLower(name), ".zip:")
+	if i < 0 {
+		return "", "", false
+	}
+	return name[:i+len(".zip")], name[i+len(".zip:"):], true
+}
+
+// openInput opens an input font, which is either a file or a member of a
+// zip archive given as archive.zip:member.
+func openInput(name string) (io.ReadCloser, error) {
+	archive, member, ok := zipMember(name)
+	if !ok {
+		return os.Open(name)
+	}
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, err
+	}
+	var bdfs []string
+	for _, f := range zr.File {
+		if f.Name == member {
+			rc, err := f.Open()
+			if err != nil {
+				zr.Close()
+				return nil, err
+			}
+			return &zipFile{rc, zr}, nil
+		}
+		if strings.EqualFold(path.Ext(f.Name), ".bdf") {
+			bdfs = append(bdfs, f.Name)
+		}
+	}
+	zr.Close()
+	if len(bdfs) == 0 {
+		return nil, fmt.Errorf("%s: no member %q and no .bdf members", archive, member)
+	}
+	return nil, fmt.Errorf("%s: no member %q, the .bdf members are:\n  %s", archive, member, strings.Join(bdfs, "\n  "))
+}
+
+// zipFile closes the archive together with the member.
+type zipFile struct {
+	io.ReadCloser
+	archive *zip.ReadCloser
+}
+
+func (f *zipFile) Close() error {
+	err := f.ReadCloser.Close()
+	if cerr := f.archive.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
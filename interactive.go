@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+// -interactive shows interactivePage glyphs at once, in rows of
+// interactiveColumns.
+const (
+	interactivePage    = 16
+	interactiveColumns = 8
+)
+
+// isTerminal reports whether f is a character device such as a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// selectInteractive shows the glyphs of the font page by page on the
+// terminal and lets the user toggle them with commands read from stdin.
+// All glyphs start selected. On "w" the unselected glyphs are dropped and
+// the conversion goes on; on "q" or the end of the input it stops without
+// writing anything.
+func selectInteractive(font *gfx.Font) {
+	if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		log.Fatal("-interactive needs a terminal on stdin and stdout")
+	}
+	if len(font.Glyphs) == 0 {
+		log.Fatal("-interactive: the font has no glyphs")
+	}
+	if err := selectGlyphs(font, os.Stdin, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// selectGlyphs runs the commands of selectInteractive read from in,
+// drawing the pages to out. It returns an error if the input quits or
+// ends before "w".
+func selectGlyphs(font *gfx.Font, in io.Reader, out io.Writer) error {
+	cells, width, height := asciiCells(font)
+	cellWidth := max(width, len("[x] U+0000"))
+	selected := make([]bool, len(font.Glyphs))
+	for i := range selected {
+		selected[i] = true
+	}
+	pages := (len(font.Glyphs) + interactivePage - 1) / interactivePage
+
+	page, message := 0, ""
+	input := bufio.NewScanner(in)
+	for {
+		count := 0
+		for _, s := range selected {
+			if s {
+				count++
+			}
+		}
+		fmt.Fprint(out, "\x1b[H\x1b[2J")
+		fmt.Fprintf(out, "Page %d of %d, %d of %d glyphs selected\n", page+1, pages, count, len(font.Glyphs))
+		start := page * interactivePage
+		end := min(start+interactivePage, len(font.Glyphs))
+		for row := start; row < end; row += interactiveColumns {
+			rowEnd := min(row+interactiveColumns, end)
+			var labels []string
+			for i, g := range font.Glyphs[row:rowEnd] {
+				mark := " "
+				if selected[row+i] {
+					mark = "x"
+				}
+				labels = append(labels, fmt.Sprintf("%-*s", cellWidth, fmt.Sprintf("[%s] U+%04X", mark, g.Code)))
+			}
+			fmt.Fprintf(out, "\n%s\n", strings.TrimRight(strings.Join(labels, "  "), " "))
+			for y := 0; y < height; y++ {
+				var line []string
+				for _, cell := range cells[row:rowEnd] {
+					line = append(line, fmt.Sprintf("%-*s", cellWidth, cell[y]))
+				}
+				fmt.Fprintf(out, "%s\n", strings.TrimRight(strings.Join(line, "  "), " "))
+			}
+		}
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "n/p: next/previous page, g <code>: go to code, t <ranges>: toggle, e.g. t 0x41-0x5A,0xB0")
+		fmt.Fprintln(out, "c <text>: toggle characters, a: select all, x: select none, w: write, q: quit")
+		if message != "" {
+			fmt.Fprintln(out, message)
+			message = ""
+		}
+		fmt.Fprint(out, "> ")
+		if !input.Scan() {
+			break
+		}
+		command, arg, _ := strings.Cut(strings.TrimSpace(input.Text()), " ")
+		arg = strings.TrimSpace(arg)
+		switch command {
+		case "", "n":
+			page = (page + 1) % pages
+		case "p":
+			page = (page + pages - 1) % pages
+		case "g":
+			code, err := parseCodepoint(arg)
+			if err != nil {
+				message = err.Error()
+				break
+			}
+			i := glyphIndex(font, code)
+			if i < 0 {
+				message = fmt.Sprintf("No glyph 0x%04X in the font", code)
+				break
+			}
+			page = i / interactivePage
+		case "t":
+			ranges, err := gfx.ParseRanges(arg)
+			if err != nil {
+				message = err.Error()
+				break
+			}
+			for i, g := range font.Glyphs {
+				if ranges.Contains(g.Code) {
+					selected[i] = !selected[i]
+				}
+			}
+		case "c":
+			for _, r := range arg {
+				if i := glyphIndex(font, int(r)); i >= 0 {
+					selected[i] = !selected[i]
+				}
+			}
+		case "a", "x":
+			for i := range selected {
+				selected[i] = command == "a"
+			}
+		case "w":
+			if count == 0 {
+				message = "Select at least one glyph to write"
+				break
+			}
+			keep := map[*gfx.Glyph]bool{}
+			for i, g := range font.Glyphs {
+				keep[g] = selected[i]
+			}
+			dropped := font.Drop(func(g *gfx.Glyph) bool { return !keep[g] })
+			fmt.Fprintf(out, "Selected %d glyphs, dropped %d\n", len(font.Glyphs), len(dropped))
+			return nil
+		case "q":
+			return fmt.Errorf("-interactive: quit without writing")
+		default:
+			message = fmt.Sprintf("Unknown command %q", command)
+		}
+	}
+	if err := input.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("-interactive: end of input, nothing written")
+}
+
+// glyphIndex returns the index of the first glyph of code in the font, or
+// -1 if there is none.
+func glyphIndex(font *gfx.Font, code int) int {
+	for i, g := range font.Glyphs {
+		if g.Code == code {
+			return i
+		}
+	}
+	return -1
+}
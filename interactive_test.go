@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSelectGlyphs(t *testing.T) {
+	codes := []int{0x20}
+	for c := 0x41; c <= 0x5A; c++ {
+		codes = append(codes, c)
+	}
+	filename := writeTestBDF(t, nil, codes...)
+
+	// Select none, then toggle a range and the characters of a string,
+	// one of which toggles a glyph of the range back off.
+	font := parseBDF(filename)
+	var out strings.Builder
+	if err := selectGlyphs(font, strings.NewReader("x\nt 0x41-0x43\nc CZ?\nw\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := glyphCodes(font), []int{0x41, 0x42, 0x5A}; !slices.Equal(got, want) {
+		t.Errorf("codes %#x, want %#x", got, want)
+	}
+	if !strings.Contains(out.String(), "Page 1 of 2, 27 of 27 glyphs selected\n") {
+		t.Errorf("first page without its title:\n%s", &out)
+	}
+
+	// Paging wraps around, and "g" jumps to the page of a code.
+	font = parseBDF(filename)
+	out.Reset()
+	if err := selectGlyphs(font, strings.NewReader("p\ng 0x41\ng 0x61\nq\n"), &out); err == nil {
+		t.Error("quitting did not fail")
+	}
+	for _, want := range []string{"Page 2 of 2,", "No glyph 0x0061 in the font\n", "[x] U+0050"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output without %q:\n%s", want, &out)
+		}
+	}
+	if len(font.Glyphs) != len(codes) {
+		t.Errorf("%d glyphs left after quitting, want all %d", len(font.Glyphs), len(codes))
+	}
+
+	// Writing needs a selection, and the end of the input fails.
+	out.Reset()
+	if err := selectGlyphs(font, strings.NewReader("x\nw\n"), &out); err == nil {
+		t.Error("end of input did not fail")
+	}
+	if !strings.Contains(out.String(), "Select at least one glyph to write\n") {
+		t.Errorf("empty selection written:\n%s", &out)
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	if isTerminal(r) || isTerminal(w) {
+		t.Error("a pipe is a terminal")
+	}
+}
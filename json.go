@@ -0,0 +1,19 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+// generateJSON dumps the parsed font for inspection by other tools.
+func generateJSON(filename string, font *gfx.Font) {
+	data, err := json.MarshalIndent(font, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := writeOutput(filename, append(data, '\n')); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+// libraryVersion is the version the library manifests start at; bump it
+// in the copies when the font changes.
+const libraryVersion = "1.0.0"
+
+// writeLibrary writes the font as an Arduino/PlatformIO library into dir:
+// src/<name>.h declares the font, src/<name>.cpp defines it with the C
+// header of the font, and library.json and library.properties describe
+// the library to PlatformIO and the Arduino IDE.
+func writeLibrary(dir string, header *gfx.Header) {
+	name := header.Name
+	if err := os.MkdirAll(filepath.Join(dir, "src"), 0o777); err != nil {
+		log.Fatal(err)
+	}
+	writeFile(filepath.Join(dir, "src", name+".h"), &libraryDeclarations{header})
+	writeFile(filepath.Join(dir, "src", name+".cpp"), &libraryDefinitions{header})
+
+	description := fmt.Sprintf("The %s bitmap font for Adafruit_GFX", name)
+	if xlfd := header.Font.XLFD; xlfd != "" {
+		description += ", converted from " + xlfd
+	}
+	manifest := struct {
+		Name         string            `json:"name"`
+		Version      string            `json:"version"`
+		Description  string            `json:"description"`
+		Keywords     string            `json:"keywords"`
+		Frameworks   string            `json:"frameworks"`
+		Platforms    string            `json:"platforms"`
+		Dependencies map[string]string `json:"dependencies"`
+	}{name, libraryVersion, description, "font, gfx, display", "arduino", "*",
+		map[string]string{"adafruit/Adafruit GFX Library": "*"}}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := writeOutput(filepath.Join(dir, "library.json"), append(data, '\n')); err != nil {
+		log.Fatal(err)
+	}
+
+	var properties bytes.Buffer
+	fmt.Fprintf(&properties, "name=%s\n", name)
+	fmt.Fprintf(&properties, "version=%s\n", libraryVersion)
+	fmt.Fprint(&properties, "author=\n")
+	fmt.Fprint(&properties, "maintainer=\n")
+	fmt.Fprintf(&properties, "sentence=%s.\n", description)
+	fmt.Fprint(&properties, "paragraph=Generated by bdf2tft.\n")
+	fmt.Fprint(&properties, "category=Display\n")
+	fmt.Fprint(&properties, "url=\n")
+	fmt.Fprint(&properties, "architectures=*\n")
+	fmt.Fprint(&properties, "depends=Adafruit GFX Library\n")
+	fmt.Fprintf(&properties, "includes=%s.h\n", name)
+	if err := writeOutput(filepath.Join(dir, "library.properties"), properties.Bytes()); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Wrote the %s library to %s\n", name, dir)
+}
+
+// libraryGuard returns the include guard macro of a library header.
+func libraryGuard(name string) string {
+	return strings.ToUpper(name) + "_H"
+}
+
+// libraryDeclarations writes the header of a library, with an include
+// guard, that declares the font extern so any number of files can
+// include it, and the ascent and descent defines.
+type libraryDeclarations struct {
+	header *gfx.Header
+}
+
+func (d *libraryDeclarations) WriteTo(w io.Writer) (int64, error) {
+	name, font := d.header.Name, d.header.Font
+	guard := libraryGuard(name)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// The %s font, generated by bdf2tft. The font is defined in %s.cpp;\n", name, name)
+	fmt.Fprintf(&buf, "// define %s_NO_FONT to leave it out of the build.\n", name)
+	fmt.Fprintf(&buf, "#ifndef %s\n", guard)
+	fmt.Fprintf(&buf, "#define %s\n\n", guard)
+	fmt.Fprint(&buf, "#include <Adafruit_GFX.h>\n\n")
+	fmt.Fprintf(&buf, "extern const GFXfont %s PROGMEM;\n\n", name)
+	fmt.Fprintf(&buf, "#define %s_ASCENT %d\n", name, font.Ascent)
+	fmt.Fprintf(&buf, "#define %s_DESCENT %d\n\n", name, font.Descent)
+	fmt.Fprintf(&buf, "#endif // %s\n", guard)
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+// libraryDefinitions writes the source file of a library: the C header of
+// the font after the declarations, which give its symbols external
+// linkage in C++, unless <name>_NO_FONT is defined.
+type libraryDefinitions struct {
+	header *gfx.Header
+}
+
+func (d *libraryDefinitions) WriteTo(w io.Writer) (int64, error) {
+	name := d.header.Name
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "#include \"%s.h\"\n\n", name)
+	fmt.Fprintf(&buf, "#ifndef %s_NO_FONT\n\n", name)
+	if _, err := d.header.WriteTo(&buf); err != nil {
+		return 0, err
+	}
+	fmt.Fprintf(&buf, "\n#endif // %s_NO_FONT\n", name)
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
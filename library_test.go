@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+func TestWriteLibrary(t *testing.T) {
+	box := &gfx.Glyph{Code: 0x41, Width: 4, Height: 4, XAdvance: 5, YOffset: -4, Bitmap: []byte{0xF0, 0xF0, 0xF0, 0xF0}}
+	font := &gfx.Font{Ascent: 6, Descent: 2, DefaultChar: -1, XLFD: "-Test-Box-Medium-R-Normal--8-80-75-75-C-50-ISO10646-1",
+		Glyphs: []*gfx.Glyph{box}}
+	dir := t.TempDir()
+	writeLibrary(dir, &gfx.Header{Font: font, Name: "Box8"})
+	read := func(name string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	h := read("src/Box8.h")
+	for _, want := range []string{
+		"#ifndef BOX8_H\n#define BOX8_H\n",
+		"extern const GFXfont Box8 PROGMEM;\n",
+		"#define Box8_ASCENT 6\n",
+		"#endif // BOX8_H\n",
+	} {
+		if !strings.Contains(h, want) {
+			t.Errorf("Box8.h without %q:\n%s", want, h)
+		}
+	}
+	// The declarations come before the definitions of the C header.
+	cpp := read("src/Box8.cpp")
+	if !strings.HasPrefix(cpp, "#include \"Box8.h\"\n\n#ifndef Box8_NO_FONT\n") ||
+		!strings.Contains(cpp, "const GFXfont Box8 PROGMEM = {\n") ||
+		!strings.HasSuffix(cpp, "\n#endif // Box8_NO_FONT\n") {
+		t.Errorf("Box8.cpp does not define the font behind Box8_NO_FONT:\n%s", cpp)
+	}
+
+	var manifest struct {
+		Name, Description string
+		Dependencies      map[string]string
+	}
+	if err := json.Unmarshal([]byte(read("library.json")), &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Name != "Box8" || !strings.HasSuffix(manifest.Description, font.XLFD) ||
+		manifest.Dependencies["adafruit/Adafruit GFX Library"] != "*" {
+		t.Errorf("library.json = %+v", manifest)
+	}
+	if p := read("library.properties"); !strings.Contains(p, "name=Box8\n") || !strings.Contains(p, "includes=Box8.h\n") {
+		t.Errorf("library.properties:\n%s", p)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+// lvglCmap is a run of consecutive codes mapped to consecutive glyph ids,
+// an LV_FONT_FMT_TXT_CMAP_FORMAT0_TINY entry.
+type lvglCmap struct {
+	start, length, glyphID int
+}
+
+// generateLVGL writes the font as a C source file for LVGL 8 in the
+// format-1 (lv_font_fmt_txt) bitmap layout that lv_font_conv produces:
+// bitmaps packed continuously at the font's bits per pixel, a glyph
+// descriptor per glyph with id 0 reserved, and one cmap per run of
+// consecutive codes. Kerning is not emitted.
+func generateLVGL(filename string, font *gfx.Font, name string) {
+	bpp := max(font.BitsPerPixel, 1)
+
+	var bitmap []byte
+	var offsets []int
+	for _, g := range font.Glyphs {
+		offsets = append(offsets, len(bitmap))
+		bitmap = append(bitmap, lvglBitmap(g, bpp)...)
+	}
+	if len(bitmap) >= 1<<20 {
+		log.Fatalf("bitmap data of %d bytes does not fit the 20-bit LVGL bitmap_index", len(bitmap))
+	}
+	for _, g := range font.Glyphs {
+		switch {
+		case g.XAdvance < 0 || g.XAdvance*16 >= 1<<12:
+			log.Fatalf("glyph 0x%04X: advance %d does not fit LVGL adv_w", g.Code, g.XAdvance)
+		case g.Width > 0xFF || g.Height > 0xFF:
+			log.Fatalf("glyph 0x%04X: %dx%d does not fit LVGL box_w/box_h", g.Code, g.Width, g.Height)
+		case g.XOffset < -128 || g.XOffset > 127 || g.YOffset < -128 || g.YOffset > 127:
+			log.Fatalf("glyph 0x%04X: offset %d,%d does not fit LVGL ofs_x/ofs_y", g.Code, g.XOffset, g.YOffset)
+		}
+	}
+
+	var cmaps []lvglCmap
+	for i, g := range font.Glyphs {
+		if n := len(cmaps); n > 0 {
+			if c := &cmaps[n-1]; g.Code == c.start+c.length && c.length < 0xFFFF {
+				c.length++
+				continue
+			}
+		}
+		cmaps = append(cmaps, lvglCmap{g.Code, 1, i + 1})
+	}
+
+	var buf bytes.Buffer
+	if font.XLFD != "" {
+		safe, _ := gfx.SanitizeComment(font.XLFD)
+		fmt.Fprintf(&buf, "/* Converted from %s */\n\n", safe)
+	}
+	fmt.Fprint(&buf, "#ifdef LV_LVGL_H_INCLUDE_SIMPLE\n#include \"lvgl.h\"\n#else\n#include \"lvgl/lvgl.h\"\n#endif\n\n")
+
+	fmt.Fprintf(&buf, "static LV_ATTRIBUTE_LARGE_CONST const uint8_t %s_glyph_bitmap[] = {\n", name)
+	for i, g := range font.Glyphs {
+		end := len(bitmap)
+		if i+1 < len(offsets) {
+			end = offsets[i+1]
+		}
+		if offsets[i] == end {
+			continue
+		}
+		fmt.Fprintf(&buf, "    /* U+%04X */\n   ", g.Code)
+		for _, b := range bitmap[offsets[i]:end] {
+			fmt.Fprintf(&buf, " 0x%02X,", b)
+		}
+		fmt.Fprint(&buf, "\n")
+	}
+	if len(bitmap) == 0 {
+		fmt.Fprint(&buf, "    0x00, /* no glyph has bitmap data */\n")
+	}
+	fmt.Fprint(&buf, "};\n\n")
+
+	fmt.Fprintf(&buf, "static const lv_font_fmt_txt_glyph_dsc_t %s_glyph_dsc[] = {\n", name)
+	fmt.Fprint(&buf, "    {.bitmap_index = 0, .adv_w = 0, .box_w = 0, .box_h = 0, .ofs_x = 0, .ofs_y = 0} /* id = 0 reserved */,\n")
+	for i, g := range font.Glyphs {
+		fmt.Fprintf(&buf, "    {.bitmap_index = %d, .adv_w = %d, .box_w = %d, .box_h = %d, .ofs_x = %d, .ofs_y = %d}, /* U+%04X */\n",
+			offsets[i], g.XAdvance*16, g.Width, g.Height, g.XOffset, g.YOffset, g.Code)
+	}
+	fmt.Fprint(&buf, "};\n\n")
+
+	fmt.Fprintf(&buf, "static const lv_font_fmt_txt_cmap_t %s_cmaps[] = {\n", name)
+	for _, c := range cmaps {
+		fmt.Fprintf(&buf, "    {.range_start = %d, .range_length = %d, .glyph_id_start = %d, .unicode_list = NULL, .glyph_id_ofs_list = NULL, .list_length = 0, .type = LV_FONT_FMT_TXT_CMAP_FORMAT0_TINY},\n",
+			c.start, c.length, c.glyphID)
+	}
+	fmt.Fprint(&buf, "};\n\n")
+
+	fmt.Fprintf(&buf, "static lv_font_fmt_txt_glyph_cache_t %s_cache;\n\n", name)
+	fmt.Fprintf(&buf, "static const lv_font_fmt_txt_dsc_t %s_dsc = {\n", name)
+	fmt.Fprintf(&buf, "    .glyph_bitmap = %s_glyph_bitmap,\n", name)
+	fmt.Fprintf(&buf, "    .glyph_dsc = %s_glyph_dsc,\n", name)
+	fmt.Fprintf(&buf, "    .cmaps = %s_cmaps,\n", name)
+	fmt.Fprint(&buf, "    .kern_dsc = NULL,\n    .kern_scale = 0,\n")
+	fmt.Fprintf(&buf, "    .cmap_num = %d,\n", len(cmaps))
+	fmt.Fprintf(&buf, "    .bpp = %d,\n", bpp)
+	fmt.Fprint(&buf, "    .kern_classes = 0,\n    .bitmap_format = 0,\n")
+	fmt.Fprintf(&buf, "    .cache = &%s_cache\n};\n\n", name)
+
+	fmt.Fprintf(&buf, "const lv_font_t %s = {\n", name)
+	fmt.Fprint(&buf, "    .get_glyph_dsc = lv_font_get_glyph_dsc_fmt_txt,\n")
+	fmt.Fprint(&buf, "    .get_glyph_bitmap = lv_font_get_bitmap_fmt_txt,\n")
+	fmt.Fprintf(&buf, "    .line_height = %d,\n", font.YAdvance())
+	fmt.Fprintf(&buf, "    .base_line = %d,\n", font.Descent)
+	fmt.Fprint(&buf, "    .subpx = LV_FONT_SUBPX_NONE,\n")
+	fmt.Fprint(&buf, "    .underline_position = 0,\n    .underline_thickness = 0,\n")
+	fmt.Fprintf(&buf, "    .dsc = &%s_dsc\n};\n", name)
+
+	if err := writeOutput(filename, buf.Bytes()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// lvglBitmap packs the pixels of g row by row without padding, bpp bits
+// each, most significant bits first.
+func lvglBitmap(g *gfx.Glyph, bpp int) []byte {
+	var out []byte
+	var acc, n int
+	for y := 0; y < g.Height; y++ {
+		for x := 0; x < g.Width; x++ {
+			acc = acc<<bpp | g.Level(x, y, bpp)
+			n += bpp
+			if n == 8 {
+				out = append(out, byte(acc))
+				acc, n = 0, 0
+			}
+		}
+	}
+	if n > 0 {
+		out = append(out, byte(acc<<(8-n)))
+	}
+	return out
+}
@@ -2,182 +2,1108 @@ package main
 
 import (
 	"bufio"
-	"encoding/hex"
+	"bytes"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"io/fs"
 	"log"
 	"os"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
 )
 
-type Glyph struct {
-	code         int
-	width        int
-	height       int
-	xOffset      int
-	bbxY         int
-	xAdvance     int
-	bitmap       []byte
-	bitmapOffset int
-	yOffsetTFT   int
-}
+var (
+	werror           = flag.Bool("werror", false, "exit with an error if there were any warnings")
+	canonical        = flag.Bool("canonical", false, "normalize the formatting of C headers for reproducible diffs")
+	noClobber        = flag.Bool("n", false, "do not overwrite existing output files")
+	force            = flag.Bool("f", false, "overwrite existing output files even with -n")
+	verbose          = flag.Bool("v", false, "verbose output")
+	dump             = flag.String("dump", "", "print the metrics and bitmap of this glyph (codepoint) and exit")
+	dumpProps        = flag.Bool("dump-props", false, "print the STARTPROPERTIES block of the input and exit")
+	countOnly        = flag.Bool("count", false, "print the glyph count, range and coverage of the input and exit")
+	interactive      = flag.Bool("interactive", false, "browse the glyphs on the terminal and choose the ones to convert")
+	probe            = flag.Bool("probe", false, "print \"bdf\" or \"pcf\" and exit 0 if the input parses as a BDF or PCF font with glyphs, fail otherwise")
+	selftest         = flag.Bool("selftest", false, "run a built-in conversion check and print PASS or FAIL")
+	packReport       = flag.Bool("pack-report", false, "compare the bitmap size with and without row padding")
+	reportDuplicates = flag.Bool("report-duplicates", false, "report glyphs sharing identical bitmaps")
+	trimTrailing     = flag.Bool("trim-trailing-glyphs", false, "drop blank glyphs at the end of the range")
+	trimLeading      = flag.Bool("trim-leading-glyphs", false, "drop blank glyphs at the start of the range")
+	outputFormat     = flag.String("format", "gfx", "output format: gfx (C header), gfx-blocks, gfx-cmap, gfx-compact, gfx-planes, debug-c, lvgl, winfnt (Windows .FNT), otb (OpenType bitmap font), ssd1306-page (SSD1306 display memory), rust, go, json, md (Markdown table), svg (specimen image) or rawWxH (fixed cells, e.g. raw8x16)")
+	notdef           = flag.String("notdef", "", "emit this glyph (codepoint, glyph name or \"default\") first as the missing-glyph box")
+	bboxReport       = flag.Bool("bbox-report", false, "print the bounding box of all glyphs")
+	measure          = flag.String("measure", "", "print the pixel bounds of this string")
+	inBitOrder       = flag.String("in-bit-order", "msb", "bit order of the input bitmap rows: msb (per spec) or lsb")
+	inByteOrder      = flag.String("in-byte-order", "normal", "byte order of the input bitmap rows: normal (per spec) or reverse")
+	skipBadGlyphs    = flag.Bool("skip-bad-glyphs", false, "skip glyphs that fail to parse instead of aborting")
+	auditHex         = flag.Bool("audit-hex", false, "warn about bitmap lines whose hex letter case differs from the rest of the file")
+	validateNames    = flag.Bool("validate-names", false, "fail on glyph names that are not safe to put in C comments")
+	validateBBX      = flag.Bool("validate-bbx", false, "warn about glyphs whose BBX is outside the FONTBOUNDINGBOX")
+	validateEncoding = flag.Bool("validate-encoding", false, "report out of order and duplicate ENCODING values")
+	repairEncoding   = flag.Bool("repair", false, "drop glyphs with duplicate ENCODING values, keeping the first")
+	name             = flag.String("name", "Font", "symbol name of the generated font; derived from the XLFD name when not given")
+	goPackage        = flag.String("go-package", "fonts", "package name for -format=go")
+	goTypes          = flag.Bool("go-types", true, "emit the GFXGlyph type for -format=go; disable when several fonts share a package")
+	warnEmptyGlyph   = flag.Bool("warn-on-empty-glyph", false, "warn about glyphs with a non-empty BBX but no set pixels, except spaces")
+	requireMonospace = flag.Bool("require-monospace", false, "fail unless all glyphs with a non-zero advance share the same advance")
+	verifyPNG        = flag.String("verify-png-dir", "", "fail if a glyph differs from its reference image <code>.png in this directory")
+	assertRange      = flag.String("assert-range", "", "fail if any of these codepoints has no glyph, e.g. 0x20-0x7E")
+	codeRange        = flag.String("range", "", "keep only these codepoints, e.g. 0x20-0x7E,0xB0")
+	preset           = flag.String("preset", "", "keep a predefined subset: ascii or ascii+symbols")
+	uppercaseOnly    = flag.Bool("uppercase-only", false, "keep only A-Z and the -subset-extra characters")
+	lowercaseOnly    = flag.Bool("lowercase-only", false, "keep only a-z and the -subset-extra characters")
+	subsetExtra      = flag.String("subset-extra", " 0123456789", "characters kept in addition to the letters by -uppercase-only/-lowercase-only")
+	keepControlChars = flag.Bool("keep-control-chars", false, "keep the glyphs of control codes below 0x20, which are dropped by default")
+	alignCap         = flag.Int("align-cap-height", -1, "shift glyphs so the cap line is this many pixels above the baseline (-1 disables)")
+	rebaseFirst      = flag.Int("rebase-first", -1, "renumber glyphs so the first one has this code (-1 disables)")
+	padFirstToZero   = flag.Bool("pad-first-to-zero", false, "add empty glyphs from code 0 so the glyph table is indexed by the codepoint itself")
+	chars            = flag.String("chars", "", "keep only the characters of this string")
+	fallbackFont     = flag.String("fallback", "", "also emit this BDF as <name>Fallback and a <name>GlyphFor helper that tries both fonts")
+	templateFile     = flag.String("template", "", "keep only the characters of the string literals in this C/C++ source file")
+	charsFile        = flag.String("chars-file", "", "keep only the characters found in this file")
+	charsEncoding    = flag.String("chars-encoding", "utf-8", "charset of -chars-file: utf-8, latin1, utf-16le or utf-16be")
+	maxWidth         = flag.Int("max-width", 0, "drop glyphs wider than this (0 disables)")
+	maxHeight        = flag.Int("max-height", 0, "drop glyphs taller than this (0 disables)")
+	latin1ToUnicode  = flag.Bool("latin1-to-unicode", false, "take the glyph codes as ISO8859-1 whatever the charset properties say, and remap them to Unicode")
+	toUnicode        = flag.Bool("to-unicode", false, "remap glyph codes of legacy 8-bit charset fonts to Unicode")
+	lineGap          = flag.Int("line-gap", 0, "pixels added to yAdvance between lines")
+	wideAdvance      = flag.Bool("wide-advance", false, "use a uint16_t glyph xAdvance (needs a matching renderer)")
+	mergeAdvances    = flag.Bool("merge-duplicate-advances", false, "experimental: store a uint8_t index into a table of the distinct advances instead of xAdvance (needs a matching renderer)")
+	vertical         = flag.Bool("vertical", false, "add the vertical metrics from DWIDTH1 and VVECTOR and a <name>DrawVertical helper for top-to-bottom text")
+	wide             = flag.Bool("wide", false, "use uint16_t glyph width and height (needs a matching renderer)")
+	precomposeMap    = flag.String("precompose", "", "synthesize precomposed glyphs from a map file of \"target base mark\" lines")
+	stripAccents     = flag.Bool("strip-accents", false, "drop accented Latin letters, or move them to their missing ASCII base letter")
+	normalizeAdvance = flag.Int("normalize-advance", -1, "set advances that end inside the ink to xOffset+width plus this spacing (-1 disables)")
+	minAdvance       = flag.Int("min-advance", 0, "raise positive advances below this to it (0 disables)")
+	adobeNames       = flag.Bool("adobe-names", false, "accept Adobe glyph names such as space as ENCODING values")
+	clampAdvance     = flag.Bool("clamp-advance", false, "set negative advances to zero, except in -format=json")
+	targetHeight     = flag.Int("target-height", 0, "resample the font to this pixel height with nearest-neighbor sampling (0 disables)")
+	emitGlyphCRC     = flag.Bool("emit-glyph-crc", false, "add a CRC-8 of every glyph bitmap and a <name>GlyphCRCOk check")
+	emitDefines      = flag.Bool("emit-defines", false, "add <name>_FIRST, _LAST, _GLYPH_COUNT and _Y_ADVANCE defines")
+	emitCoverage     = flag.Bool("emit-coverage-bitset", false, "add a <name>Coverage bitset of the codes that have a glyph and a <name>HasGlyph helper")
+	bold             = flag.Bool("bold", false, "fake a bold weight by widening every glyph by one pixel")
+	italic           = optionalFloatFlag("italic", 0.2, "slant every glyph by this many pixels per row above the baseline, 0.2 without a value (use -italic=<slope>)")
+	rotate           = flag.Int("rotate", 0, "rotate every glyph clockwise by 90, 180 or 270 degrees")
+	dither           = flag.String("dither", "threshold", "reduce the gray levels of grayscale fonts to 1 bit with threshold, floyd-steinberg or ordered")
+	normalizeOffsets = flag.Bool("normalize-offsets", false, "move the ink of glyphs with a negative xOffset right to the pen and widen their advance to match")
+	bakeXOffset      = flag.Bool("bake-xoffset", false, "move positive xOffsets into the bitmaps")
+	fillMissing      = flag.String("fill-missing", "empty", "glyphs for the codes without one inside the glyph table: empty (zero-advance placeholders) or box (visible rectangle outlines)")
+	blockGap         = flag.Int("block-gap", 16, "for -format=gfx-blocks, start a new block after this many missing codes")
+	bitmapOrderFlag  = flag.String("bitmap-order", "code", "order of the bitmaps of a C header: code, frequency to put the glyphs of -frequency-file first, or block to group them by Unicode block")
+	maxBytes         = flag.Int("max-bytes", 0, "drop glyphs, least important first, until the C header fits this many bytes of flash (0 disables)")
+	priorityFile     = flag.String("priority-file", "", "file of codepoints, most important first, that -max-bytes keeps in this order")
+	frequencyFile    = flag.String("frequency-file", "", "usage frequency file of \"code count\" lines for -bitmap-order=frequency or block")
+	orientation      = flag.String("orientation", "row", "bitmap layout of the C header: row (GFX) or column (SSD1306 pages, needs a matching renderer)")
+	compress         = flag.String("compress", "", "bitmap compression for the C header: delta or huffman (needs a matching renderer)")
+	emitSizeDefine   = flag.Bool("emit-size-define", false, "add a <name>_FLASH_BYTES define with the flash footprint to the C header")
+	emitOffsets      = flag.Bool("emit-offsets", false, "add an array of the glyph bitmap offsets to the C header")
+	emitNames        = flag.Bool("emit-names", false, "add an array of the glyph names to the C header")
+	pretty           = flag.Bool("pretty", false, "size the glyph table columns to fit the values")
+	designatedInit   = flag.Bool("designated-init", false, "write the glyph table with C99 designated initializers")
+	metricsOut       = flag.String("metrics-out", "", "write the glyph table and font struct of a C header to this file; needs -bitmaps-out")
+	bitmapsOut       = flag.String("bitmaps-out", "", "write the bitmaps of a C header to this file; needs -metrics-out")
+	manifest         = flag.String("manifest", "", "write a header to this file that includes the given C headers and lists their fonts in a <name> array, then exit")
+	library          = flag.String("library", "", "write the font as an Arduino/PlatformIO library into this directory instead of an output file")
+	hexOut           = flag.String("hex-out", "", "also write the bitmap bytes of a C header as hex to this file")
+	hexFormat        = flag.String("hex-format", "plain", "format of -hex-out: plain or ihex (Intel HEX)")
+	grid             = flag.String("grid", "", "write fixed WxH cells with every glyph centered, instead of -format")
+	atlas            = flag.String("atlas", "", "also write every glyph as ASCII art in a text grid to this file")
+	atlasColumns     = flag.Int("atlas-columns", 16, "glyphs per row of -atlas")
+	sheet            = flag.String("sheet", "", "also write every glyph into a PNG sprite sheet with this name")
+	sheetJSON        = flag.String("sheet-json", "", "write the positions of the glyphs in the -sheet PNG as JSON to this file")
+	sheetColumns     = flag.Int("sheet-columns", 16, "glyphs per row of -sheet")
+	emitSketch       = flag.String("emit-sketch", "", "also write an Arduino sketch that draws the font with Adafruit_GFX to this file")
+	sketchDisplay    = flag.String("sketch-display", "ssd1306", "display of -emit-sketch: ssd1306, sh1106, st7735, st7789 or ili9341")
+	sketchText       = flag.String("sketch-text", "The quick brown fox jumps over the lazy dog", "specimen string of -emit-sketch, drawn before the glyphs")
+	svgText          = flag.String("svg-text", "The quick brown fox jumps over the lazy dog", "specimen string of -format=svg")
+	svgScale         = flag.Int("svg-scale", 4, "size of a font pixel in -format=svg, in SVG pixels")
+	outputWidth      = flag.Int("output-width", 0, "truncate glyph table comments to end at this column (0 disables)")
+	alignOffset      = flag.Int("align-offset", 1, "pad the bitmaps so every glyph offset is a multiple of this (gfx and debug-c)")
+)
 
 func main() {
-	if len(os.Args) != 3 {
-		log.Fatal("Usage: bdf2tft <input.bdf> <output.h>")
+	log.SetOutput(fatalOutput{})
+	run()
+	finish()
+}
+
+func run() {
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		diffMain(os.Args[2:])
+		return
 	}
 
-	inputFile := os.Args[1]
-	outputFile := os.Args[2]
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: bdf2tft [flags] <input.bdf>... <output.h>")
+		fmt.Fprintln(flag.CommandLine.Output(), "       bdf2tft -metrics-out <metrics.h> -bitmaps-out <bitmaps.c> [flags] <input.bdf>...")
+		fmt.Fprintln(flag.CommandLine.Output(), "       bdf2tft -library <dir> [flags] <input.bdf>...")
+		fmt.Fprintln(flag.CommandLine.Output(), "       bdf2tft -manifest <fonts.h> [-name <family>] <font.h>...")
+		fmt.Fprintln(flag.CommandLine.Output(), "       bdf2tft -count <input.bdf>")
+		fmt.Fprintln(flag.CommandLine.Output(), "       bdf2tft -probe <file>")
+		fmt.Fprintln(flag.CommandLine.Output(), "       bdf2tft -dump-props <input.bdf>")
+		fmt.Fprintln(flag.CommandLine.Output(), "       bdf2tft -dump=<code> [flags] <input.bdf>")
+		fmt.Fprintln(flag.CommandLine.Output(), "       bdf2tft diff [-diff-json] <a> <b>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *selftest {
+		runSelfTest()
+		return
+	}
+	if *probe && flag.NArg() == 1 {
+		probeBDF(flag.Arg(0))
+		return
+	}
+	if *manifest != "" {
+		if flag.NArg() == 0 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		family := "FontFamily"
+		if flagSet("name") {
+			family = *name
+		}
+		writeManifest(*manifest, family, flag.Args())
+		return
+	}
+	if *countOnly && flag.NArg() == 1 {
+		printCount(flag.Arg(0))
+		return
+	}
+	if *dumpProps && flag.NArg() == 1 {
+		printProperties(flag.Arg(0))
+		return
+	}
+	if *dump != "" && flag.NArg() == 1 {
+		dumpGlyph(parseBDF(flag.Arg(0)), *dump)
+		return
+	}
+	split := *metricsOut != "" || *bitmapsOut != ""
+	if split && (*metricsOut == "" || *bitmapsOut == "") {
+		log.Fatal("-metrics-out and -bitmaps-out must be given together")
+	}
+	if split && *library != "" {
+		log.Fatal("-library cannot be combined with -metrics-out and -bitmaps-out")
+	}
+	inputs := flag.Args()
+	outputFile := ""
+	if !split && *library == "" {
+		if len(inputs) < 2 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		inputs, outputFile = inputs[:len(inputs)-1], inputs[len(inputs)-1]
+	} else if len(inputs) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	inputFile := inputs[0]
 
-	fontAscent, fontDescent, glyphs := parseBDF(inputFile)
-	generateHeader(outputFile, fontAscent, fontDescent, glyphs)
-}
+	font := loadInput(inputFile)
+	for _, input := range inputs[1:] {
+		conflicts, err := font.Merge(loadInput(input))
+		if err != nil {
+			log.Fatalf("%s: %v", input, err)
+		}
+		if len(conflicts) > 0 {
+			warnf("%s: %d glyphs already defined by an earlier input were ignored", input, len(conflicts))
+		}
+		fmt.Printf("Merged %s: %d glyphs\n", input, len(font.Glyphs))
+	}
+	if *precomposeMap != "" {
+		precompose(font, *precomposeMap)
+	}
+	if *stripAccents {
+		remapped, dropped, err := font.StripAccents()
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, r := range remapped {
+			fmt.Printf("  U+%04X -> %q\n", r.From, rune(r.To))
+		}
+		if *verbose {
+			for _, g := range dropped {
+				fmt.Printf("  U+%04X dropped\n", g.Code)
+			}
+		}
+		fmt.Printf("Stripped accents: %d glyphs remapped to their base letter, %d dropped\n", len(remapped), len(dropped))
+	}
+	if *assertRange != "" {
+		ranges, err := gfx.ParseRanges(*assertRange)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if missing := font.Missing(ranges); len(missing) > 0 {
+			log.Fatalf("%s: missing codepoints %s", inputFile, missing)
+		}
+	}
+	if *reportDuplicates {
+		printDuplicateReport(font.Glyphs, *verbose)
+	}
+	filterGlyphs(font, *notdef)
+	if *interactive {
+		selectInteractive(font)
+	}
+	if *warnEmptyGlyph {
+		if inkless := font.InklessGlyphs(); len(inkless) > 0 {
+			var list []string
+			for _, g := range inkless {
+				list = append(list, fmt.Sprintf("0x%04X (%dx%d)", g.Code, g.Width, g.Height))
+			}
+			warnf("%d glyphs have a BBX but no set pixels: %s", len(inkless), strings.Join(list, ", "))
+		}
+	}
+	if *requireMonospace {
+		if advance, offenders := font.Monospace(); len(offenders) > 0 {
+			var list []string
+			for _, g := range offenders {
+				list = append(list, fmt.Sprintf("0x%04X (%d)", g.Code, g.XAdvance))
+			}
+			log.Fatalf("%s: not monospace, %d glyphs differ from the advance %d: %s", inputFile, len(offenders), advance, strings.Join(list, ", "))
+		}
+	}
+	if *measure != "" {
+		width, ascent, descent := font.MeasureString(*measure)
+		fmt.Printf("Bounds of %q: width %d, ascent %d, descent %d\n", *measure, width, ascent, descent)
+	}
+	if *bboxReport {
+		minX, maxX, minY, maxY := font.InkBounds()
+		fmt.Printf("Ink bounds: x %d..%d, y %d..%d (%dx%d pixels)\n", minX, maxX, minY, maxY, maxX-minX, maxY-minY)
+	}
+	if *trimTrailing || *trimLeading {
+		n := font.TrimBlank(*trimLeading, *trimTrailing)
+		if len(font.Glyphs) == 0 {
+			log.Fatal("No glyphs left after trimming blank glyphs")
+		}
+		first, last := font.Range()
+		fmt.Printf("Trimmed %d blank glyphs, range is now 0x%04X-0x%04X\n", n, first, last)
+	}
+	if *targetHeight > 0 {
+		from := font.Ascent + font.Descent
+		if from > 0 && *targetHeight%from != 0 && from%*targetHeight != 0 {
+			warnf("resampling from %d to %d pixels is not an integer ratio, strokes will have uneven widths", from, *targetHeight)
+		}
+		if err := font.Resample(*targetHeight); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Resampled glyphs from %d to %d pixels high\n", from, *targetHeight)
+	}
+	if *bold {
+		font.Embolden()
+		fmt.Println("Emboldened glyphs")
+	}
+	if italic.set {
+		font.Shear(italic.value)
+		fmt.Printf("Slanted glyphs by %g pixels per row\n", italic.value)
+	}
+	if *rotate != 0 {
+		if err := font.Rotate(*rotate); err != nil {
+			log.Fatal(err)
+		}
+		if *rotate != 180 {
+			warnf("-rotate=%d keeps the horizontal advances; check them for your layout by hand", *rotate)
+		}
+		fmt.Printf("Rotated glyphs by %d degrees\n", *rotate)
+	}
+	if *dither != "threshold" {
+		method, err := gfx.ParseDither(*dither)
+		if err != nil {
+			log.Fatal(err)
+		}
+		switch {
+		case font.BitsPerPixel <= 1:
+			warnf("-dither=%s has no effect, the font has no gray levels", *dither)
+		case *outputFormat == "gfx-planes" || *outputFormat == "lvgl":
+			warnf("-dither=%s has no effect, -format=%s keeps the gray levels", *dither, *outputFormat)
+		default:
+			fmt.Printf("Dithered %d glyphs to 1 bit per pixel\n", font.Dither(method))
+		}
+	}
+	if *normalizeOffsets {
+		changed := font.NormalizeXOffsets()
+		if len(changed) > 0 {
+			var list []string
+			for _, c := range changed {
+				list = append(list, fmt.Sprintf("0x%04X (%d -> %d)", c.Code, c.From, c.To))
+			}
+			warnf("-normalize-offsets widened the advances of %d glyphs with a negative xOffset: %s", len(changed), strings.Join(list, ", "))
+		}
+		for _, g := range font.Glyphs {
+			if g.XOffset < 0 && g.Width > 0 && g.Height > 0 {
+				warnf("glyph 0x%04X has a negative xOffset (%d) but an advance of %d and was left alone", g.Code, g.XOffset, g.XAdvance)
+			}
+		}
+	}
+	if *bakeXOffset {
+		for _, g := range font.Glyphs {
+			if !g.BakeXOffset() {
+				warnf("glyph 0x%04X has a negative xOffset (%d) and was not baked", g.Code, g.XOffset)
+			}
+		}
+	}
+	if *alignCap >= 0 {
+		shift, err := font.AlignCapHeight(*alignCap)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Shifted glyphs by %d pixels to align the cap height\n", shift)
+	}
+	if *maxBytes > 0 {
+		var priority []int
+		if *priorityFile != "" {
+			priority = readPriorityFile(*priorityFile)
+		}
+		fitBudget(font, *maxBytes, priority)
+	} else if *priorityFile != "" {
+		warnf("-priority-file has no effect without -max-bytes")
+	}
+	if *rebaseFirst >= 0 {
+		font.Rebase(*rebaseFirst)
+		first, last := font.Range()
+		fmt.Printf("Rebased codes by 0x%X, range is now 0x%04X-0x%04X\n", font.CodeBase, first, last)
+	}
+	if *padFirstToZero {
+		if *rebaseFirst >= 0 {
+			log.Fatal("-pad-first-to-zero cannot be combined with -rebase-first")
+		}
+		if *notdef != "" {
+			log.Fatal("-pad-first-to-zero cannot be combined with -notdef, which takes the code below the first glyph")
+		}
+		if n := padToZero(font); n > 0 {
+			layout := &gfx.Header{Wide: *wide, WideAdvance: *wideAdvance, AdvanceIndex: *mergeAdvances}
+			warnf("-pad-first-to-zero adds %d empty glyphs for 0x0000-0x%04X, %d bytes of glyph table", n, n-1, layout.GlyphSize()*n)
+		}
+	}
+	if *notdef != "" {
+		synthesized, err := font.MoveNotdefFirst(*notdef)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if synthesized {
+			warnf("notdef glyph %q not found, using an empty box", *notdef)
+		}
+	}
 
-func parseBDF(filename string) (int, int, []*Glyph) {
-	file, err := os.Open(filename)
-	if err != nil {
-		log.Fatal(err)
+	if !flagSet("name") {
+		if derived := font.SymbolName(); derived != "" {
+			*name = derived
+		}
 	}
-	defer file.Close()
 
-	var fontAscent, fontDescent int
-	var glyphs []*Glyph
-	var currentGlyph *Glyph
-	insideGlyph := false
-	insideBitmap := false
-	var bytesPerRow int
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		if insideGlyph && insideBitmap {
-			line = strings.TrimSpace(line)
-			if line == "ENDCHAR" {
-				currentGlyph.yOffsetTFT = -(currentGlyph.bbxY + currentGlyph.height)
-				glyphs = append(glyphs, currentGlyph)
-				insideGlyph = false
-				insideBitmap = false
-				continue
-			}
+	font.LineGap = *lineGap
 
-			rowBytes, err := hex.DecodeString(line)
-			if err != nil {
-				log.Fatalf("Hex decode error: %v", err)
+	if *normalizeAdvance >= 0 {
+		changed := font.NormalizeAdvances(*normalizeAdvance)
+		for _, c := range changed {
+			fmt.Printf("  0x%04X: advance %d -> %d\n", c.Code, c.From, c.To)
+		}
+		fmt.Printf("Normalized %d advances\n", len(changed))
+	}
+	if *minAdvance != 0 {
+		limit := 0xFF
+		if *wideAdvance {
+			limit = 0xFFFF
+		}
+		if *minAdvance < 0 || *minAdvance > limit {
+			log.Fatalf("Invalid -min-advance %d, want 0-%d", *minAdvance, limit)
+		}
+		changed := font.MinAdvance(*minAdvance)
+		if *verbose {
+			for _, c := range changed {
+				fmt.Printf("  0x%04X: advance %d -> %d\n", c.Code, c.From, c.To)
 			}
-			if len(rowBytes) != bytesPerRow {
-				log.Fatalf("Expected %d bytes, got %d", bytesPerRow, len(rowBytes))
+		}
+		fmt.Printf("Raised %d advances to %d\n", len(changed), *minAdvance)
+	}
+	if *clampAdvance && *outputFormat != "json" {
+		for _, g := range font.ClampAdvances() {
+			warnf("glyph 0x%04X: negative advance clamped to 0", g.Code)
+		}
+	}
+
+	if *packReport {
+		printPackReport(font.Glyphs, *verbose)
+	}
+
+	if *wide {
+		warnf("-wide changes the GFXglyph layout; standard GFX renderers will misread the glyph table")
+	}
+	if *wideAdvance {
+		warnf("-wide-advance changes the GFXglyph layout; it needs a GFX fork with a uint16_t xAdvance")
+	}
+
+	if *vertical {
+		if *outputFormat != "gfx" && *outputFormat != "gfx-blocks" && *outputFormat != "gfx-cmap" && *outputFormat != "debug-c" {
+			log.Fatal("-vertical only applies to -format=gfx, gfx-blocks, gfx-cmap and debug-c")
+		}
+		if !font.HasVerticalMetrics() {
+			warnf("%s has no DWIDTH1 or VVECTOR; -vertical advances by the line height from the top center of every glyph cell", inputFile)
+		}
+	}
+
+	if *mergeAdvances {
+		warnf("-merge-duplicate-advances changes the GFXglyph layout; it needs a renderer that reads the advance through <name>GlyphAdvance")
+	}
+
+	if *alignOffset < 1 {
+		log.Fatalf("Invalid -align-offset %d", *alignOffset)
+	}
+	if *alignOffset > 1 {
+		if *outputFormat != "gfx" && *outputFormat != "gfx-blocks" && *outputFormat != "gfx-cmap" && *outputFormat != "gfx-planes" && *outputFormat != "debug-c" {
+			warnf("-align-offset only applies to -format=gfx and debug-c")
+		} else {
+			blob, _ := gfx.PackGlyphs(font.Glyphs, *alignOffset)
+			unaligned, _ := gfx.PackGlyphs(font.Glyphs, 1)
+			fmt.Printf("Aligned glyph offsets to %d bytes, %d bytes of padding\n", *alignOffset, len(blob)-len(unaligned))
+		}
+	}
+
+	if *atlas != "" {
+		if *atlasColumns < 1 {
+			log.Fatalf("Invalid -atlas-columns %d", *atlasColumns)
+		}
+		writeAtlas(*atlas, font, *atlasColumns)
+	}
+	if *sheetJSON != "" && *sheet == "" {
+		log.Fatal("-sheet-json needs -sheet")
+	}
+	if *sheet != "" {
+		if *sheetColumns < 1 {
+			log.Fatalf("Invalid -sheet-columns %d", *sheetColumns)
+		}
+		writeSheet(*sheet, *sheetJSON, font, *sheetColumns)
+	}
+
+	if *grid != "" {
+		checkNotSplit()
+		var width, height int
+		if n, _ := fmt.Sscanf(*grid, "%dx%d", &width, &height); n != 2 || width <= 0 || height <= 0 {
+			log.Fatalf("Invalid -grid %q, want WxH", *grid)
+		}
+		generateRawCells(outputFile, font, *name, width, height, true)
+		return
+	}
+
+	if *verifyPNG != "" {
+		verifyPNGDir(font, *verifyPNG)
+	}
+
+	switch *outputFormat {
+	case "gfx", "gfx-planes", "debug-c", "rust", "go":
+		// Values out of range are left to the header writer, which knows
+		// the layout chosen by -wide and friends; the other errors, such
+		// as bitmaps that do not match their BBX, no layout can hold.
+		// Glyphs outside FONTBOUNDINGBOX are only reported with
+		// -validate-bbx.
+		for _, d := range font.Validate() {
+			switch {
+			case d.Severity == gfx.SeverityError && errors.Is(d.Err, gfx.ErrFieldRange):
+			case d.Severity == gfx.SeverityError && errors.Is(d.Err, gfx.ErrNegativeAdvance):
+				log.Fatalf("glyph 0x%04X: %v, use -clamp-advance", d.Code, d.Err)
+			case d.Severity == gfx.SeverityError && d.Code >= 0:
+				log.Fatalf("glyph 0x%04X: %v", d.Code, d.Err)
+			case d.Severity == gfx.SeverityError:
+				log.Fatal(d.Err)
+			case errors.Is(d.Err, gfx.ErrOutsideBBX):
+			case errors.Is(d.Err, gfx.ErrTablePadding):
+				warnf("%v, consider -format=gfx-blocks or gfx-cmap", d.Err)
+			case d.Code >= 0:
+				warnf("glyph 0x%04X: %v", d.Code, d.Err)
+			default:
+				warnf("%v", d.Err)
 			}
-			currentGlyph.bitmap = append(currentGlyph.bitmap, rowBytes...)
-			continue
 		}
+	}
 
-		fields := strings.Fields(line)
-		if len(fields) == 0 {
-			continue
+	switch *compress {
+	case "":
+	case "delta":
+		if *outputFormat != "gfx" && *outputFormat != "gfx-blocks" && *outputFormat != "gfx-cmap" && *outputFormat != "debug-c" {
+			log.Fatal("-compress=delta only applies to -format=gfx, gfx-blocks, gfx-cmap and debug-c")
+		}
+		printDeltaReport(font.Glyphs)
+	case "huffman":
+		if *outputFormat != "gfx" && *outputFormat != "gfx-blocks" && *outputFormat != "gfx-cmap" && *outputFormat != "debug-c" {
+			log.Fatal("-compress=huffman only applies to -format=gfx, gfx-blocks, gfx-cmap and debug-c")
+		}
+		printHuffmanReport(font.Glyphs)
+	default:
+		log.Fatalf("Unknown -compress %q", *compress)
+	}
+
+	if *mergeAdvances {
+		if *outputFormat != "gfx" && *outputFormat != "gfx-blocks" && *outputFormat != "gfx-cmap" && *outputFormat != "debug-c" {
+			log.Fatal("-merge-duplicate-advances only applies to -format=gfx, gfx-blocks, gfx-cmap and debug-c")
+		}
+		if *fallbackFont != "" {
+			log.Fatal("-merge-duplicate-advances cannot be combined with -fallback")
+		}
+		printAdvanceIndexReport(font, *wideAdvance)
+	}
+
+	switch *orientation {
+	case "row":
+	case "column":
+		if *outputFormat != "gfx" && *outputFormat != "gfx-blocks" && *outputFormat != "gfx-cmap" && *outputFormat != "debug-c" {
+			log.Fatal("-orientation=column only applies to -format=gfx, gfx-blocks, gfx-cmap and debug-c")
 		}
+	default:
+		log.Fatalf("Unknown -orientation %q", *orientation)
+	}
 
-		switch fields[0] {
-		case "FONT_ASCENT":
-			fontAscent, _ = strconv.Atoi(fields[1])
-		case "FONT_DESCENT":
-			fontDescent, _ = strconv.Atoi(fields[1])
-		case "STARTCHAR":
-			currentGlyph = &Glyph{}
-			insideGlyph = true
-		case "ENCODING":
-			if insideGlyph {
-				code, _ := strconv.Atoi(fields[1])
-				currentGlyph.code = code
+	var hot []int
+	switch *bitmapOrderFlag {
+	case "code":
+	case "frequency":
+		if *outputFormat != "gfx" && *outputFormat != "gfx-blocks" && *outputFormat != "gfx-cmap" && *outputFormat != "gfx-planes" && *outputFormat != "debug-c" {
+			log.Fatal("-bitmap-order=frequency only applies to -format=gfx, gfx-blocks, gfx-cmap, gfx-planes and debug-c")
+		}
+		if *frequencyFile == "" {
+			log.Fatal("-bitmap-order=frequency needs -frequency-file")
+		}
+		// The file has real codepoints, the glyphs may have been rebased.
+		for _, code := range readFrequencyFile(*frequencyFile) {
+			if _, ok := font.Glyph(code - font.CodeBase); ok {
+				hot = append(hot, code-font.CodeBase)
 			}
-		case "DWIDTH":
-			if insideGlyph {
-				xAdvance, _ := strconv.Atoi(fields[1])
-				currentGlyph.xAdvance = xAdvance
+		}
+		fmt.Printf("Placed the bitmaps of %d glyphs from %s first\n", len(hot), *frequencyFile)
+	case "block":
+		if *outputFormat != "gfx" && *outputFormat != "gfx-blocks" && *outputFormat != "gfx-cmap" && *outputFormat != "gfx-planes" && *outputFormat != "debug-c" {
+			log.Fatal("-bitmap-order=block only applies to -format=gfx, gfx-blocks, gfx-cmap, gfx-planes and debug-c")
+		}
+		var used []int
+		if *frequencyFile != "" {
+			for _, code := range readFrequencyFile(*frequencyFile) {
+				if _, ok := font.Glyph(code - font.CodeBase); ok {
+					used = append(used, code-font.CodeBase)
+				}
+			}
+		}
+		hot = blockBitmapOrder(font, used)
+		printBlockLayout(font, hot, *alignOffset)
+	default:
+		log.Fatalf("Unknown -bitmap-order %q", *bitmapOrderFlag)
+	}
+
+	header := &gfx.Header{
+		Font:          font,
+		Name:          *name,
+		Wide:          *wide,
+		WideAdvance:   *wideAdvance,
+		AdvanceIndex:  *mergeAdvances,
+		Align:         *alignOffset,
+		CommentWidth:  *outputWidth,
+		GlyphNames:    *emitNames,
+		Offsets:       *emitOffsets,
+		Pretty:        *pretty,
+		Designated:    *designatedInit,
+		Delta:         *compress == "delta",
+		Huffman:       *compress == "huffman",
+		Columns:       *orientation == "column",
+		BitmapOrder:   hot,
+		SizeDefine:    *emitSizeDefine,
+		Coverage:      *emitCoverage,
+		MetricDefines: *emitDefines,
+		GlyphCRC:      *emitGlyphCRC,
+		Vertical:      *vertical,
+	}
+	switch *fillMissing {
+	case "empty":
+	case "box":
+		switch *outputFormat {
+		case "gfx", "gfx-blocks", "debug-c":
+			header.MissingBox = true
+		case "gfx-cmap":
+			warnf("-fill-missing=box has no effect, -format=gfx-cmap has no entries for missing codes")
+		default:
+			log.Fatalf("-fill-missing=box only applies to -format=gfx, gfx-blocks and debug-c")
+		}
+	default:
+		log.Fatalf("Unknown -fill-missing %q", *fillMissing)
+	}
+	if *emitSketch != "" {
+		if *outputFormat != "gfx" && *outputFormat != "debug-c" {
+			log.Fatal("-emit-sketch only applies to -format=gfx and debug-c, which Adafruit_GFX can draw")
+		}
+		headerFile := outputFile
+		if *metricsOut != "" {
+			headerFile = *metricsOut
+		}
+		if *library != "" {
+			headerFile = filepath.Join(*library, "src", *name+".h")
+		}
+		writeSketch(*emitSketch, headerFile, font, *name, *sketchDisplay, *sketchText)
+	}
+	if *library != "" {
+		if *outputFormat != "gfx" && *outputFormat != "debug-c" {
+			log.Fatal("-library only applies to -format=gfx and debug-c, which define a GFXfont")
+		}
+		if *fallbackFont != "" {
+			log.Fatal("-library cannot be combined with -fallback")
+		}
+		checkNotSplit()
+		header.DebugArrays = *outputFormat == "debug-c"
+		writeLibrary(*library, header)
+		return
+	}
+	if *fallbackFont != "" {
+		if *outputFormat != "gfx" {
+			log.Fatal("-fallback only applies to -format=gfx")
+		}
+		checkNotSplit()
+		fallback := loadInput(*fallbackFont)
+		filterGlyphs(fallback, "")
+		fallbackHeader := *header
+		fallbackHeader.Font = fallback
+		fallbackHeader.Name = *name + "Fallback"
+		writeFile(outputFile, &fallbackChain{header, &fallbackHeader})
+		return
+	}
+
+	switch *outputFormat {
+	case "gfx":
+		writeHeader(outputFile, header)
+	case "gfx-blocks":
+		header.Blocks = true
+		header.BlockGap = *blockGap
+		writeHeader(outputFile, header)
+	case "gfx-cmap":
+		header.CodeMap = true
+		writeHeader(outputFile, header)
+	case "gfx-compact":
+		checkNotSplit()
+		printCompactReport(font)
+		header.Compact = true
+		writeHeader(outputFile, header)
+	case "gfx-planes":
+		header.Planes = true
+		writeHeader(outputFile, header)
+	case "debug-c":
+		header.DebugArrays = true
+		writeHeader(outputFile, header)
+	case "winfnt":
+		checkNotSplit()
+		generateWinFNT(outputFile, font, *name)
+	case "otb":
+		checkNotSplit()
+		generateOTB(outputFile, font, *name)
+	case "ssd1306-page":
+		checkNotSplit()
+		generateSSD1306(outputFile, font, *name)
+	case "lvgl":
+		checkNotSplit()
+		generateLVGL(outputFile, font, *name)
+	case "rust":
+		checkNotSplit()
+		generateRust(outputFile, font, strings.ToUpper(*name))
+	case "go":
+		checkNotSplit()
+		generateGo(outputFile, font, *name, *goPackage, *goTypes)
+	case "json":
+		checkNotSplit()
+		generateJSON(outputFile, font)
+	case "svg":
+		checkNotSplit()
+		if *svgScale <= 0 {
+			log.Fatalf("Invalid -svg-scale %d", *svgScale)
+		}
+		generateSVG(outputFile, font, *svgText, *svgScale)
+	case "md":
+		checkNotSplit()
+		generateMarkdown(outputFile, font)
+	default:
+		var width, height int
+		if n, _ := fmt.Sscanf(*outputFormat, "raw%dx%d", &width, &height); *outputFormat == "raw" {
+			width, height = 8, 16
+		} else if n != 2 || width <= 0 || height <= 0 {
+			log.Fatalf("Unknown output format %q", *outputFormat)
+		}
+		checkNotSplit()
+		generateRawCells(outputFile, font, *name, width, height, false)
+	}
+}
+
+// optionalFloat is a float flag that may also be given without a value,
+// which then stands for def. Like a boolean flag, a value must be attached
+// with "=".
+type optionalFloat struct {
+	value, def float64
+	set        bool
+}
+
+func optionalFloatFlag(name string, def float64, usage string) *optionalFloat {
+	f := &optionalFloat{def: def}
+	flag.Var(f, name, usage)
+	return f
+}
+
+func (f *optionalFloat) String() string {
+	if f == nil || !f.set {
+		return ""
+	}
+	return strconv.FormatFloat(f.value, 'g', -1, 64)
+}
+
+func (f *optionalFloat) Set(s string) error {
+	switch s {
+	case "true":
+		f.value, f.set = f.def, true
+	case "false":
+		f.set = false
+	default:
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		f.value, f.set = v, true
+	}
+	return nil
+}
+
+func (f *optionalFloat) IsBoolFlag() bool { return true }
+
+// flagSet reports whether a flag was given on the command line.
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// padToZero adds an empty glyph at code 0 if the font starts later, and
+// returns the number of glyph table entries this adds. The gap up to the
+// real first glyph is filled like any other.
+func padToZero(font *gfx.Font) int {
+	first, _ := font.Range()
+	if first <= 0 {
+		return 0
+	}
+	font.AddGlyph(&gfx.Glyph{Code: 0, Bitmap: []byte{}})
+	return first
+}
+
+// loadInput parses one input font and converts it to Unicode with
+// -to-unicode or -latin1-to-unicode, before it is merged with the other
+// inputs.
+func loadInput(filename string) *gfx.Font {
+	font := parseBDF(filename)
+	if *latin1ToUnicode {
+		if cs := font.Charset(); cs != "" && !strings.EqualFold(cs, "ISO8859-1") {
+			warnf("%s: charset %s is taken as ISO8859-1", filename, cs)
+		}
+		font.CharsetRegistry, font.CharsetEncoding = "ISO8859", "1"
+	}
+	if *toUnicode || *latin1ToUnicode {
+		remapped, dropped, err := font.ToUnicode()
+		if err != nil {
+			log.Fatalf("%s: %v", filename, err)
+		}
+		for _, g := range dropped {
+			warnf("glyph 0x%04X is not defined in the font charset and was dropped", g.Code)
+		}
+		if *verbose {
+			for _, r := range remapped {
+				fmt.Printf("  0x%04X -> U+%04X\n", r.From, r.To)
 			}
-		case "BBX":
-			if insideGlyph {
-				width, _ := strconv.Atoi(fields[1])
-				height, _ := strconv.Atoi(fields[2])
-				xOffset, _ := strconv.Atoi(fields[3])
-				bbxY, _ := strconv.Atoi(fields[4])
-				currentGlyph.width = width
-				currentGlyph.height = height
-				currentGlyph.xOffset = xOffset
-				currentGlyph.bbxY = bbxY
-				bytesPerRow = (width + 7) / 8
+		}
+		fmt.Printf("Remapped %d glyphs to Unicode\n", len(remapped))
+	}
+	return font
+}
+
+func parseBDF(filename string) *gfx.Font {
+	file, err := openInput(filename)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	var parser gfx.Parser
+	switch *inBitOrder {
+	case "msb":
+	case "lsb":
+		parser.LSBFirst = true
+	default:
+		log.Fatalf("Unknown input bit order %q", *inBitOrder)
+	}
+	switch *inByteOrder {
+	case "normal":
+	case "reverse":
+		parser.ReverseBytes = true
+	default:
+		log.Fatalf("Unknown input byte order %q", *inByteOrder)
+	}
+
+	skipped, outOfOrder, duplicates := 0, 0, 0
+	parser.SkipBadGlyphs = *skipBadGlyphs
+	parser.CheckEncoding = *validateEncoding
+	parser.DropDuplicates = *repairEncoding
+	parser.AdobeNames = *adobeNames
+	parser.Warn = func(err error) {
+		var glyphErr *gfx.GlyphError
+		switch {
+		case errors.Is(err, gfx.ErrOutOfOrder):
+			outOfOrder++
+		case errors.Is(err, gfx.ErrDuplicateCode):
+			duplicates++
+		case errors.As(err, &glyphErr) && glyphErr.Skipped:
+			skipped++
+		}
+		warnf("%s: %v", filename, err)
+	}
+
+	var font *gfx.Font
+	in := bufio.NewReader(file)
+	if magic, _ := in.Peek(4); gfx.IsPCF(magic) {
+		if *inBitOrder != "msb" || *inByteOrder != "normal" {
+			warnf("%s is a PCF font, which records its own bit and byte order; -in-bit-order and -in-byte-order are ignored", filename)
+		}
+		if *auditHex {
+			warnf("%s is a PCF font, which has no hex bitmap lines to audit", filename)
+		}
+		font, err = parser.ParsePCF(in)
+	} else {
+		var input io.Reader = in
+		if *auditHex {
+			data, err := io.ReadAll(in)
+			if err != nil {
+				log.Fatalf("%s: %v", filename, err)
 			}
-		case "BITMAP":
-			if insideGlyph {
-				currentGlyph.bitmap = []byte{}
-				insideBitmap = true
+			auditHexCase(filename, data)
+			input = bytes.NewReader(data)
+		}
+		font, err = parser.Parse(input)
+	}
+	if err != nil {
+		log.Fatalf("%s: %v", filename, err)
+	}
+	if skipped > 0 {
+		fmt.Printf("Skipped %d bad glyphs\n", skipped)
+	}
+	if *validateEncoding {
+		fmt.Printf("Encoding check: %d glyphs out of order, %d duplicate encodings\n", outOfOrder, duplicates)
+	}
+	if *repairEncoding && duplicates > 0 {
+		fmt.Printf("Dropped %d glyphs with duplicate encodings\n", duplicates)
+	}
+	if len(font.Glyphs) == 0 {
+		log.Fatalf("%s: no glyphs found", filename)
+	}
+	if *validateNames {
+		bad := 0
+		for _, g := range font.Glyphs {
+			if safe, ok := gfx.SanitizeComment(g.Name); !ok {
+				warnf("%s: glyph 0x%04X: unsafe name %q (would be written as %q)", filename, g.Code, g.Name, safe)
+				bad++
 			}
 		}
+		if bad > 0 {
+			log.Fatalf("%s: %d glyph names need sanitizing", filename, bad)
+		}
 	}
+	if *validateBBX {
+		if font.BoundingBox == nil {
+			warnf("%s: no FONTBOUNDINGBOX to check the glyphs against", filename)
+		}
+		outside := font.OutsideBoundingBox()
+		for _, g := range outside {
+			bb := font.BoundingBox
+			warnf("%s: glyph 0x%04X: BBX %d %d %d %d is outside the FONTBOUNDINGBOX %d %d %d %d", filename, g.Code,
+				g.Width, g.Height, g.XOffset, g.YOffset, bb.Width, bb.Height, bb.XOffset, bb.YOffset)
+		}
+		fmt.Printf("BBX check: %d glyphs outside the FONTBOUNDINGBOX\n", len(outside))
+	}
+	if font.MetricsSet == gfx.MetricsVertical {
+		warnf("%s is a vertical-only font (METRICSSET 1), advances are taken from DWIDTH1", filename)
+	}
+	return font
+}
 
-	if err := scanner.Err(); err != nil {
+// probeBDF prints "bdf" if the file parses as a BDF font with at least one
+// glyph whose bitmap rows match its BBX, or "pcf" for such a PCF font, and
+// fails otherwise. The bitmaps are checked but not kept.
+func probeBDF(filename string) {
+	file, err := openInput(filename)
+	if err != nil {
 		log.Fatal(err)
 	}
+	defer file.Close()
 
-	sort.Slice(glyphs, func(i, j int) bool {
-		return glyphs[i].code < glyphs[j].code
-	})
+	in := bufio.NewReader(file)
+	magic, _ := in.Peek(4)
+	pcf := gfx.IsPCF(magic)
+	font, err := (&gfx.Parser{DiscardBitmaps: true}).ParseFont(in)
+	if err != nil {
+		log.Fatalf("%s: %v", filename, err)
+	}
+	if len(font.Glyphs) == 0 {
+		log.Fatalf("%s: no glyphs", filename)
+	}
+	if pcf {
+		fmt.Println("pcf")
+		return
+	}
+	fmt.Println("bdf")
+}
+
+// printCount reads only the metrics of a font and prints how many glyphs
+// it has and how much of its code range they cover.
+func printCount(filename string) {
+	file, err := openInput(filename)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	font, err := (&gfx.Parser{MetricsOnly: true, SkipBadGlyphs: true}).ParseFont(file)
+	if err != nil {
+		log.Fatalf("%s: %v", filename, err)
+	}
+	if len(font.Glyphs) == 0 {
+		fmt.Println("0 glyphs")
+		return
+	}
+	// Glyphs are sorted, so repeated codes are adjacent.
+	codes := 0
+	for i, g := range font.Glyphs {
+		if i == 0 || g.Code != font.Glyphs[i-1].Code {
+			codes++
+		}
+	}
+	first, last := font.Range()
+	fmt.Printf("%d glyphs, range 0x%04X-0x%04X, %.1f%% coverage\n",
+		len(font.Glyphs), first, last, 100*float64(codes)/float64(last-first+1))
+}
+
+// writeHeader writes a C header, or with -metrics-out and -bitmaps-out its
+// metrics and bitmaps to separate files, and with -hex-out its bitmaps as
+// hex.
+func writeHeader(filename string, header *gfx.Header) {
+	if *hexOut != "" {
+		writeHexBitmaps(*hexOut, *hexFormat, header)
+	}
+	if *metricsOut == "" {
+		writeFile(filename, header)
+		return
+	}
+	metrics, bitmaps := *header, *header
+	metrics.Part = gfx.MetricsPart
+	bitmaps.Part = gfx.BitmapsPart
+	writeFile(*metricsOut, &metrics)
+	writeFile(*bitmapsOut, &bitmaps)
+}
+
+// checkNotSplit rejects -metrics-out, -bitmaps-out and -hex-out for
+// formats other than C headers.
+func checkNotSplit() {
+	if *metricsOut != "" {
+		log.Fatalf("-metrics-out and -bitmaps-out need a C header format, not %q", *outputFormat)
+	}
+	if *hexOut != "" {
+		log.Fatalf("-hex-out needs a C header format, not %q", *outputFormat)
+	}
+}
 
-	return fontAscent, fontDescent, glyphs
+// createOutput creates an output file. With -n an existing file is not
+// overwritten unless -f is given too.
+func createOutput(filename string) (*os.File, error) {
+	flags := os.O_RDWR | os.O_CREATE | os.O_TRUNC
+	if *noClobber && !*force {
+		flags |= os.O_EXCL
+	}
+	f, err := os.OpenFile(filename, flags, 0o666)
+	if errors.Is(err, fs.ErrExist) {
+		return nil, fmt.Errorf("%s already exists (use -f to overwrite)", filename)
+	}
+	return f, err
 }
 
-func generateHeader(filename string, ascent, descent int, glyphs []*Glyph) {
-	outFile, err := os.Create(filename)
+// writeOutput writes data to an output file like os.WriteFile, but honors
+// -n.
+func writeOutput(filename string, data []byte) error {
+	f, err := createOutput(filename)
 	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// writeFile writes the output of an emitter to filename.
+func writeFile(filename string, emitter io.WriterTo) {
+	if *canonical {
+		emitter = canonicalText{emitter}
+	}
+	outFile, err := createOutput(filename)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := emitter.WriteTo(outFile); err != nil {
+		outFile.Close()
+		os.Remove(filename)
+		log.Fatalf("%s: %v", filename, err)
+	}
+	if err := outFile.Close(); err != nil {
 		log.Fatal(err)
 	}
-	defer outFile.Close()
-
-	var bitmapData []byte
-	var offsets []int
-	offset := 0
-	for _, g := range glyphs {
-		offsets = append(offsets, offset)
-		bitmapData = append(bitmapData, g.bitmap...)
-		offset += len(g.bitmap)
-	}
-
-	fmt.Fprintf(outFile, "// typedef struct {\n")
-	fmt.Fprintf(outFile, "//   uint16_t bitmapOffset;\n")
-	fmt.Fprintf(outFile, "//   uint8_t  width;\n")
-	fmt.Fprintf(outFile, "//   uint8_t  height;\n")
-	fmt.Fprintf(outFile, "//   uint8_t  xAdvance;\n")
-	fmt.Fprintf(outFile, "//   int8_t   xOffset;\n")
-	fmt.Fprintf(outFile, "//   int8_t   yOffset;\n} GFXglyph;\n\n")
-
-	fmt.Fprintf(outFile, "// typedef struct {\n")
-	fmt.Fprintf(outFile, "//   uint8_t  *bitmap;\n")
-	fmt.Fprintf(outFile, "//   GFXglyph *glyph;\n")
-	fmt.Fprintf(outFile, "//   uint16_t  first;\n")
-	fmt.Fprintf(outFile, "//   uint16_t  last;\n")
-	fmt.Fprintf(outFile, "//   uint8_t   yAdvance;\n} GFXfont;\n\n")
-
-	fmt.Fprintf(outFile, "const uint8_t FontBitmaps[] PROGMEM = {\n  ")
-	for i, b := range bitmapData {
-		if i > 0 && i%(ascent+descent) == 0 {
-			fmt.Fprint(outFile, "\n  ")
-		}
-		fmt.Fprintf(outFile, "0x%02X, ", b)
-	}
-	fmt.Fprintf(outFile, "\n};\n\n")
-
-	fmt.Fprintf(outFile, "const GFXglyph FontGlyphs[] PROGMEM = {\n")
-	for i, g := range glyphs {
-		fmt.Fprintf(outFile, "  { %5d, %2d, %2d, %2d, %3d, %3d }, // 0x%04X\n",
-			offsets[i], g.width, g.height, g.xAdvance, g.xOffset, g.yOffsetTFT, g.code)
-	}
-	fmt.Fprint(outFile, "};\n\n")
-
-	first, last := glyphs[0].code, glyphs[len(glyphs)-1].code
-	fmt.Fprintf(outFile, "const GFXfont Font PROGMEM = {\n")
-	fmt.Fprintf(outFile, "  (uint8_t*)FontBitmaps,\n")
-	fmt.Fprintf(outFile, "  (GFXglyph*)FontGlyphs,\n")
-	fmt.Fprintf(outFile, "  0x%x, 0x%x, %d\n};\n", first, last, ascent+descent)
+}
+
+// canonicalText normalizes the formatting of the text an emitter writes:
+// trailing blanks are stripped, runs of empty lines collapse into one, and
+// the text ends in exactly one newline.
+type canonicalText struct {
+	emitter io.WriterTo
+}
+
+func (c canonicalText) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	if _, err := c.emitter.WriteTo(&buf); err != nil {
+		return 0, err
+	}
+	var out bytes.Buffer
+	blank := false
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" && blank {
+			continue
+		}
+		blank = line == ""
+		out.WriteString(line)
+		out.WriteByte('\n')
+	}
+	n, err := w.Write(out.Bytes())
+	return int64(n), err
+}
+
+func printProperties(filename string) {
+	file, err := openInput(filename)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	font, err := (&gfx.Parser{MetricsOnly: true, SkipBadGlyphs: true}).ParseFont(file)
+	if err != nil {
+		log.Fatalf("%s: %v", filename, err)
+	}
+	names := make([]string, 0, len(font.Properties))
+	for name := range font.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("%s %s\n", name, font.Properties[name])
+	}
 }
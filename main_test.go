@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+// textEmitter writes a fixed text.
+type textEmitter string
+
+func (e textEmitter) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, string(e))
+	return int64(n), err
+}
+
+func TestCanonicalText(t *testing.T) {
+	for _, tt := range []struct{ in, want string }{
+		{"a  \nb\t\n", "a\nb\n"},
+		{"a\r\n\r\n\r\n\r\nb\r\n", "a\n\nb\n"},
+		{"\n\na\n\n\n", "a\n"},
+		{"a", "a\n"},
+	} {
+		var buf bytes.Buffer
+		if _, err := (canonicalText{textEmitter(tt.in)}).WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("canonical %q = %q, want %q", tt.in, buf.String(), tt.want)
+		}
+	}
+}
+
+// writeTestBDF writes a BDF font with an ascent of 6 and a descent of 2,
+// the given extra properties and a 4x4 box for every code, and returns
+// its file name.
+func writeTestBDF(t *testing.T, properties []string, codes ...int) string {
+	t.Helper()
+	var b strings.Builder
+	b.WriteString("STARTFONT 2.1\nSIZE 8 75 75\nFONTBOUNDINGBOX 6 8 0 -2\n")
+	fmt.Fprintf(&b, "STARTPROPERTIES %d\nFONT_ASCENT 6\nFONT_DESCENT 2\n", len(properties)+2)
+	for _, p := range properties {
+		b.WriteString(p + "\n")
+	}
+	fmt.Fprintf(&b, "ENDPROPERTIES\nCHARS %d\n", len(codes))
+	for _, code := range codes {
+		fmt.Fprintf(&b, "STARTCHAR u%04X\nENCODING %d\nDWIDTH 5 0\nBBX 4 4 0 0\nBITMAP\nF0\nF0\nF0\nF0\nENDCHAR\n", code, code)
+	}
+	b.WriteString("ENDFONT\n")
+	filename := filepath.Join(t.TempDir(), "font.bdf")
+	if err := os.WriteFile(filename, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+// captureWarnings collects the warnings of the test in a buffer.
+func captureWarnings(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	l, n := warnLog, warnings
+	t.Cleanup(func() { warnLog, warnings = l, n })
+	warnLog, warnings = log.New(&buf, "", 0), 0
+	return &buf
+}
+
+// setFlag sets a flag for the duration of the test.
+func setFlag[T any](t *testing.T, p *T, v T) {
+	old := *p
+	t.Cleanup(func() { *p = old })
+	*p = v
+}
+
+func TestLatin1ToUnicode(t *testing.T) {
+	setFlag(t, latin1ToUnicode, true)
+
+	// A font without a charset converts unchanged and without warnings.
+	warned := captureWarnings(t)
+	font := loadInput(writeTestBDF(t, nil, 0x41, 0xE9))
+	if got := glyphCodes(font); !slices.Equal(got, []int{0x41, 0xE9}) || warnings != 0 {
+		t.Errorf("codes %#x with %d warnings, want 0x41 and 0xe9 without warnings:\n%s", got, warnings, warned)
+	}
+	if cs := font.Charset(); cs != "ISO10646-1" {
+		t.Errorf("charset %q after the conversion, want ISO10646-1", cs)
+	}
+
+	// A Greek font is taken as Latin-1 with a warning.
+	warned = captureWarnings(t)
+	font = loadInput(writeTestBDF(t, []string{`CHARSET_REGISTRY "ISO8859"`, `CHARSET_ENCODING "7"`}, 0x41, 0xE9))
+	if got := glyphCodes(font); !slices.Equal(got, []int{0x41, 0xE9}) {
+		t.Errorf("codes %#x, want 0x41 and 0xe9", got)
+	}
+	if !strings.Contains(warned.String(), "charset ISO8859-7 is taken as ISO8859-1") || warnings != 1 {
+		t.Errorf("%d warnings, want one about ISO8859-7:\n%s", warnings, warned)
+	}
+}
+
+// glyphCodes returns the codepoints of the glyphs of a font, in order.
+func glyphCodes(font *gfx.Font) []int {
+	var codes []int
+	for _, g := range font.Glyphs {
+		codes = append(codes, g.Code)
+	}
+	return codes
+}
+
+func TestPadToZero(t *testing.T) {
+	font := parseBDF(writeTestBDF(t, nil, 0x20, 0x22))
+	if n := padToZero(font); n != 0x20 {
+		t.Errorf("%d glyph table entries added, want 0x20", n)
+	}
+	var buf bytes.Buffer
+	if _, err := (&gfx.Header{Font: font, Name: "Test"}).WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"{     0,  0,  0,  0,   0,   0 }, // 0x0000\n",
+		"{     0,  0,  0,  0,   0,   0 }, // 0x001F\n",
+		"{     0,  4,  4,  5,   0,  -4 }, // 0x0020\n",
+		"  0x0, 0x22, 8\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("header without %q:\n%s", want, &buf)
+		}
+	}
+	if n := padToZero(font); n != 0 {
+		t.Errorf("%d entries added to a font starting at 0, want none", n)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// gfxFontLine matches the GFXfont definitions of the C headers.
+var gfxFontLine = regexp.MustCompile(`^const GFXfont (\w+) PROGMEM = \{`)
+
+// headerFonts returns the symbols of the GFXfonts a C header defines, in
+// file order.
+func headerFonts(filename string) []string {
+	file, err := os.Open(filename)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	var fonts []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if m := gfxFontLine.FindStringSubmatch(scanner.Text()); m != nil {
+			fonts = append(fonts, m[1])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatalf("%s: %v", filename, err)
+	}
+	return fonts
+}
+
+// writeManifest writes a header that includes the font headers and lists
+// their GFXfonts in a family array, with an enum of the indexes, so a
+// sketch can switch fonts at runtime. The fonts keep the symbols their
+// conversion gave them; family names the array and prefixes the enum.
+func writeManifest(filename, family string, headers []string) {
+	var fonts, includes []string
+	seen := map[string]string{}
+	for _, header := range headers {
+		symbols := headerFonts(header)
+		switch {
+		case len(symbols) == 0:
+			log.Fatalf("%s: no GFXfont found; -manifest needs headers of -format=gfx, gfx-blocks or debug-c", header)
+		case len(symbols) > 1:
+			warnf("%s defines %d GFXfonts (blocks or a fallback), all are listed", header, len(symbols))
+		}
+		for _, s := range symbols {
+			if other, ok := seen[s]; ok {
+				log.Fatalf("%s: GFXfont %s is already defined by %s; convert one of them with another -name", header, s, other)
+			}
+			seen[s] = header
+		}
+		fonts = append(fonts, symbols...)
+
+		include := header
+		if rel, err := filepath.Rel(filepath.Dir(filename), header); err == nil {
+			include = rel
+		}
+		includes = append(includes, filepath.ToSlash(include))
+	}
+
+	prefix := strings.ToUpper(family)
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// The %s font family, generated by bdf2tft. Include it once, after\n", family)
+	fmt.Fprint(&out, "// Adafruit_GFX.h; index the array with the enum to switch fonts.\n")
+	fmt.Fprintf(&out, "#ifndef %s_H\n", prefix)
+	fmt.Fprintf(&out, "#define %s_H\n\n", prefix)
+	for _, include := range includes {
+		fmt.Fprintf(&out, "#include \"%s\"\n", include)
+	}
+	fmt.Fprint(&out, "\n")
+	fmt.Fprintf(&out, "enum %sIndex {\n", family)
+	for _, f := range fonts {
+		fmt.Fprintf(&out, "  %s_%s,\n", prefix, strings.ToUpper(f))
+	}
+	fmt.Fprintf(&out, "  %s_COUNT\n", prefix)
+	fmt.Fprint(&out, "};\n\n")
+	fmt.Fprintf(&out, "const GFXfont *const %s[%s_COUNT] = {\n", family, prefix)
+	for _, f := range fonts {
+		fmt.Fprintf(&out, "  &%s,\n", f)
+	}
+	fmt.Fprint(&out, "};\n\n")
+	fmt.Fprintf(&out, "#endif // %s_H\n", prefix)
+
+	if err := writeOutput(filename, out.Bytes()); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Wrote a manifest of %d fonts from %d headers to %s\n", len(fonts), len(headers), filename)
+}
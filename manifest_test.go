@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+func TestWriteManifest(t *testing.T) {
+	dir := t.TempDir()
+	box := &gfx.Glyph{Code: 0x41, Width: 4, Height: 4, XAdvance: 5, YOffset: -4, Bitmap: []byte{0xF0, 0xF0, 0xF0, 0xF0}}
+	var headers []string
+	for _, name := range []string{"Small", "Large"} {
+		var buf bytes.Buffer
+		header := &gfx.Header{Font: &gfx.Font{Ascent: 6, Descent: 2, DefaultChar: -1, Glyphs: []*gfx.Glyph{box}}, Name: name}
+		if _, err := header.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		filename := filepath.Join(dir, "fonts", name+".h")
+		if err := os.MkdirAll(filepath.Dir(filename), 0o777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		headers = append(headers, filename)
+	}
+	if got := headerFonts(headers[0]); len(got) != 1 || got[0] != "Small" {
+		t.Errorf("headerFonts() = %q, want Small", got)
+	}
+
+	filename := filepath.Join(dir, "family.h")
+	writeManifest(filename, "Family", headers)
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The includes are relative to the manifest.
+	want := "#ifndef FAMILY_H\n#define FAMILY_H\n\n" +
+		"#include \"fonts/Small.h\"\n#include \"fonts/Large.h\"\n\n" +
+		"enum FamilyIndex {\n  FAMILY_SMALL,\n  FAMILY_LARGE,\n  FAMILY_COUNT\n};\n\n" +
+		"const GFXfont *const Family[FAMILY_COUNT] = {\n  &Small,\n  &Large,\n};\n\n" +
+		"#endif // FAMILY_H\n"
+	if !bytes.HasSuffix(data, []byte(want)) {
+		t.Errorf("manifest\n%s\nwant it to end with\n%s", data, want)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"strings"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+// generateMarkdown writes a table of the glyphs for documentation, with
+// every bitmap drawn as ASCII art inside the table cell.
+func generateMarkdown(filename string, font *gfx.Font) {
+	var b strings.Builder
+	first, last := font.Range()
+	fmt.Fprintf(&b, "%d glyphs, 0x%04X-0x%04X, %d pixels high.\n\n", len(font.Glyphs), first, last, font.Ascent+font.Descent)
+	fmt.Fprint(&b, "| Code | Name | Width | Height | Advance | Glyph |\n")
+	fmt.Fprint(&b, "|------|------|------:|-------:|--------:|-------|\n")
+	for _, g := range font.Glyphs {
+		art := strings.TrimSuffix(g.ASCII(), "\n")
+		cell := ""
+		if art != "" {
+			cell = "<code>" + strings.ReplaceAll(html.EscapeString(art), "\n", "<br>") + "</code>"
+		}
+		name := strings.ReplaceAll(html.EscapeString(g.Name), "|", "\\|")
+		fmt.Fprintf(&b, "| U+%04X | %s | %d | %d | %d | %s |\n", g.Code, name, g.Width, g.Height, g.XAdvance, cell)
+	}
+	if err := writeOutput(filename, []byte(b.String())); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,403 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"math/bits"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+// otbUnitsPerPixel is the number of font units per pixel. With a whole
+// number of units per pixel every metric is exact.
+const otbUnitsPerPixel = 64
+
+// generateOTB writes the font as a bitmap-only OpenType font (OTB), the
+// format of fonttosfnt that FreeType, and so most Linux desktops, load:
+// one strike of 1-bit bitmaps in EBLC and EBDT, with no outlines, and the
+// head, hhea, hmtx, maxp, OS/2, name, post and cmap tables applications
+// need. There is no EBSC table, so the font only has its own pixel size.
+// Codes are taken as Unicode and glyph 0 is an empty .notdef. Gray levels
+// and kerning are not carried over.
+func generateOTB(filename string, font *gfx.Font, name string) {
+	ppem := font.Ascent + font.Descent
+	if ppem <= 0 || font.Ascent > 0x7F || font.Descent > 0x80 {
+		log.Fatalf("font ascent %d and descent %d do not fit an OTB strike", font.Ascent, font.Descent)
+	}
+	u := otbUnitsPerPixel
+
+	// Glyph 0 is .notdef, the glyphs follow in code order. Of repeated
+	// codes the first glyph is kept.
+	glyphs := []*gfx.Glyph{{}}
+	var codes []int
+	dropped := 0
+	for i, g := range font.Glyphs {
+		if i > 0 && g.Code == font.Glyphs[i-1].Code {
+			continue
+		}
+		if g.Code < 0 || g.Code > 0x10FFFF || g.Code == 0xFFFF {
+			dropped++
+			continue
+		}
+		if g.Width > 0xFF || g.Height > 0xFF || g.XAdvance < 0 || g.XAdvance > 0xFF ||
+			g.XOffset < -0x80 || g.XOffset > 0x7F || g.YOffset+g.Height < -0x80 || g.YOffset+g.Height > 0x7F {
+			log.Fatalf("glyph 0x%04X: BBX %d %d %d %d with advance %d does not fit the small glyph metrics of OTB",
+				g.Code, g.Width, g.Height, g.XOffset, g.YOffset, g.XAdvance)
+		}
+		glyphs = append(glyphs, g)
+		codes = append(codes, g.Code)
+	}
+	if dropped > 0 {
+		warnf("%d glyphs with codes that are not Unicode characters were left out", dropped)
+	}
+	if len(codes) == 0 {
+		log.Fatal("no glyphs for the OTB character map")
+	}
+	if len(glyphs) > 0xFFFF {
+		log.Fatalf("%d glyphs do not fit the 16-bit glyph IDs of OTB", len(glyphs))
+	}
+
+	bold := strings.EqualFold(font.Properties["WEIGHT_NAME"], "Bold")
+	slant := strings.ToUpper(font.Properties["SLANT"])
+	italic := slant == "I" || slant == "O"
+	subfamily := "Regular"
+	switch {
+	case bold && italic:
+		subfamily = "Bold Italic"
+	case bold:
+		subfamily = "Bold"
+	case italic:
+		subfamily = "Italic"
+	}
+	family := font.Family()
+	if family == "" {
+		family = name
+	}
+
+	// Metrics over the glyphs with ink, in pixels.
+	advances, advanceCount, maxAdvance, fixedAdvance := 0, 0, 0, 0
+	minLSB, minRSB, maxExtent, minX, minY, maxX, maxY := 0, 0, 0, 0, 0, 0, 0
+	maxBeforeBL, minAfterBL := 0, 0
+	fixed, first := true, true
+	for _, g := range glyphs[1:] {
+		maxAdvance = max(maxAdvance, g.XAdvance)
+		if g.XAdvance > 0 {
+			if advanceCount == 0 {
+				fixedAdvance = g.XAdvance
+			}
+			advances += g.XAdvance
+			advanceCount++
+			fixed = fixed && g.XAdvance == fixedAdvance
+		}
+		if g.Width <= 0 || g.Height <= 0 {
+			continue
+		}
+		top, bottom := g.YOffset+g.Height, g.YOffset
+		if first {
+			minLSB, minRSB, maxExtent = g.XOffset, g.XAdvance-g.XOffset-g.Width, g.XOffset+g.Width
+			minX, minY, maxX, maxY = g.XOffset, bottom, g.XOffset+g.Width, top
+			maxBeforeBL, minAfterBL = top, bottom
+			first = false
+		}
+		minLSB = min(minLSB, g.XOffset)
+		minRSB = min(minRSB, g.XAdvance-g.XOffset-g.Width)
+		maxExtent = max(maxExtent, g.XOffset+g.Width)
+		minX, minY = min(minX, g.XOffset), min(minY, bottom)
+		maxX, maxY = max(maxX, g.XOffset+g.Width), max(maxY, top)
+		maxBeforeBL, minAfterBL = max(maxBeforeBL, top), min(minAfterBL, bottom)
+	}
+	avgAdvance := 0
+	if advanceCount > 0 {
+		avgAdvance = (advances + advanceCount/2) / advanceCount
+	}
+
+	be := func(b *bytes.Buffer, values ...any) {
+		for _, v := range values {
+			binary.Write(b, binary.BigEndian, v)
+		}
+	}
+	tables := map[string][]byte{}
+
+	// EBDT holds every glyph as image format 1: small metrics followed by
+	// the BDF rows, which are byte aligned already. offsets are relative
+	// to the first glyph, 4 bytes into the table.
+	var ebdt bytes.Buffer
+	be(&ebdt, uint32(0x00020000))
+	offsets := []uint32{0}
+	for _, g := range glyphs[1:] {
+		be(&ebdt, uint8(max(g.Height, 0)), uint8(max(g.Width, 0)), int8(g.XOffset), int8(g.YOffset+g.Height), uint8(g.XAdvance))
+		if g.Width > 0 && g.Height > 0 {
+			ebdt.Write(g.Bitmap)
+		}
+		offsets = append(offsets, uint32(ebdt.Len()-4))
+	}
+	// .notdef is empty: its two offsets are the same.
+	offsets = append([]uint32{0}, offsets...)
+	tables["EBDT"] = ebdt.Bytes()
+
+	// EBLC has one strike with a single index subtable of format 1 for
+	// all glyphs.
+	var eblc bytes.Buffer
+	lineMetrics := func() {
+		be(&eblc, int8(font.Ascent), int8(-font.Descent), uint8(maxAdvance), int8(1), int8(0), int8(0),
+			int8(minLSB), int8(minRSB), int8(maxBeforeBL), int8(minAfterBL), int8(0), int8(0))
+	}
+	subtableSize := 8 + 4*len(offsets)
+	be(&eblc, uint32(0x00020000), uint32(1))
+	be(&eblc, uint32(8+48), uint32(8+subtableSize), uint32(1), uint32(0))
+	lineMetrics() // hori
+	lineMetrics() // vert
+	be(&eblc, uint16(0), uint16(len(glyphs)-1), uint8(ppem), uint8(ppem), uint8(1), int8(1))
+	be(&eblc, uint16(0), uint16(len(glyphs)-1), uint32(8))
+	be(&eblc, uint16(1), uint16(1), uint32(4))
+	be(&eblc, offsets)
+	tables["EBLC"] = eblc.Bytes()
+
+	var head bytes.Buffer
+	macStyle := uint16(0)
+	if bold {
+		macStyle |= 1
+	}
+	if italic {
+		macStyle |= 2
+	}
+	be(&head, uint32(0x00010000), uint32(0x00010000), uint32(0), uint32(0x5F0F3CF5))
+	be(&head, uint16(0x0009), uint16(ppem*u), int64(0), int64(0))
+	be(&head, int16(minX*u), int16(minY*u), int16(maxX*u), int16(maxY*u))
+	be(&head, macStyle, uint16(ppem), int16(2), int16(0), int16(0))
+	tables["head"] = head.Bytes()
+
+	var hhea bytes.Buffer
+	be(&hhea, uint32(0x00010000), int16(font.Ascent*u), int16(-font.Descent*u), int16(font.LineGap*u))
+	be(&hhea, uint16(maxAdvance*u), int16(minLSB*u), int16(minRSB*u), int16(maxExtent*u))
+	be(&hhea, int16(1), int16(0), int16(0), [4]int16{}, int16(0), uint16(len(glyphs)))
+	tables["hhea"] = hhea.Bytes()
+
+	var hmtx bytes.Buffer
+	for _, g := range glyphs {
+		be(&hmtx, uint16(g.XAdvance*u), int16(g.XOffset*u))
+	}
+	tables["hmtx"] = hmtx.Bytes()
+
+	var maxp bytes.Buffer
+	be(&maxp, uint32(0x00005000), uint16(len(glyphs)))
+	tables["maxp"] = maxp.Bytes()
+
+	var os2 bytes.Buffer
+	weight, selection := uint16(400), uint16(0x80) // USE_TYPO_METRICS
+	if bold {
+		weight = 700
+		selection |= 0x20
+	}
+	if italic {
+		selection |= 0x01
+	}
+	if !bold && !italic {
+		selection |= 0x40
+	}
+	em := ppem * u
+	be(&os2, uint16(4), int16(avgAdvance*u), weight, uint16(5), uint16(0))
+	be(&os2, int16(em*2/3), int16(em*2/3), int16(0), int16(em/8), int16(em*2/3), int16(em*2/3), int16(0), int16(em/2))
+	be(&os2, int16(u), int16(font.Ascent*u/3), int16(0), [10]byte{}, [4]uint32{}, [4]byte{' ', ' ', ' ', ' '})
+	defaultChar := 0
+	if font.DefaultChar > 0 && font.DefaultChar <= 0xFFFF {
+		defaultChar = font.DefaultChar
+	}
+	be(&os2, selection, uint16(codes[0]), uint16(min(codes[len(codes)-1], 0xFFFF)))
+	be(&os2, int16(font.Ascent*u), int16(-font.Descent*u), int16(font.LineGap*u))
+	be(&os2, uint16(max(font.Ascent, maxY)*u), uint16(max(font.Descent, -minY)*u), [2]uint32{1, 0})
+	be(&os2, int16(font.XHeight*u), int16(font.CapHeight*u), uint16(defaultChar), uint16(' '), uint16(1))
+	tables["OS/2"] = os2.Bytes()
+
+	tables["name"] = otbNames(map[int]string{
+		0: font.Properties["COPYRIGHT"],
+		1: family,
+		2: subfamily,
+		3: fmt.Sprintf("%s %s %d", family, subfamily, ppem),
+		4: family + " " + subfamily,
+		5: "Version 1.0",
+		6: otbPostScriptName(family + "-" + subfamily),
+	})
+
+	var post bytes.Buffer
+	isFixed := uint32(0)
+	if fixed {
+		isFixed = 1
+	}
+	be(&post, uint32(0x00030000), int32(0), int16(-u), int16(u), isFixed, [4]uint32{})
+	tables["post"] = post.Bytes()
+
+	cmap, err := otbCmap(codes)
+	if err != nil {
+		log.Fatal(err)
+	}
+	tables["cmap"] = cmap
+
+	out := otbFile(tables)
+	if err := writeOutput(filename, out); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Wrote %d glyphs in a %d pixel strike, %d bytes\n", len(glyphs)-1, ppem, len(out))
+}
+
+// otbCmap builds a cmap table with a format 4 subtable for the codes of
+// the Basic Multilingual Plane and, if there are codes above it, a format
+// 12 subtable for all of them. Glyph i+1 is the glyph of codes[i], which
+// are sorted.
+func otbCmap(codes []int) ([]byte, error) {
+	// Runs of consecutive codes map to runs of consecutive glyph IDs.
+	type run struct{ start, end, glyph int }
+	var runs []run
+	for i, code := range codes {
+		if n := len(runs); n > 0 && runs[n-1].end == code-1 {
+			runs[n-1].end = code
+			continue
+		}
+		runs = append(runs, run{code, code, i + 1})
+	}
+
+	var bmp []run
+	for _, r := range runs {
+		if r.start <= 0xFFFF {
+			r.end = min(r.end, 0xFFFE)
+			bmp = append(bmp, r)
+		}
+	}
+	segments := len(bmp) + 1
+	if 16+8*segments > 0xFFFF {
+		return nil, fmt.Errorf("%d runs of codes do not fit a format 4 cmap subtable", len(bmp))
+	}
+	var format4 bytes.Buffer
+	be := func(b *bytes.Buffer, values ...any) {
+		for _, v := range values {
+			binary.Write(b, binary.BigEndian, v)
+		}
+	}
+	searchRange := 2 << (bits.Len(uint(segments)) - 1)
+	be(&format4, uint16(4), uint16(16+8*segments), uint16(0), uint16(2*segments),
+		uint16(searchRange), uint16(bits.Len(uint(segments))-1), uint16(2*segments-searchRange))
+	for _, r := range bmp {
+		be(&format4, uint16(r.end))
+	}
+	be(&format4, uint16(0xFFFF), uint16(0))
+	for _, r := range bmp {
+		be(&format4, uint16(r.start))
+	}
+	be(&format4, uint16(0xFFFF))
+	for _, r := range bmp {
+		be(&format4, uint16(r.glyph-r.start))
+	}
+	be(&format4, uint16(1))
+	for range segments {
+		be(&format4, uint16(0))
+	}
+
+	subtables := [][]byte{format4.Bytes()}
+	encodings := []uint16{1}
+	if codes[len(codes)-1] > 0xFFFF {
+		var format12 bytes.Buffer
+		be(&format12, uint16(12), uint16(0), uint32(16+12*len(runs)), uint32(0), uint32(len(runs)))
+		for _, r := range runs {
+			be(&format12, uint32(r.start), uint32(r.end), uint32(r.glyph))
+		}
+		subtables = append(subtables, format12.Bytes())
+		encodings = append(encodings, 10)
+	}
+
+	var cmap bytes.Buffer
+	be(&cmap, uint16(0), uint16(len(subtables)))
+	offset := 4 + 8*len(subtables)
+	for i, s := range subtables {
+		be(&cmap, uint16(3), encodings[i], uint32(offset))
+		offset += len(s)
+	}
+	for _, s := range subtables {
+		cmap.Write(s)
+	}
+	return cmap.Bytes(), nil
+}
+
+// otbNames builds a name table with the non-empty strings of names, by
+// name ID, for the Windows platform in US English.
+func otbNames(names map[int]string) []byte {
+	var ids []int
+	for id, s := range names {
+		if s != "" {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	var records, storage bytes.Buffer
+	for _, id := range ids {
+		var s bytes.Buffer
+		binary.Write(&s, binary.BigEndian, utf16.Encode([]rune(names[id])))
+		binary.Write(&records, binary.BigEndian, [6]uint16{3, 1, 0x409, uint16(id), uint16(s.Len()), uint16(storage.Len())})
+		storage.Write(s.Bytes())
+	}
+	var name bytes.Buffer
+	binary.Write(&name, binary.BigEndian, [3]uint16{0, uint16(len(ids)), uint16(6 + records.Len())})
+	name.Write(records.Bytes())
+	name.Write(storage.Bytes())
+	return name.Bytes()
+}
+
+// otbPostScriptName keeps the characters allowed in a PostScript name, at
+// most 63 of them.
+func otbPostScriptName(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r > ' ' && r < 0x7F && !strings.ContainsRune("[](){}<>/%", r) && b.Len() < 63 {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// otbFile lays out the tables in an sfnt file, sorted by tag and padded
+// to 4 bytes, and sets the checksum adjustment of the head table.
+func otbFile(tables map[string][]byte) []byte {
+	var tags []string
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	checksum := func(data []byte) uint32 {
+		sum := uint32(0)
+		for i := 0; i < len(data); i += 4 {
+			var word [4]byte
+			copy(word[:], data[i:])
+			sum += binary.BigEndian.Uint32(word[:])
+		}
+		return sum
+	}
+
+	var out bytes.Buffer
+	n := len(tags)
+	searchRange := 16 << (bits.Len(uint(n)) - 1)
+	binary.Write(&out, binary.BigEndian, [6]uint16{0x0001, 0x0000, uint16(n),
+		uint16(searchRange), uint16(bits.Len(uint(n)) - 1), uint16(16*n - searchRange)})
+	offset := 12 + 16*n
+	headOffset := 0
+	for _, tag := range tags {
+		data := tables[tag]
+		out.WriteString(tag)
+		binary.Write(&out, binary.BigEndian, [3]uint32{checksum(data), uint32(offset), uint32(len(data))})
+		if tag == "head" {
+			headOffset = offset
+		}
+		offset += (len(data) + 3) &^ 3
+	}
+	for _, tag := range tags {
+		out.Write(tables[tag])
+		out.Write(make([]byte, (4-len(tables[tag])%4)%4))
+	}
+	file := out.Bytes()
+	binary.BigEndian.PutUint32(file[headOffset+8:], 0xB1B0AFBA-checksum(file))
+	return file
+}
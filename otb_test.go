@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+// otbLookup maps a code to a glyph ID through a cmap subtable of format 4
+// or 12.
+func otbLookup(sub []byte, code int) int {
+	u16 := func(off int) int { return int(binary.BigEndian.Uint16(sub[off:])) }
+	u32 := func(off int) int { return int(binary.BigEndian.Uint32(sub[off:])) }
+	switch u16(0) {
+	case 4:
+		segX2 := u16(6)
+		for i := 0; i < segX2; i += 2 {
+			if code <= u16(14+i) {
+				if code < u16(16+segX2+i) {
+					return 0
+				}
+				return (code + u16(16+2*segX2+i)) & 0xFFFF
+			}
+		}
+	case 12:
+		for i := range u32(12) {
+			if start, end := u32(16+12*i), u32(20+12*i); code >= start && code <= end {
+				return u32(24+12*i) + code - start
+			}
+		}
+	}
+	return 0
+}
+
+func TestOTB(t *testing.T) {
+	box := func(code int) *gfx.Glyph {
+		return &gfx.Glyph{Code: code, Width: 4, Height: 4, XAdvance: 5, YOffset: -4, Bitmap: []byte{0xF0, 0xF0, 0xF0, 0xF0}}
+	}
+	font := &gfx.Font{Ascent: 6, Descent: 2, DefaultChar: -1,
+		Glyphs: []*gfx.Glyph{box(0x41), box(0x42), box(0x44), box(0x1F600)}}
+
+	filename := filepath.Join(t.TempDir(), "font.otb")
+	generateOTB(filename, font, "Test Font")
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u16 := func(off int) int { return int(binary.BigEndian.Uint16(data[off:])) }
+	u32 := func(off int) int { return int(binary.BigEndian.Uint32(data[off:])) }
+	if len(data)%4 != 0 || u32(0) != 0x00010000 {
+		t.Fatalf("version %#x and size %d, want 0x10000 and a multiple of 4", u32(0), len(data))
+	}
+
+	// The checksum adjustment of head makes the whole file sum to the
+	// magic number.
+	sum := uint32(0)
+	for i := 0; i < len(data); i += 4 {
+		sum += binary.BigEndian.Uint32(data[i:])
+	}
+	if sum != 0xB1B0AFBA {
+		t.Errorf("file checksum %#x, want 0xB1B0AFBA", sum)
+	}
+
+	tables := make(map[string][]byte)
+	var tags []string
+	for i := range u16(4) {
+		entry := 12 + 16*i
+		tag := string(data[entry : entry+4])
+		tags = append(tags, tag)
+		tables[tag] = data[u32(entry+8) : u32(entry+8)+u32(entry+12)]
+	}
+	want := []string{"EBDT", "EBLC", "OS/2", "cmap", "head", "hhea", "hmtx", "maxp", "name", "post"}
+	if !slices.Equal(tags, want) {
+		t.Fatalf("tables %q, want %q", tags, want)
+	}
+	if numGlyphs := binary.BigEndian.Uint16(tables["maxp"][4:]); numGlyphs != 5 {
+		t.Errorf("%d glyphs, want .notdef and 4", numGlyphs)
+	}
+
+	// Glyph i+1 is the glyph of the i-th code, in both subtables.
+	cmap := tables["cmap"]
+	if n := binary.BigEndian.Uint16(cmap[2:]); n != 2 {
+		t.Fatalf("%d cmap subtables, want formats 4 and 12", n)
+	}
+	format4 := cmap[binary.BigEndian.Uint32(cmap[8:]):]
+	format12 := cmap[binary.BigEndian.Uint32(cmap[16:]):]
+	for _, tt := range []struct{ code, bmp, all int }{
+		{0x41, 1, 1}, {0x42, 2, 2}, {0x43, 0, 0}, {0x44, 3, 3}, {0x1F600, 0, 4},
+	} {
+		if id := otbLookup(format4, tt.code); id != tt.bmp {
+			t.Errorf("format 4: 0x%04X maps to glyph %d, want %d", tt.code, id, tt.bmp)
+		}
+		if id := otbLookup(format12, tt.code); id != tt.all {
+			t.Errorf("format 12: 0x%04X maps to glyph %d, want %d", tt.code, id, tt.all)
+		}
+	}
+}
+
+func TestOTBCmapBMPOnly(t *testing.T) {
+	cmap, err := otbCmap([]int{0x20, 0x21, 0xFFFE})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := binary.BigEndian.Uint16(cmap[2:]); n != 1 {
+		t.Fatalf("%d cmap subtables, want only format 4", n)
+	}
+	format4 := cmap[binary.BigEndian.Uint32(cmap[8:]):]
+	for code, want := range map[int]int{0x20: 1, 0x21: 2, 0x22: 0, 0xFFFE: 3, 0xFFFF: 0} {
+		if id := otbLookup(format4, code); id != want {
+			t.Errorf("0x%04X maps to glyph %d, want %d", code, id, want)
+		}
+	}
+}
+
+func TestOTBPostScriptName(t *testing.T) {
+	for _, tt := range []struct{ in, want string }{
+		{"Test Font", "TestFont"},
+		{"a(b)/c%d[e]", "abcde"},
+		{"Grüße", "Gre"},
+		{string(make([]byte, 80)), ""},
+	} {
+		if got := otbPostScriptName(tt.in); got != tt.want {
+			t.Errorf("otbPostScriptName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	long := otbPostScriptName(string(slices.Repeat([]byte("x"), 80)))
+	if len(long) != 63 {
+		t.Errorf("name of %d characters, want 63", len(long))
+	}
+}
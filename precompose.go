@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+// precompose synthesizes glyphs listed in a map file. Every non-empty line
+// holds three codepoints, "target base mark", with '#' starting a comment.
+func precompose(font *gfx.Font, filename string) {
+	file, err := os.Open(filename)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	lineNo, added := 0, 0
+	for scanner.Scan() {
+		lineNo++
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			log.Fatalf("%s:%d: expected \"target base mark\"", filename, lineNo)
+		}
+		var codes [3]int
+		for i, f := range fields {
+			codes[i], err = parseCodepoint(f)
+			if err != nil {
+				log.Fatalf("%s:%d: %v", filename, lineNo, err)
+			}
+		}
+
+		base, ok := font.Glyph(codes[1])
+		if !ok {
+			warnf("precompose 0x%04X: base glyph 0x%04X not in font", codes[0], codes[1])
+			continue
+		}
+		mark, ok := font.Glyph(codes[2])
+		if !ok {
+			warnf("precompose 0x%04X: combining glyph 0x%04X not in font", codes[0], codes[2])
+			continue
+		}
+		if font.AddGlyph(gfx.Compose(base, mark, codes[0])) {
+			warnf("precompose 0x%04X: replaced the existing glyph", codes[0])
+		}
+		added++
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Precomposed %d glyphs\n", added)
+}
+
+// parseCodepoint accepts decimal, 0x-prefixed hex and U+XXXX notation.
+func parseCodepoint(s string) (int, error) {
+	if rest, ok := strings.CutPrefix(strings.ToUpper(s), "U+"); ok {
+		s = "0x" + rest
+	}
+	code, err := strconv.ParseInt(s, 0, 32)
+	if err != nil {
+		return 0, fmt.Errorf("bad codepoint %q", s)
+	}
+	return int(code), nil
+}
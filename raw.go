@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+// generateRawCells writes fixed-size character cells for legacy LCDs, one
+// cell per codepoint from first to last with blank cells for missing
+// glyphs, so cell n is at offset (n - first) * NAME_CELL_BYTES. With
+// centered set every glyph is centered in its cell.
+func generateRawCells(filename string, font *gfx.Font, name string, width, height int, centered bool) {
+	outFile, err := createOutput(filename)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer outFile.Close()
+
+	first, last := font.Range()
+	bytesPerRow := (width + 7) / 8
+	cellBytes := bytesPerRow * height
+
+	fmt.Fprintf(outFile, "#define %s_CELL_WIDTH %d\n", name, width)
+	fmt.Fprintf(outFile, "#define %s_CELL_HEIGHT %d\n", name, height)
+	fmt.Fprintf(outFile, "#define %s_CELL_BYTES %d\n", name, cellBytes)
+	fmt.Fprintf(outFile, "#define %s_FIRST 0x%x\n", name, first)
+	fmt.Fprintf(outFile, "#define %s_LAST 0x%x\n\n", name, last)
+
+	glyphs := map[int]*gfx.Glyph{}
+	for _, g := range font.Glyphs {
+		glyphs[g.Code] = g
+	}
+
+	render := font.RenderCell
+	if centered {
+		render = font.RenderCentered
+	}
+	clippedGlyphs := 0
+	fmt.Fprintf(outFile, "const uint8_t %sCells[] PROGMEM = {\n", name)
+	for code := first; code <= last; code++ {
+		cell := make([]byte, cellBytes)
+		if g, ok := glyphs[code]; ok {
+			var clipped bool
+			cell, clipped = render(g, width, height)
+			if clipped {
+				warnf("glyph 0x%04X does not fit the %dx%d cell and was clipped", code, width, height)
+				clippedGlyphs++
+			}
+		}
+		fmt.Fprint(outFile, "  ")
+		for _, b := range cell {
+			fmt.Fprintf(outFile, "0x%02X, ", b)
+		}
+		fmt.Fprintf(outFile, "// 0x%04X\n", code)
+	}
+	fmt.Fprint(outFile, "};\n")
+	if clippedGlyphs > 0 {
+		fmt.Printf("Clipped %d glyphs to the %dx%d cell\n", clippedGlyphs, width, height)
+	}
+}
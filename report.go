@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+// printDuplicateReport lists glyphs whose bitmaps are byte-identical and
+// how much the bitmap array would shrink if they shared one copy. It does
+// not change the generated output.
+func printDuplicateReport(glyphs []*gfx.Glyph, verbose bool) {
+	groups := map[string][]*gfx.Glyph{}
+	var keys []string
+	for _, g := range glyphs {
+		if len(g.Bitmap) == 0 {
+			continue
+		}
+		key := fmt.Sprintf("%dx%d:%s", g.Width, g.Height, g.PackedBitmap())
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], g)
+	}
+
+	var dupGroups [][]*gfx.Glyph
+	dupGlyphs, saved := 0, 0
+	for _, key := range keys {
+		group := groups[key]
+		if len(group) < 2 {
+			continue
+		}
+		dupGroups = append(dupGroups, group)
+		dupGlyphs += len(group)
+		saved += (len(group) - 1) * len(group[0].PackedBitmap())
+	}
+	sort.SliceStable(dupGroups, func(i, j int) bool {
+		return len(dupGroups[i]) > len(dupGroups[j])
+	})
+
+	fmt.Printf("Duplicate bitmaps: %d glyphs in %d groups, %d bytes could be saved\n",
+		dupGlyphs, len(dupGroups), saved)
+	if !verbose {
+		return
+	}
+	for _, group := range dupGroups {
+		fmt.Printf("  %d bytes:", len(group[0].PackedBitmap()))
+		for _, g := range group {
+			fmt.Printf(" 0x%04X", g.Code)
+		}
+		fmt.Println()
+	}
+}
+
+// printPackReport compares, per glyph and in total, the size of the bitmap
+// data with BDF row padding, as older versions wrote it, and packed
+// continuously as GFX renderers read it.
+func printPackReport(glyphs []*gfx.Glyph, verbose bool) {
+	padded, _ := gfx.PackGlyphsPadded(glyphs)
+	packed, _ := gfx.PackGlyphs(glyphs, 1)
+	for _, g := range glyphs {
+		p, c := len(g.Bitmap), len(g.PackedBitmap())
+		if p == c && !verbose {
+			continue
+		}
+		fmt.Printf("  0x%04X %dx%d: %d -> %d bytes (%s saved)\n", g.Code, g.Width, g.Height, p, c, percentSaved(p, c))
+	}
+	fmt.Printf("Bitmap data: %d bytes row-padded, %d bytes packed (%s saved)\n",
+		len(padded), len(packed), percentSaved(len(padded), len(packed)))
+}
+
+func percentSaved(before, after int) string {
+	if before == 0 {
+		return "0.0%"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(before-after)/float64(before))
+}
+
+// printDeltaReport compares the bitmap size with and without delta
+// records, counting the GFXdelta entries they need.
+func printDeltaReport(glyphs []*gfx.Glyph) {
+	raw, _ := gfx.PackGlyphs(glyphs, 1)
+	delta, _, deltas := gfx.PackGlyphsDelta(glyphs)
+	size := len(delta) + 4*len(deltas)
+	fmt.Printf("Delta bitmaps: %d glyphs stored as deltas, %d bytes instead of %d (%s saved)\n",
+		len(deltas), size, len(raw), percentSaved(len(raw), size))
+}
+
+// printAdvanceIndexReport compares the xAdvance column of the glyph table
+// with the advance indexes and the table of distinct advances replacing it.
+func printAdvanceIndexReport(font *gfx.Font, wideAdvance bool) {
+	contiguous, _ := font.Contiguous()
+	size := 1
+	if wideAdvance {
+		size = 2
+	}
+	advances := contiguous.Advances()
+	standard := size * len(contiguous.Glyphs)
+	indexed := len(contiguous.Glyphs) + size*len(advances)
+	fmt.Printf("Advance index: %d distinct advances, %d bytes instead of %d (%s saved)\n",
+		len(advances), indexed, standard, percentSaved(standard, indexed))
+	if indexed >= standard {
+		warnf("the advance index does not save flash: it replaces xAdvance by an index of the same size, which only pays with -wide-advance")
+	}
+}
+
+// printHuffmanReport compares the bitmap size with and without Huffman
+// coding, counting the code table and the padding of every glyph to a
+// whole byte.
+func printHuffmanReport(glyphs []*gfx.Glyph) {
+	raw, _ := gfx.PackGlyphs(glyphs, 1)
+	coded, _, table := gfx.PackGlyphsHuffman(glyphs)
+	size := len(coded) + 2*len(table.Counts) + len(table.Symbols)
+	ratio := 0.0
+	if len(raw) > 0 {
+		ratio = float64(size) / float64(len(raw))
+	}
+	fmt.Printf("Huffman bitmaps: %d distinct bytes, %d bytes with the table instead of %d (ratio %.2f, %s saved)\n",
+		len(table.Symbols), size, len(raw), ratio, percentSaved(len(raw), size))
+	if size >= len(raw) {
+		warnf("Huffman coding does not save flash for this font, the code table takes more than it gains")
+	}
+}
+
+// printCompactReport compares the size of the compact glyph records and
+// their index with the 7-byte GFXglyph table they replace.
+func printCompactReport(font *gfx.Font) {
+	contiguous, _ := font.Contiguous()
+	records, index := gfx.EncodeCompact(contiguous.Glyphs)
+	standard := 7 * len(contiguous.Glyphs)
+	size := len(records) + 4*len(index)
+	fmt.Printf("Compact glyph records: %d bytes instead of %d (%s saved)\n", size, standard, percentSaved(standard, size))
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+// generateRust writes the font as a Rust module for embedded-graphics style
+// projects. The Glyph struct mirrors the fields of GFXglyph and prefix is
+// prepended to every static and constant.
+func generateRust(filename string, font *gfx.Font, prefix string) {
+	if err := font.CheckCodes16(); err != nil {
+		log.Fatal(err)
+	}
+	contiguous, _ := font.Contiguous()
+	glyphs := contiguous.Glyphs
+	bitmapData, offsets := gfx.PackGlyphs(glyphs, 1)
+	if err := checkGlyphTable(glyphs, offsets, rustGlyphFields); err != nil {
+		log.Fatal(err)
+	}
+	if r := outputTypeRange["u8"]; font.YAdvance() < r[0] || font.YAdvance() > r[1] {
+		log.Fatalf("yAdvance %d does not fit u8", font.YAdvance())
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "#[derive(Clone, Copy, Debug)]\n")
+	fmt.Fprintf(&buf, "pub struct Glyph {\n")
+	for _, f := range rustGlyphFields {
+		fmt.Fprintf(&buf, "    pub %s: %s,\n", f.name, f.typ)
+	}
+	fmt.Fprintf(&buf, "}\n\n")
+
+	first, last := font.Range()
+	fmt.Fprintf(&buf, "pub const %s_ASCENT: i16 = %d;\n", prefix, font.Ascent)
+	fmt.Fprintf(&buf, "pub const %s_DESCENT: i16 = %d;\n", prefix, font.Descent)
+	fmt.Fprintf(&buf, "pub const %s_FIRST: u16 = 0x%x;\n", prefix, first)
+	fmt.Fprintf(&buf, "pub const %s_LAST: u16 = 0x%x;\n", prefix, last)
+	fmt.Fprintf(&buf, "pub const %s_Y_ADVANCE: u8 = %d;\n", prefix, font.YAdvance())
+	if font.HasNotdef {
+		fmt.Fprintf(&buf, "pub const %s_NOTDEF_INDEX: usize = 0;\n", prefix)
+	}
+	if font.CodeBase != 0 {
+		fmt.Fprintf(&buf, "/// Add to a glyph code to get the real codepoint.\n")
+		fmt.Fprintf(&buf, "pub const %s_CODE_BASE: u32 = 0x%X;\n", prefix, font.CodeBase)
+	}
+	fmt.Fprintln(&buf)
+
+	fmt.Fprintf(&buf, "pub static %s_BITMAPS: &[u8] = &[\n    ", prefix)
+	// A line per glyph height, unless the font has none.
+	wrap := font.Ascent + font.Descent
+	for i, b := range bitmapData {
+		if wrap > 0 && i > 0 && i%wrap == 0 {
+			fmt.Fprint(&buf, "\n    ")
+		}
+		fmt.Fprintf(&buf, "0x%02X, ", b)
+	}
+	fmt.Fprintf(&buf, "\n];\n\n")
+
+	fmt.Fprintf(&buf, "pub static %s_GLYPHS: &[Glyph] = &[\n", prefix)
+	for i, g := range glyphs {
+		fmt.Fprintf(&buf, "    Glyph { bitmap_offset: %5d, width: %2d, height: %2d, x_advance: %2d, x_offset: %3d, y_offset: %3d }, // 0x%04X\n",
+			offsets[i], g.Width, g.Height, g.XAdvance, g.XOffset, g.YOffsetTFT(), g.Code)
+	}
+	fmt.Fprint(&buf, "];\n")
+
+	if err := writeOutput(filename, buf.Bytes()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// rustGlyphFields is the Glyph struct of the Rust output.
+var rustGlyphFields = []outputField{
+	{"bitmap_offset", "u16"},
+	{"width", "u8"},
+	{"height", "u8"},
+	{"x_advance", "u8"},
+	{"x_offset", "i8"},
+	{"y_offset", "i8"},
+}
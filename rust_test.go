@@ -0,0 +1,19 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+func TestCheckGlyphTableRust(t *testing.T) {
+	glyphs := []*gfx.Glyph{
+		{Code: 0x41, Width: 8, Height: 8, XAdvance: 9, YOffset: -2},
+		{Code: 0x42, Width: 8, Height: 8, XAdvance: 9, XOffset: -129, YOffset: -2},
+	}
+	err := checkGlyphTable(glyphs, []int{0, 8}, rustGlyphFields)
+	const want = "glyph 0x0042: x_offset -129 does not fit i8"
+	if err == nil || err.Error() != want {
+		t.Errorf("got %v, want %q", err, want)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+const selfTestBDF = `STARTFONT 2.1
+FONT_ASCENT 7
+FONT_DESCENT 1
+CHARS 2
+STARTCHAR space
+ENCODING 32
+DWIDTH 6 0
+BBX 0 0 0 0
+BITMAP
+ENDCHAR
+STARTCHAR A
+ENCODING 65
+DWIDTH 6 0
+BBX 5 7 0 0
+BITMAP
+20
+50
+88
+88
+F8
+88
+88
+ENDCHAR
+ENDFONT
+`
+
+const selfTestA = `..#..
+.#.#.
+#...#
+#...#
+#####
+#...#
+#...#
+`
+
+// runSelfTest parses an embedded font, packs it like the header emitter
+// does and checks that glyph 'A' unpacks to the expected picture.
+func runSelfTest() {
+	got, err := selfTest()
+	if err == nil && got != selfTestA {
+		err = fmt.Errorf("glyph 'A' rendered as\n%swant\n%s", got, selfTestA)
+	}
+	if err != nil {
+		fmt.Printf("FAIL: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(got)
+	fmt.Println("PASS")
+}
+
+func selfTest() (string, error) {
+	font, err := gfx.ParseBDF(strings.NewReader(selfTestBDF))
+	if err != nil {
+		return "", err
+	}
+	if len(font.Glyphs) != 2 {
+		return "", fmt.Errorf("parsed %d glyphs, want 2", len(font.Glyphs))
+	}
+
+	bitmapData, offsets := gfx.PackGlyphs(font.Glyphs, 1)
+	g := font.Glyphs[1]
+	if g.Code != 'A' {
+		return "", fmt.Errorf("second glyph is 0x%04X, want 0x0041", g.Code)
+	}
+	// GFX bitmaps are a continuous bit stream, rows are not padded.
+	unpacked := &gfx.Glyph{Width: g.Width, Height: g.Height}
+	unpacked.Bitmap = make([]byte, g.Height*g.BytesPerRow())
+	for i := 0; i < g.Width*g.Height; i++ {
+		if bitmapData[offsets[1]+i/8]&(0x80>>(i%8)) != 0 {
+			unpacked.SetPixel(i%g.Width, i/g.Width)
+		}
+	}
+	return unpacked.ASCII(), nil
+}
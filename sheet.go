@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"log"
+	"path/filepath"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+// sheetGlyph is the atlas entry of one glyph: its rectangle in the sheet
+// and the metrics to place it. yOffset is the top of the bitmap relative
+// to the baseline, with y growing downwards as in GFX.
+type sheetGlyph struct {
+	Code     int `json:"code"`
+	X        int `json:"x"`
+	Y        int `json:"y"`
+	W        int `json:"w"`
+	H        int `json:"h"`
+	XOffset  int `json:"xOffset"`
+	YOffset  int `json:"yOffset"`
+	XAdvance int `json:"xAdvance"`
+}
+
+type sheetAtlas struct {
+	Image      string       `json:"image"`
+	Width      int          `json:"width"`
+	Height     int          `json:"height"`
+	LineHeight int          `json:"lineHeight"`
+	Ascent     int          `json:"ascent"`
+	Glyphs     []sheetGlyph `json:"glyphs"`
+}
+
+// writeSheet draws every glyph into a PNG sprite sheet, white on
+// transparent, and optionally writes a JSON atlas of where each glyph is.
+// The glyphs are laid out in codepoint order in a grid of the given number
+// of columns, with cells as large as the largest bitmap plus a pixel of
+// spacing, so the same font always gives the same sheet.
+func writeSheet(filename, atlasFile string, font *gfx.Font, columns int) {
+	cellWidth, cellHeight := 0, 0
+	for _, g := range font.Glyphs {
+		cellWidth, cellHeight = max(cellWidth, g.Width), max(cellHeight, g.Height)
+	}
+	cellWidth, cellHeight = cellWidth+1, cellHeight+1
+	columns = min(columns, len(font.Glyphs))
+	rows := (len(font.Glyphs) + columns - 1) / columns
+
+	palette := color.Palette{color.Transparent, color.White}
+	img := image.NewPaletted(image.Rect(0, 0, columns*cellWidth, rows*cellHeight), palette)
+	atlas := sheetAtlas{
+		Image:      filepath.Base(filename),
+		Width:      img.Rect.Dx(),
+		Height:     img.Rect.Dy(),
+		LineHeight: font.YAdvance(),
+		Ascent:     font.Ascent,
+	}
+	for i, g := range font.Glyphs {
+		x0, y0 := i%columns*cellWidth, i/columns*cellHeight
+		for y := 0; y < g.Height; y++ {
+			for x := 0; x < g.Width; x++ {
+				if g.Pixel(x, y) {
+					img.SetColorIndex(x0+x, y0+y, 1)
+				}
+			}
+		}
+		atlas.Glyphs = append(atlas.Glyphs, sheetGlyph{
+			Code: g.Code, X: x0, Y: y0, W: g.Width, H: g.Height,
+			XOffset: g.XOffset, YOffset: g.YOffsetTFT(), XAdvance: g.XAdvance,
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		log.Fatal(err)
+	}
+	if err := writeOutput(filename, buf.Bytes()); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Wrote a %dx%d sprite sheet of %d glyphs to %s\n", atlas.Width, atlas.Height, len(font.Glyphs), filename)
+
+	if atlasFile == "" {
+		return
+	}
+	data, err := json.MarshalIndent(atlas, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := writeOutput(atlasFile, append(data, '\n')); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+func TestWriteSheet(t *testing.T) {
+	box := &gfx.Glyph{Code: 0x41, Width: 4, Height: 4, XAdvance: 5, Bitmap: []byte{0xF0, 0x90, 0x90, 0xF0}}
+	bar := &gfx.Glyph{Code: 0x42, Width: 1, Height: 6, XAdvance: 2, YOffset: -2, Bitmap: []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80}}
+	space := &gfx.Glyph{Code: 0x43, XAdvance: 3}
+	font := &gfx.Font{Ascent: 6, Descent: 2, Glyphs: []*gfx.Glyph{box, bar, space}}
+
+	dir := t.TempDir()
+	sheetFile, atlasFile := filepath.Join(dir, "sheet.png"), filepath.Join(dir, "sheet.json")
+	writeSheet(sheetFile, atlasFile, font, 2)
+
+	f, err := os.Open(sheetFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Two columns of cells 5x7, the largest bitmap plus a pixel, and two
+	// rows.
+	if b := img.Bounds(); b.Dx() != 10 || b.Dy() != 14 {
+		t.Fatalf("sheet is %dx%d, want 10x14", b.Dx(), b.Dy())
+	}
+	set := func(x, y int) bool { _, _, _, a := img.At(x, y).RGBA(); return a != 0 }
+	for _, g := range []struct {
+		glyph  *gfx.Glyph
+		x0, y0 int
+	}{{box, 0, 0}, {bar, 5, 0}, {space, 0, 7}} {
+		for y := 0; y < 7; y++ {
+			for x := 0; x < 5; x++ {
+				want := x < g.glyph.Width && y < g.glyph.Height && g.glyph.Pixel(x, y)
+				if got := set(g.x0+x, g.y0+y); got != want {
+					t.Errorf("glyph 0x%04X pixel %d,%d is %v, want %v", g.glyph.Code, x, y, got, want)
+				}
+			}
+		}
+	}
+
+	data, err := os.ReadFile(atlasFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var atlas sheetAtlas
+	if err := json.Unmarshal(data, &atlas); err != nil {
+		t.Fatal(err)
+	}
+	if atlas.Image != "sheet.png" || atlas.Width != 10 || atlas.Height != 14 || atlas.LineHeight != 8 || atlas.Ascent != 6 {
+		t.Errorf("atlas %+v, want sheet.png of 10x14 with a line height of 8 and an ascent of 6", atlas)
+	}
+	want := []sheetGlyph{
+		{Code: 0x41, X: 0, Y: 0, W: 4, H: 4, YOffset: -4, XAdvance: 5},
+		{Code: 0x42, X: 5, Y: 0, W: 1, H: 6, YOffset: -4, XAdvance: 2},
+		{Code: 0x43, X: 0, Y: 7, XAdvance: 3},
+	}
+	if len(atlas.Glyphs) != len(want) {
+		t.Fatalf("atlas glyphs %+v, want %+v", atlas.Glyphs, want)
+	}
+	for i := range want {
+		if atlas.Glyphs[i] != want[i] {
+			t.Errorf("atlas glyph %d = %+v, want %+v", i, atlas.Glyphs[i], want[i])
+		}
+	}
+}
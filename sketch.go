@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+// sketchDriver is the Adafruit driver code of a -sketch-display.
+type sketchDriver struct {
+	include string   // driver header
+	pins    []string // pin defines, to be changed to match the wiring
+	declare string   // global display object
+	begin   string   // initialization in setup
+	clear   string
+	color   string
+	show    string // pushes the frame buffer, if the driver has one
+}
+
+var sketchDisplays = map[string]sketchDriver{
+	"ssd1306": {
+		include: "Adafruit_SSD1306.h",
+		declare: "Adafruit_SSD1306 display(128, 64, &Wire, -1);",
+		begin:   "display.begin(SSD1306_SWITCHCAPVCC, 0x3C);",
+		clear:   "display.clearDisplay();",
+		color:   "SSD1306_WHITE",
+		show:    "display.display();",
+	},
+	"sh1106": {
+		include: "Adafruit_SH110X.h",
+		declare: "Adafruit_SH1106G display(128, 64, &Wire, -1);",
+		begin:   "display.begin(0x3C, true);",
+		clear:   "display.clearDisplay();",
+		color:   "SH110X_WHITE",
+		show:    "display.display();",
+	},
+	"st7735": {
+		include: "Adafruit_ST7735.h",
+		pins:    []string{"TFT_CS 10", "TFT_DC 9", "TFT_RST 8"},
+		declare: "Adafruit_ST7735 display(TFT_CS, TFT_DC, TFT_RST);",
+		begin:   "display.initR(INITR_BLACKTAB);",
+		clear:   "display.fillScreen(ST77XX_BLACK);",
+		color:   "ST77XX_WHITE",
+	},
+	"st7789": {
+		include: "Adafruit_ST7789.h",
+		pins:    []string{"TFT_CS 10", "TFT_DC 9", "TFT_RST 8"},
+		declare: "Adafruit_ST7789 display(TFT_CS, TFT_DC, TFT_RST);",
+		begin:   "display.init(240, 320);",
+		clear:   "display.fillScreen(ST77XX_BLACK);",
+		color:   "ST77XX_WHITE",
+	},
+	"ili9341": {
+		include: "Adafruit_ILI9341.h",
+		pins:    []string{"TFT_CS 10", "TFT_DC 9"},
+		declare: "Adafruit_ILI9341 display(TFT_CS, TFT_DC);",
+		begin:   "display.begin();",
+		clear:   "display.fillScreen(ILI9341_BLACK);",
+		color:   "ILI9341_WHITE",
+	},
+}
+
+// sketchDisplayNames lists the -sketch-display values for messages.
+func sketchDisplayNames() string {
+	var names []string
+	for name := range sketchDisplays {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// writeSketch writes an Arduino sketch that includes the header written to
+// headerFile and draws text with the font on the display, followed by
+// every glyph that print() can reach, those up to 0xFF. The header has to
+// be copied next to the sketch.
+func writeSketch(filename, headerFile string, font *gfx.Font, name, display, text string) {
+	d, ok := sketchDisplays[display]
+	if !ok {
+		log.Fatalf("Unknown -sketch-display %q, want one of %s", display, sketchDisplayNames())
+	}
+	first, last := font.Range()
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Test sketch for the %s font, generated by bdf2tft. Copy %s next\n", name, filepath.Base(headerFile))
+	fmt.Fprint(&out, "// to this file, check the display setup below and flash it.\n")
+	fmt.Fprint(&out, "#include <Adafruit_GFX.h>\n")
+	fmt.Fprintf(&out, "#include <%s>\n", d.include)
+	fmt.Fprintf(&out, "#include \"%s\"\n\n", filepath.Base(headerFile))
+	if len(d.pins) > 0 {
+		fmt.Fprint(&out, "// Change these to match the wiring.\n")
+		for _, p := range d.pins {
+			fmt.Fprintf(&out, "#define %s\n", p)
+		}
+		fmt.Fprint(&out, "\n")
+	}
+	fmt.Fprintf(&out, "%s\n\n", d.declare)
+	fmt.Fprint(&out, "void setup() {\n")
+	fmt.Fprintf(&out, "  %s\n", d.begin)
+	fmt.Fprintf(&out, "  %s\n", d.clear)
+	fmt.Fprintf(&out, "  display.setFont(&%s);\n", name)
+	fmt.Fprintf(&out, "  display.setTextColor(%s);\n", d.color)
+	fmt.Fprint(&out, "  display.setTextWrap(true);\n")
+	fmt.Fprint(&out, "  // GFX fonts are drawn from the baseline, so start one ascent down.\n")
+	fmt.Fprintf(&out, "  display.setCursor(0, %d);\n", font.Ascent)
+	if text != "" {
+		fmt.Fprintf(&out, "  display.println(F(%s));\n", gfx.CString(text))
+	}
+	if first <= 0xFF {
+		fmt.Fprintf(&out, "  for (uint16_t c = 0x%02X; c <= 0x%02X; c++) {\n", first, min(last, 0xFF))
+		fmt.Fprint(&out, "    if (c != '\\n' && c != '\\r') {\n")
+		fmt.Fprint(&out, "      display.write(c);\n")
+		fmt.Fprint(&out, "    }\n")
+		fmt.Fprint(&out, "  }\n")
+	}
+	if d.show != "" {
+		fmt.Fprintf(&out, "  %s\n", d.show)
+	}
+	fmt.Fprint(&out, "}\n\n")
+	fmt.Fprint(&out, "void loop() {}\n")
+
+	if err := writeOutput(filename, out.Bytes()); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Wrote a %s test sketch to %s\n", display, filename)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+func TestWriteSketch(t *testing.T) {
+	box := func(code int) *gfx.Glyph {
+		return &gfx.Glyph{Code: code, Width: 4, Height: 4, XAdvance: 5, YOffset: -4, Bitmap: []byte{0xF0, 0xF0, 0xF0, 0xF0}}
+	}
+	font := &gfx.Font{Ascent: 6, Descent: 2, DefaultChar: -1, Glyphs: []*gfx.Glyph{box(0x20), box(0x41), box(0x100)}}
+
+	filename := filepath.Join(t.TempDir(), "test.ino")
+	writeSketch(filename, "out/font.h", font, "Test", "st7735", `say "hi"`)
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sketch := string(data)
+	for _, want := range []string{
+		"#include <Adafruit_ST7735.h>\n#include \"font.h\"\n\n",
+		"#define TFT_CS 10\n",
+		"Adafruit_ST7735 display(TFT_CS, TFT_DC, TFT_RST);\n",
+		"  display.setFont(&Test);\n",
+		"  display.setCursor(0, 6);\n",
+		"  display.println(F(\"say \\\"hi\\\"\"));\n",
+		// The glyphs print() can reach, up to 0xFF.
+		"  for (uint16_t c = 0x20; c <= 0xFF; c++) {\n",
+	} {
+		if !strings.Contains(sketch, want) {
+			t.Errorf("sketch without %q:\n%s", want, sketch)
+		}
+	}
+	// The ST7735 has no frame buffer to push.
+	if strings.Contains(sketch, "display.display();") {
+		t.Errorf("sketch pushes a frame buffer:\n%s", sketch)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+// generateSSD1306 writes the font in the page layout of SSD1306 display
+// memory, ready to be copied into a frame buffer such as the one of
+// Adafruit_SSD1306::getBuffer(). Every glyph is drawn into a cell as wide
+// as its advance and as high as ascent plus descent rounded up to whole
+// 8-row pages, with the top of the font at the top of the cell, and stored
+// column-major one page after the other, see gfx.Glyph.ColumnBitmap.
+// Codes in the range without a glyph get a width of zero.
+func generateSSD1306(filename string, font *gfx.Font, name string) {
+	height := font.Ascent + font.Descent
+	if height <= 0 {
+		log.Fatalf("font ascent plus descent is %d, page cells need a height", height)
+	}
+	pages := (height + 7) / 8
+	first, last := font.Range()
+
+	glyphs := map[int]*gfx.Glyph{}
+	for _, g := range font.Glyphs {
+		if g.XAdvance < 0 || g.XAdvance > 0xFF {
+			log.Fatalf("glyph 0x%04X: advance %d does not fit the uint8_t cell width", g.Code, g.XAdvance)
+		}
+		glyphs[g.Code] = g
+	}
+
+	outFile, err := createOutput(filename)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer outFile.Close()
+
+	fmt.Fprintf(outFile, "// Glyphs in SSD1306 page layout: %s_PAGES pages of 8 rows each, one byte\n", name)
+	fmt.Fprint(outFile, "// per column with the top row in bit 0, one page after the other.\n")
+	fmt.Fprintf(outFile, "#define %s_PAGES %d\n", name, pages)
+	fmt.Fprintf(outFile, "#define %s_HEIGHT %d\n", name, pages*8)
+	fmt.Fprintf(outFile, "#define %s_FIRST 0x%x\n", name, first)
+	fmt.Fprintf(outFile, "#define %s_LAST 0x%x\n\n", name, last)
+
+	var offsets, widths []int
+	offset, clippedGlyphs := 0, 0
+	fmt.Fprintf(outFile, "const uint8_t %sBitmaps[] PROGMEM = {\n", name)
+	for code := first; code <= last; code++ {
+		g, ok := glyphs[code]
+		if !ok {
+			offsets, widths = append(offsets, offset), append(widths, 0)
+			continue
+		}
+		cell, clipped := font.RenderCell(g, g.XAdvance, height)
+		if clipped {
+			warnf("glyph 0x%04X does not fit its %dx%d cell and was clipped", code, g.XAdvance, height)
+			clippedGlyphs++
+		}
+		// The rows below the descent fill up the last page.
+		bytesPerRow := (g.XAdvance + 7) / 8
+		cell = append(cell, make([]byte, (pages*8-height)*bytesPerRow)...)
+		columns := (&gfx.Glyph{Width: g.XAdvance, Height: pages * 8, Bitmap: cell}).ColumnBitmap()
+		if offset > 0xFFFF {
+			log.Fatalf("glyph 0x%04X: offset %d does not fit uint16_t, use fewer glyphs", code, offset)
+		}
+		offsets, widths = append(offsets, offset), append(widths, g.XAdvance)
+		offset += len(columns)
+
+		if len(columns) == 0 {
+			continue
+		}
+		fmt.Fprint(outFile, "  ")
+		for _, b := range columns {
+			fmt.Fprintf(outFile, "0x%02X, ", b)
+		}
+		fmt.Fprintf(outFile, "// 0x%04X\n", code)
+	}
+	if offset == 0 {
+		// C does not allow empty arrays.
+		fmt.Fprint(outFile, "  0x00, // no glyph has bitmap data\n")
+	}
+	fmt.Fprint(outFile, "};\n\n")
+
+	// Empty cells at the end would point one past the last byte; like in
+	// the GFX header they share the offset of the last cell with data.
+	lastData := 0
+	for i, w := range widths {
+		if w > 0 {
+			lastData = offsets[i]
+		}
+	}
+	for i := len(widths) - 1; i >= 0 && widths[i] == 0; i-- {
+		offsets[i] = lastData
+	}
+
+	fmt.Fprintf(outFile, "// Offset of every glyph in %sBitmaps, from %s_FIRST to %s_LAST.\n", name, name, name)
+	fmt.Fprintf(outFile, "const uint16_t %sOffsets[] PROGMEM = {", name)
+	for i, o := range offsets {
+		if i%8 == 0 {
+			fmt.Fprint(outFile, "\n  ")
+		}
+		fmt.Fprintf(outFile, "%d, ", o)
+	}
+	fmt.Fprint(outFile, "\n};\n\n")
+
+	fmt.Fprintf(outFile, "// Width of every glyph, which is also its advance.\n")
+	fmt.Fprintf(outFile, "const uint8_t %sWidths[] PROGMEM = {", name)
+	for i, w := range widths {
+		if i%16 == 0 {
+			fmt.Fprint(outFile, "\n  ")
+		}
+		fmt.Fprintf(outFile, "%d, ", w)
+	}
+	fmt.Fprint(outFile, "\n};\n\n")
+
+	fmt.Fprintf(outFile, "// %sBlit copies the glyph of code into buffer, the display memory of a\n", name)
+	fmt.Fprint(outFile, "// display width columns wide and pages pages high, with its left column at\n")
+	fmt.Fprint(outFile, "// x and its top row at the top of page. The columns it covers are\n")
+	fmt.Fprint(outFile, "// overwritten; those outside the display are skipped. It returns the\n")
+	fmt.Fprint(outFile, "// advance, 0 for codes without a glyph.\n")
+	fmt.Fprintf(outFile, "static inline uint8_t %sBlit(uint8_t *buffer, int16_t width, uint8_t pages, int16_t x, uint8_t page, uint16_t code) {\n", name)
+	fmt.Fprintf(outFile, "  if (code < 0x%x || code > 0x%x) {\n", first, last)
+	fmt.Fprint(outFile, "    return 0;\n")
+	fmt.Fprint(outFile, "  }\n")
+	fmt.Fprintf(outFile, "  code -= 0x%x;\n", first)
+	fmt.Fprintf(outFile, "  uint16_t offset = pgm_read_word(&%sOffsets[code]);\n", name)
+	fmt.Fprintf(outFile, "  uint8_t w = pgm_read_byte(&%sWidths[code]);\n", name)
+	fmt.Fprintf(outFile, "  for (uint8_t p = 0; p < %s_PAGES && page + p < pages; p++) {\n", name)
+	fmt.Fprint(outFile, "    for (uint8_t i = 0; i < w; i++) {\n")
+	fmt.Fprint(outFile, "      int16_t column = x + i;\n")
+	fmt.Fprint(outFile, "      if (column >= 0 && column < width) {\n")
+	fmt.Fprintf(outFile, "        buffer[(page + p) * width + column] = pgm_read_byte(&%sBitmaps[offset + p * w + i]);\n", name)
+	fmt.Fprint(outFile, "      }\n")
+	fmt.Fprint(outFile, "    }\n")
+	fmt.Fprint(outFile, "  }\n")
+	fmt.Fprint(outFile, "  return w;\n")
+	fmt.Fprint(outFile, "}\n")
+
+	if clippedGlyphs > 0 {
+		fmt.Printf("Clipped %d glyphs to their cells\n", clippedGlyphs)
+	}
+	fmt.Printf("Wrote %d glyphs of %d pages, %d bitmap bytes\n", len(font.Glyphs), pages, offset)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+func TestSSD1306Pages(t *testing.T) {
+	box := func(code int) *gfx.Glyph {
+		return &gfx.Glyph{Code: code, Width: 4, Height: 4, XAdvance: 5, Bitmap: []byte{0xF0, 0xF0, 0xF0, 0xF0}}
+	}
+	font := &gfx.Font{Ascent: 6, Descent: 2, Glyphs: []*gfx.Glyph{box(0x41), box(0x43)}}
+
+	filename := filepath.Join(t.TempDir(), "font.h")
+	generateSSD1306(filename, font, "Test")
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// One page: the box covers rows 2 to 5, bits 2 to 5 of its four
+	// columns, and the fifth column of the advance is blank. 0x42 has no
+	// glyph and no width.
+	for _, want := range []string{
+		"#define Test_PAGES 1\n",
+		"  0x3C, 0x3C, 0x3C, 0x3C, 0x00, // 0x0041\n  0x3C, 0x3C, 0x3C, 0x3C, 0x00, // 0x0043\n",
+		"const uint16_t TestOffsets[] PROGMEM = {\n  0, 5, 5, \n};",
+		"const uint8_t TestWidths[] PROGMEM = {\n  5, 0, 5, \n};",
+		"static inline uint8_t TestBlit(",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("header without %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestSSD1306TrailingEmptyCells(t *testing.T) {
+	box := &gfx.Glyph{Code: 0x41, Width: 4, Height: 4, XAdvance: 5, Bitmap: []byte{0xF0, 0xF0, 0xF0, 0xF0}}
+	space := &gfx.Glyph{Code: 0x43, Name: "zero-width space"}
+	font := &gfx.Font{Ascent: 6, Descent: 2, Glyphs: []*gfx.Glyph{box, space}}
+
+	filename := filepath.Join(t.TempDir(), "font.h")
+	generateSSD1306(filename, font, "Test")
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// One page of 5 columns for 0x41; 0x42 has no glyph and 0x43 no
+	// width, and both point at the bytes of 0x41 instead of past them.
+	if !strings.Contains(string(data), "const uint16_t TestOffsets[] PROGMEM = {\n  0, 0, 0, \n};") {
+		t.Errorf("offsets of the empty cells are not 0:\n%s", data)
+	}
+	if !strings.Contains(string(data), "const uint8_t TestWidths[] PROGMEM = {\n  5, 0, 0, \n};") {
+		t.Errorf("widths are not 5, 0, 0:\n%s", data)
+	}
+}
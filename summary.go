@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+// Warnings go through their own logger so that everything written to the
+// standard logger is an error. Errors end the program through log.Fatal,
+// so fatalOutput prints the summary right after the error message.
+var (
+	warnLog  = log.New(os.Stderr, "", log.LstdFlags)
+	warnings int
+)
+
+type fatalOutput struct{}
+
+func (fatalOutput) Write(p []byte) (int, error) {
+	n, err := os.Stderr.Write(p)
+	printSummary(1)
+	return n, err
+}
+
+func warnf(format string, args ...any) {
+	warnings++
+	warnLog.Printf("warning: "+format, args...)
+}
+
+// printSummary prints the number of warnings and errors to stderr.
+func printSummary(errors int) {
+	fmt.Fprintf(os.Stderr, "%s, %s\n", plural(warnings, "warning"), plural(errors, "error"))
+}
+
+// finish prints the summary of a run that did not fail, and with -werror
+// fails it if there were warnings.
+func finish() {
+	if *werror && warnings > 0 {
+		log.Fatal("warnings are fatal with -werror")
+	}
+	printSummary(0)
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"testing"
+)
+
+func TestWarnfCounts(t *testing.T) {
+	var buf bytes.Buffer
+	defer func(l *log.Logger, n int) { warnLog, warnings = l, n }(warnLog, warnings)
+	warnLog, warnings = log.New(&buf, "", 0), 0
+
+	warnf("glyph 0x%04X: %s", 0x41, "padded")
+	warnf("second")
+	if warnings != 2 {
+		t.Errorf("%d warnings counted, want 2", warnings)
+	}
+	if got, want := buf.String(), "warning: glyph 0x0041: padded\nwarning: second\n"; got != want {
+		t.Errorf("warnings %q, want %q", got, want)
+	}
+}
+
+func TestPlural(t *testing.T) {
+	for n, want := range map[int]string{0: "0 warnings", 1: "1 warning", 2: "2 warnings"} {
+		if got := plural(n, "warning"); got != want {
+			t.Errorf("plural(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
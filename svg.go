@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"log"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+// generateSVG renders text as an SVG specimen with one square per set
+// pixel, laid out like -measure. The view box is in font pixels with the
+// origin of the string on the baseline, and the image is scale times as
+// large; crispEdges keeps the pixels sharp at any size.
+func generateSVG(filename string, font *gfx.Font, text string, scale int) {
+	glyphs, advance := font.Layout(text)
+	if len(glyphs) == 0 {
+		log.Fatalf("no glyphs in the font for the specimen %q", text)
+	}
+	minX, maxX := 0, advance
+	top, bottom := font.Ascent, font.Descent
+	for _, p := range glyphs {
+		if g := p.Glyph; g.Width > 0 && g.Height > 0 {
+			minX = min(minX, p.X+g.XOffset)
+			maxX = max(maxX, p.X+g.XOffset+g.Width)
+			top = max(top, g.YOffset+g.Height)
+			bottom = max(bottom, -g.YOffset)
+		}
+	}
+	width, height := maxX-minX, top+bottom
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"%d %d %d %d\" shape-rendering=\"crispEdges\">\n",
+		width*scale, height*scale, minX, -top, width, height)
+	fmt.Fprint(&out, "<title>")
+	xml.EscapeText(&out, []byte(text))
+	fmt.Fprint(&out, "</title>\n")
+	fmt.Fprint(&out, "<g fill=\"currentColor\">\n")
+	for _, p := range glyphs {
+		g := p.Glyph
+		for y := 0; y < g.Height; y++ {
+			for x := 0; x < g.Width; x++ {
+				if g.Pixel(x, y) {
+					fmt.Fprintf(&out, "<rect x=\"%d\" y=\"%d\" width=\"1\" height=\"1\"/>\n", p.X+g.XOffset+x, y-g.YOffset-g.Height)
+				}
+			}
+		}
+	}
+	fmt.Fprint(&out, "</g>\n</svg>\n")
+
+	if err := writeOutput(filename, out.Bytes()); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Wrote a %dx%d pixel specimen of %d glyphs\n", width, height, len(glyphs))
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+func TestGenerateSVG(t *testing.T) {
+	box := &gfx.Glyph{Code: 0x41, Width: 4, Height: 4, XAdvance: 5, Bitmap: []byte{0xF0, 0x90, 0x90, 0xF0}}
+	dot := &gfx.Glyph{Code: 0x42, Width: 1, Height: 1, XAdvance: 2, YOffset: -2, Bitmap: []byte{0x80}}
+	font := &gfx.Font{Ascent: 6, Descent: 2, DefaultChar: -1, Glyphs: []*gfx.Glyph{box, dot}}
+
+	filename := filepath.Join(t.TempDir(), "specimen.svg")
+	generateSVG(filename, font, "A<B", 2)
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var svg struct {
+		Width   int    `xml:"width,attr"`
+		Height  int    `xml:"height,attr"`
+		ViewBox string `xml:"viewBox,attr"`
+		Title   string `xml:"title"`
+		Rects   []struct {
+			X int `xml:"x,attr"`
+			Y int `xml:"y,attr"`
+		} `xml:"g>rect"`
+	}
+	if err := xml.Unmarshal(data, &svg); err != nil {
+		t.Fatalf("SVG does not parse: %v\n%s", err, data)
+	}
+	// The line from the ascent of 6 to the descent of 2, 7 pixels wide.
+	if svg.Width != 14 || svg.Height != 16 || svg.ViewBox != "0 -6 7 8" || svg.Title != "A<B" {
+		t.Errorf("svg %dx%d, view box %q, title %q, want 14x16, 0 -6 7 8 and A<B", svg.Width, svg.Height, svg.ViewBox, svg.Title)
+	}
+
+	// The ring of the box above the baseline and the dot below it.
+	want := map[[2]int]bool{{5, 1}: true}
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			if box.Pixel(x, y) {
+				want[[2]int{x, y - 4}] = true
+			}
+		}
+	}
+	got := map[[2]int]bool{}
+	for _, r := range svg.Rects {
+		got[[2]int{r.X, r.Y}] = true
+	}
+	if len(got) != len(svg.Rects) || len(got) != len(want) {
+		t.Fatalf("%d rects, want %d", len(svg.Rects), len(want))
+	}
+	for p := range want {
+		if !got[p] {
+			t.Errorf("no rect at %d,%d", p[0], p[1])
+		}
+	}
+}
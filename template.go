@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// templateChars returns the text of every string literal in a C or C++
+// source file, with escapes decoded, so a font can be cut down to what the
+// firmware prints. Comments, character literals and #include names are
+// skipped.
+func templateChars(filename string) (string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	src := string(data)
+	var text []byte
+	line := 1
+	for i := 0; i < len(src); i++ {
+		switch {
+		case src[i] == '\n':
+			line++
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			line++
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return "", fmt.Errorf("line %d: unterminated comment", line)
+			}
+			line += strings.Count(src[i:i+2+end], "\n")
+			i += end + 3
+		case src[i] == '#' && strings.HasPrefix(strings.TrimLeft(src[i+1:], " \t"), "include"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			line++
+		case src[i] == '\'' || src[i] == '"':
+			quote := src[i]
+			start := line
+			var lit []byte
+			for i++; i < len(src) && src[i] != quote; i++ {
+				if src[i] == '\n' {
+					return "", fmt.Errorf("line %d: unterminated literal", start)
+				}
+				if src[i] != '\\' {
+					lit = append(lit, src[i])
+					continue
+				}
+				b, n, err := unescape(src[i:])
+				if err != nil {
+					return "", fmt.Errorf("line %d: %v", line, err)
+				}
+				lit = append(lit, b...)
+				i += n - 1
+			}
+			if i == len(src) {
+				return "", fmt.Errorf("line %d: unterminated literal", start)
+			}
+			if quote == '"' {
+				text = append(text, lit...)
+			}
+		}
+	}
+	if !utf8.Valid(text) {
+		return "", fmt.Errorf("string literals are not valid UTF-8")
+	}
+	return string(text), nil
+}
+
+// unescape decodes the escape sequence at the start of s and returns its
+// bytes and the length of the sequence.
+func unescape(s string) ([]byte, int, error) {
+	if len(s) < 2 {
+		return nil, 0, fmt.Errorf("unterminated escape")
+	}
+	simple := map[byte]byte{'n': '\n', 't': '\t', 'r': '\r', 'a': '\a', 'b': '\b', 'f': '\f', 'v': '\v',
+		'\\': '\\', '\'': '\'', '"': '"', '?': '?'}
+	if b, ok := simple[s[1]]; ok {
+		return []byte{b}, 2, nil
+	}
+	digits := func(from, maxLen int, isDigit func(byte) bool) string {
+		end := from
+		for end < len(s) && end-from < maxLen && isDigit(s[end]) {
+			end++
+		}
+		return s[from:end]
+	}
+	isHex := func(b byte) bool { return strings.IndexByte("0123456789abcdefABCDEF", b) >= 0 }
+	isOctal := func(b byte) bool { return b >= '0' && b <= '7' }
+	switch s[1] {
+	case 'x':
+		d := digits(2, 2, isHex)
+		if d == "" {
+			return nil, 0, fmt.Errorf("bad \\x escape")
+		}
+		v, _ := strconv.ParseUint(d, 16, 8)
+		return []byte{byte(v)}, 2 + len(d), nil
+	case 'u', 'U':
+		n := 4
+		if s[1] == 'U' {
+			n = 8
+		}
+		d := digits(2, n, isHex)
+		v, err := strconv.ParseUint(d, 16, 32)
+		if len(d) != n || err != nil || !utf8.ValidRune(rune(v)) {
+			return nil, 0, fmt.Errorf("bad \\%c escape", s[1])
+		}
+		return utf8.AppendRune(nil, rune(v)), 2 + n, nil
+	}
+	if d := digits(1, 3, isOctal); d != "" {
+		v, _ := strconv.ParseUint(d, 8, 16)
+		return []byte{byte(v)}, 1 + len(d), nil
+	}
+	return nil, 0, fmt.Errorf("unknown escape \\%c", s[1])
+}
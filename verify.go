@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+// verifyPNGDir compares every glyph that has a reference image in dir
+// pixel for pixel and fails if any differs. References are named after
+// the codepoint in hex, like 0041.png or U+0041.png, and are exactly as
+// large as the glyph bitmap; dark opaque pixels are ink.
+func verifyPNGDir(font *gfx.Font, dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	checked, failed := 0, 0
+	for _, e := range entries {
+		base, ok := strings.CutSuffix(e.Name(), ".png")
+		if !ok || e.IsDir() {
+			continue
+		}
+		code, err := strconv.ParseInt(strings.TrimPrefix(strings.ToUpper(base), "U+"), 16, 32)
+		if err != nil {
+			warnf("%s: not named after a hex codepoint, skipped", e.Name())
+			continue
+		}
+		checked++
+		g, ok := font.Glyph(int(code))
+		if !ok {
+			fmt.Printf("  U+%04X: reference %s has no glyph\n", code, e.Name())
+			failed++
+			continue
+		}
+		ref, err := readPNG(filepath.Join(dir, e.Name()))
+		if err != nil {
+			log.Fatal(err)
+		}
+		if b := ref.Bounds(); b.Dx() != g.Width || b.Dy() != g.Height {
+			fmt.Printf("  U+%04X: glyph is %dx%d, reference %dx%d\n", code, g.Width, g.Height, b.Dx(), b.Dy())
+			failed++
+			continue
+		}
+		if diff := pixelDiff(g, ref); diff > 0 {
+			fmt.Printf("  U+%04X: %d pixels differ\n", code, diff)
+			failed++
+		}
+	}
+	if failed > 0 {
+		log.Fatalf("%d of %d glyphs differ from the references in %s", failed, checked, dir)
+	}
+	fmt.Printf("Verified %d glyphs against %s\n", checked, dir)
+}
+
+func readPNG(filename string) (image.Image, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return img, nil
+}
+
+// pixelDiff counts the pixels where the glyph and the image disagree about
+// ink. A pixel of the image is ink when it is at least half opaque and
+// darker than half intensity.
+func pixelDiff(g *gfx.Glyph, img image.Image) int {
+	b := img.Bounds()
+	diff := 0
+	for y := 0; y < g.Height; y++ {
+		for x := 0; x < g.Width; x++ {
+			r, gr, bl, a := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			ink := a >= 0x8000 && (r+gr+bl)/3 < a/2
+			if ink != g.Pixel(x, y) {
+				diff++
+			}
+		}
+	}
+	return diff
+}
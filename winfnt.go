@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+// winFNTHeaderSize is the size of the version 2.0 FNT header, including
+// the reserved byte after dfBitsOffset.
+const winFNTHeaderSize = 118
+
+// generateWinFNT writes the font as a Windows 2.x raster font resource,
+// version 2.0 of the FNT format, which Windows 3.x still reads. Its
+// characters are single bytes, so only codes 0-255 are kept, taken as
+// Latin-1. FNT cells have no offsets: every glyph is drawn into a cell as
+// wide as its advance and as high as ascent plus descent, clipping ink
+// outside of it. Codes in the range without a glyph show the default
+// character. The 16-bit offsets limit the file to 64 KiB.
+func generateWinFNT(filename string, font *gfx.Font, name string) {
+	var glyphs []*gfx.Glyph
+	dropped := 0
+	for _, g := range font.Glyphs {
+		if g.Code > 0xFF {
+			dropped++
+			continue
+		}
+		glyphs = append(glyphs, g)
+	}
+	if dropped > 0 {
+		warnf("%d glyphs above 0xFF do not fit the 8-bit FNT character set and were left out", dropped)
+	}
+	if len(glyphs) == 0 {
+		log.Fatal("no glyphs in 0x00-0xFF for the FNT character set")
+	}
+	first, last := glyphs[0].Code, glyphs[len(glyphs)-1].Code
+	height := font.Ascent + font.Descent
+	if height <= 0 {
+		log.Fatalf("font ascent plus descent is %d, FNT cells need a height", height)
+	}
+
+	byCode := map[int]*gfx.Glyph{}
+	advances, maxWidth := 0, 0
+	fixed := true
+	for _, g := range glyphs {
+		if g.XAdvance < 0 {
+			log.Fatalf("glyph 0x%04X: negative advance %d does not fit a FNT cell", g.Code, g.XAdvance)
+		}
+		byCode[g.Code] = g
+		advances += g.XAdvance
+		maxWidth = max(maxWidth, g.XAdvance)
+		fixed = fixed && g.XAdvance == glyphs[0].XAdvance
+	}
+	avgWidth := (advances + len(glyphs)/2) / len(glyphs)
+	defaultGlyph := glyphs[0]
+	for _, code := range []int{font.DefaultChar, ' '} {
+		if g, ok := byCode[code]; ok {
+			defaultGlyph = g
+			break
+		}
+	}
+
+	// The character table has an entry for every code from first to
+	// last, plus one for the absolute space, a blank of the average width.
+	entries := last - first + 2
+	bitsOffset := winFNTHeaderSize + 4*entries
+	var bits bytes.Buffer
+	offsets := map[*gfx.Glyph]int{}
+	clipped := 0
+	addGlyph := func(g *gfx.Glyph, width int) {
+		offsets[g] = bitsOffset + bits.Len()
+		cell, c := font.RenderCell(g, width, height)
+		if c {
+			clipped++
+		}
+		// Bitmaps are stored column by column, each column 8 pixels wide
+		// and as high as the cell.
+		bytesPerRow := (width + 7) / 8
+		for col := 0; col < bytesPerRow; col++ {
+			for y := 0; y < height; y++ {
+				bits.WriteByte(cell[y*bytesPerRow+col])
+			}
+		}
+	}
+	for _, g := range glyphs {
+		addGlyph(g, g.XAdvance)
+	}
+	space := &gfx.Glyph{}
+	addGlyph(space, avgWidth)
+	if clipped > 0 {
+		warnf("%d glyphs have ink outside of their FNT cell and were clipped", clipped)
+	}
+
+	face := font.Family()
+	if face == "" {
+		face = name
+	}
+	faceOffset := bitsOffset + bits.Len()
+	size := faceOffset + len(face) + 1
+	if size > 0xFFFF {
+		log.Fatalf("FNT file of %d bytes does not fit the 16-bit offsets of version 2.0, use fewer glyphs", size)
+	}
+
+	widthBytes := 0
+	for _, g := range glyphs {
+		widthBytes += (g.XAdvance + 7) / 8
+	}
+	pitch, pixWidth := byte(1), 0 // the low bit marks a variable pitch
+	if fixed {
+		pitch, pixWidth = 0, glyphs[0].XAdvance
+	}
+	weight := 400
+	if strings.EqualFold(font.Properties["WEIGHT_NAME"], "Bold") {
+		weight = 700
+	}
+	italic := byte(0)
+	if s := strings.ToUpper(font.Properties["SLANT"]); s == "I" || s == "O" {
+		italic = 1
+	}
+	breakChar := 0
+	if ' ' >= first && ' ' <= last {
+		breakChar = ' ' - first
+	}
+	var copyright [60]byte
+	copy(copyright[:len(copyright)-1], font.Properties["COPYRIGHT"])
+
+	var out bytes.Buffer
+	le := func(v any) { binary.Write(&out, binary.LittleEndian, v) }
+	le(uint16(0x0200))                                            // dfVersion
+	le(uint32(size))                                              // dfSize
+	le(copyright)                                                 // dfCopyright
+	le(uint16(0))                                                 // dfType: raster
+	le(uint16((height*72 + 48) / 96))                             // dfPoints
+	le(uint16(96))                                                // dfVertRes
+	le(uint16(96))                                                // dfHorizRes
+	le(uint16(font.Ascent))                                       // dfAscent
+	le(uint16(0))                                                 // dfInternalLeading
+	le(uint16(0))                                                 // dfExternalLeading
+	le([3]byte{italic, 0, 0})                                     // dfItalic, dfUnderline, dfStrikeOut
+	le(uint16(weight))                                            // dfWeight
+	le(byte(0))                                                   // dfCharSet: ANSI
+	le(uint16(pixWidth))                                          // dfPixWidth
+	le(uint16(height))                                            // dfPixHeight
+	le(pitch)                                                     // dfPitchAndFamily
+	le(uint16(avgWidth))                                          // dfAvgWidth
+	le(uint16(maxWidth))                                          // dfMaxWidth
+	le([2]byte{byte(first), byte(last)})                          // dfFirstChar, dfLastChar
+	le([2]byte{byte(defaultGlyph.Code - first), byte(breakChar)}) // dfDefaultChar, dfBreakChar
+	le(uint16(widthBytes + widthBytes%2))                         // dfWidthBytes
+	le(uint32(0))                                                 // dfDevice
+	le(uint32(faceOffset))                                        // dfFace
+	le(uint32(0))                                                 // dfBitsPointer
+	le(uint32(bitsOffset))                                        // dfBitsOffset
+	le(byte(0))                                                   // dfReserved
+
+	for code := first; code <= last; code++ {
+		g, ok := byCode[code]
+		if !ok {
+			g = defaultGlyph
+		}
+		le([2]uint16{uint16(g.XAdvance), uint16(offsets[g])})
+	}
+	le([2]uint16{uint16(avgWidth), uint16(offsets[space])})
+	out.Write(bits.Bytes())
+	out.WriteString(face)
+	out.WriteByte(0)
+
+	if err := writeOutput(filename, out.Bytes()); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Wrote %d characters 0x%02X-0x%02X, %d bytes\n", last-first+1, first, last, out.Len())
+}
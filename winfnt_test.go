@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mhbvr/bdf2gfx/gfx"
+)
+
+func TestWinFNT(t *testing.T) {
+	box := &gfx.Glyph{Code: 0x41, Width: 4, Height: 4, XAdvance: 5, Bitmap: []byte{0xF0, 0xF0, 0xF0, 0xF0}}
+	bar := &gfx.Glyph{Code: 0x43, Width: 1, Height: 6, XAdvance: 3, XOffset: 1, YOffset: -2, Bitmap: []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80}}
+	astral := &gfx.Glyph{Code: 0x100, Width: 4, Height: 4, XAdvance: 5, Bitmap: []byte{0xF0, 0xF0, 0xF0, 0xF0}}
+	font := &gfx.Font{Ascent: 6, Descent: 2, DefaultChar: -1, Glyphs: []*gfx.Glyph{box, bar, astral}}
+
+	filename := filepath.Join(t.TempDir(), "font.fnt")
+	generateWinFNT(filename, font, "Test")
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u16 := func(off int) int { return int(binary.LittleEndian.Uint16(data[off:])) }
+	u32 := func(off int) int { return int(binary.LittleEndian.Uint32(data[off:])) }
+	if u16(0) != 0x0200 || u32(2) != len(data) {
+		t.Fatalf("version %#x and size %d, want 0x200 and %d", u16(0), u32(2), len(data))
+	}
+	if first, last := data[95], data[96]; first != 0x41 || last != 0x43 {
+		t.Errorf("characters 0x%02X-0x%02X, want 0x41-0x43 without 0x100", first, last)
+	}
+	if h, pitch := u16(88), data[90]; h != 8 || pitch != 1 {
+		t.Errorf("height %d and pitch %d, want 8 and a variable pitch", h, pitch)
+	}
+
+	// Entries for 0x41, 0x42, 0x43 and the absolute space; 0x42 shows
+	// the default character, the first glyph.
+	bitsOffset := u32(113)
+	if bitsOffset != winFNTHeaderSize+4*4 {
+		t.Fatalf("bitmaps at %d, want right after 4 table entries", bitsOffset)
+	}
+	entry := func(i int) (int, int) { return u16(winFNTHeaderSize + 4*i), u16(winFNTHeaderSize + 4*i + 2) }
+	for i, want := range [][2]int{{5, bitsOffset}, {5, bitsOffset}, {3, bitsOffset + 8}, {4, bitsOffset + 16}} {
+		if w, off := entry(i); w != want[0] || off != want[1] {
+			t.Errorf("entry %d: width %d at %d, want %d at %d", i, w, off, want[0], want[1])
+		}
+	}
+
+	// Cells are as high as ascent plus descent, the box above the
+	// baseline and the bar one pixel in, reaching below it.
+	cells := data[bitsOffset : bitsOffset+24]
+	want := []byte{
+		0x00, 0x00, 0xF0, 0xF0, 0xF0, 0xF0, 0x00, 0x00,
+		0x00, 0x00, 0x40, 0x40, 0x40, 0x40, 0x40, 0x40,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}
+	if !bytes.Equal(cells, want) {
+		t.Errorf("cells\n% X\nwant\n% X", cells, want)
+	}
+	if face := data[u32(105):]; string(face) != "Test\x00" {
+		t.Errorf("face %q, want Test", face)
+	}
+}